@@ -0,0 +1,167 @@
+// backend/backend.go
+// last modified: Oct 16 2026
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound is returned (wrapped) when a domain does not exist
+var ErrNotFound = errors.New("domain not found")
+
+// Domain is one entry of the domain list
+type Domain struct {
+	ID    string // empty when the domain is not running
+	Name  string
+	State string // canonical: "running", "shut off", "paused", …
+}
+
+// DomainInfo holds the runtime facts of a single domain
+type DomainInfo struct {
+	State     string
+	VCPU      int
+	MemoryMiB int
+	Autostart bool
+}
+
+// Network is one libvirt virtual network
+type Network struct {
+	Name      string
+	Active    bool
+	Autostart bool
+}
+
+// Pool is one libvirt storage pool; sizes in bytes (0 while inactive)
+type Pool struct {
+	Name       string
+	Active     bool
+	Autostart  bool
+	Capacity   uint64
+	Allocation uint64
+	Available  uint64
+}
+
+// Volume is one volume of a storage pool; sizes in bytes
+type Volume struct {
+	Name       string
+	Path       string
+	Capacity   uint64
+	Allocation uint64
+}
+
+// DiskInfo describes an existing disk image or device (qemu-img info)
+type DiskInfo struct {
+	Format      string // raw, qcow2, vmdk, …
+	VirtualSize uint64 // bytes the guest sees
+	ActualSize  uint64 // bytes allocated on the host
+	Backing     string // absolute path of the backing image of an overlay
+}
+
+/*
+Backend is the single gateway to the hypervisor. Menus, the engine and
+the lab code only talk to this interface, never to virsh or qemu-img
+directly.
+*/
+type Backend interface {
+	// URI is the libvirt connection URI in use (empty = libvirt's default)
+	URI() string
+
+	// domains
+	ListDomains() ([]Domain, error)
+	DomainInfo(name string) (DomainInfo, error)
+	DomainDisks(name string) ([]string, error) // image paths of all disks (no CD-ROMs)
+	DumpXML(name string) ([]byte, error)       // live definition
+	DefinitionXML(name string) ([]byte, error) // persistent definition (what the next boot uses)
+	DefineXML(xml []byte) error
+	Start(name string) error
+	Reboot(name string) error
+	Shutdown(name string) error
+	Destroy(name string) error  // force off
+	Undefine(name string) error // removes the UEFI NVRAM and the TPM state, too
+	Rename(oldName, newName string) error
+
+	// virtual networks
+	ListNetworks() ([]Network, error)
+	NetworkXML(name string) ([]byte, error)
+	DefineNetwork(xml []byte) error
+	StartNetwork(name string) error
+	StopNetwork(name string) error
+	UndefineNetwork(name string) error
+	SetNetworkAutostart(name string, on bool) error
+
+	// storage pools and volumes
+	ListPools() ([]Pool, error)
+	PoolXML(name string) ([]byte, error)
+	DefinePool(xml []byte) error
+	BuildPool(name string) error // creates the target directory
+	StartPool(name string) error
+	StopPool(name string) error
+	DeletePool(name string) error // removes the (empty) target directory of an inactive pool
+	UndefinePool(name string) error
+	SetPoolAutostart(name string, on bool) error
+	ListVolumes(pool string) ([]Volume, error)                                // rescans the pool first
+	CreateVolume(pool, name string, sizeGiB int, format string) (bool, error) // false if the volume already existed
+	DeleteVolume(pool, name string) error
+
+	// disk images
+	DiskInfo(path string) (DiskInfo, error)
+	CreateDisk(path string, sizeGiB int, format string) (bool, error)             // false if the image already existed
+	CreateOverlay(path, backing, backingFormat string, sizeGiB int) (bool, error) // qcow2 on top of backing; size 0 = that of backing
+	ResizeDisk(path string, addGiB int) error
+	ConvertDisk(src, dst, format string) error
+	CheckDisk(path string) (string, error)  // report, error if inconsistent
+	RepairDisk(path string) (string, error) // report of the repair run
+}
+
+/*
+LocalURI reports whether uri points to the hypervisor on this machine
+(empty = libvirt's default). Disk images outside storage pools are plain
+files, qemu-img and the file system only reach them then.
+*/
+func LocalURI(uri string) bool {
+	_, rest, ok := strings.Cut(uri, "://")
+	if !ok {
+		return uri == ""
+	}
+	return strings.HasPrefix(rest, "/") // no host part
+}
+
+// CanonicalPath cleans path and resolves symlinks (as far as it exists on
+// this machine), so two spellings of one image compare equal
+func CanonicalPath(path string) string {
+	path = filepath.Clean(path)
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		return real
+	}
+	return path
+}
+
+/*
+DiskUsers maps every disk image (CanonicalPath) to the VMs that have it
+attached. The error tells that the map is incomplete – callers deciding
+about deleting or sharing an image must not trust it then.
+*/
+func DiskUsers(be Backend) (map[string][]string, error) {
+	users := map[string][]string{}
+	doms, err := be.ListDomains()
+	if err != nil {
+		return users, fmt.Errorf("list VMs: %w", err)
+	}
+	for _, d := range doms {
+		paths, err := be.DomainDisks(d.Name)
+		if errors.Is(err, ErrNotFound) {
+			continue // gone since the list was read
+		}
+		if err != nil {
+			return users, fmt.Errorf("disks of %s: %w", d.Name, err)
+		}
+		for _, path := range paths {
+			key := CanonicalPath(path)
+			users[key] = append(users[key], d.Name)
+		}
+	}
+	return users, nil
+}
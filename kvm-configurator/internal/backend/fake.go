@@ -0,0 +1,687 @@
+// backend/fake.go
+// last modified: Oct 16 2026
+package backend
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	// internal
+	"configurator/internal/domxml"
+	"configurator/internal/netxml"
+	"configurator/internal/poolxml"
+)
+
+// FakeDomain is the in-memory state of one domain
+type FakeDomain struct {
+	Name      string
+	ID        int // 0 while shut off
+	State     string
+	VCPU      int
+	MemoryMiB int
+	Autostart bool
+	Disks     []string
+	XML       []byte
+}
+
+// FakeNetwork is an in-memory virtual network
+type FakeNetwork struct {
+	Network
+	XML []byte
+}
+
+// FakePool is an in-memory storage pool; its volumes are the Images
+// inside Path
+type FakePool struct {
+	Pool
+	Path  string
+	Built bool // target directory exists
+	XML   []byte
+}
+
+// FakeImage is an in-memory disk image
+type FakeImage struct {
+	Format  string
+	SizeGiB int
+	Backing string // base image of an overlay
+	Corrupt bool   // CheckDisk fails until RepairDisk ran
+}
+
+/*
+Fake implements Backend completely in memory – for tests and demos.
+Every call is recorded in Calls ("start web-01", "define web-01", …).
+*/
+type Fake struct {
+	mu       sync.Mutex
+	Domains  map[string]*FakeDomain
+	Images   map[string]*FakeImage
+	Networks map[string]*FakeNetwork
+	Pools    map[string]*FakePool
+	Calls    []string
+	Host     string // set: the fake is a remote hypervisor on this host
+	nextID   int
+}
+
+// NewFake returns a fake hypervisor without domains; like a fresh libvirt
+// install it has the active NAT network and storage pool "default"
+func NewFake() *Fake {
+	return &Fake{
+		Domains: map[string]*FakeDomain{},
+		Images:  map[string]*FakeImage{},
+		Networks: map[string]*FakeNetwork{"default": {
+			Network: Network{Name: "default", Active: true, Autostart: true},
+			XML:     []byte(defaultNetworkXML),
+		}},
+		Pools: map[string]*FakePool{"default": {
+			Pool:  Pool{Name: "default", Active: true, Autostart: true},
+			Path:  "/var/lib/libvirt/images",
+			Built: true,
+			XML:   []byte(defaultPoolXML),
+		}},
+		nextID: 1,
+	}
+}
+
+// URI of the fake hypervisor (libvirt's own test driver uses test:///)
+func (f *Fake) URI() string {
+	if f.Host != "" {
+		return "test://" + f.Host + "/fake"
+	}
+	return "test:///fake"
+}
+
+func (f *Fake) record(format string, a ...any) {
+	f.Calls = append(f.Calls, fmt.Sprintf(format, a...))
+}
+
+// lookup must be called with f.mu held
+func (f *Fake) lookup(name string) (*FakeDomain, error) {
+	d, ok := f.Domains[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return d, nil
+}
+
+func (f *Fake) ListDomains() ([]Domain, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []Domain
+	for _, d := range f.Domains {
+		dom := Domain{Name: d.Name, State: d.State}
+		if d.ID > 0 {
+			dom.ID = strconv.Itoa(d.ID)
+		}
+		out = append(out, dom)
+	}
+	slices.SortFunc(out, func(a, b Domain) int { return strings.Compare(a.Name, b.Name) })
+	return out, nil
+}
+
+func (f *Fake) DomainInfo(name string) (DomainInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	d, err := f.lookup(name)
+	if err != nil {
+		return DomainInfo{}, err
+	}
+	return DomainInfo{State: d.State, VCPU: d.VCPU, MemoryMiB: d.MemoryMiB, Autostart: d.Autostart}, nil
+}
+
+func (f *Fake) DomainDisks(name string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	d, err := f.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(d.Disks), nil
+}
+
+func (f *Fake) DumpXML(name string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	d, err := f.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(d.XML), nil
+}
+
+// DefinitionXML – the fake has no live state, so same as DumpXML
+func (f *Fake) DefinitionXML(name string) ([]byte, error) {
+	return f.DumpXML(name)
+}
+
+func (f *Fake) DefineXML(data []byte) error {
+	x, err := domxml.Parse(data)
+	if err != nil {
+		return fmt.Errorf("invalid domain XML: %w", err)
+	}
+	if x.Name == "" {
+		return fmt.Errorf("invalid domain XML: missing <name>")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record("define %s", x.Name)
+
+	d, ok := f.Domains[x.Name]
+	if !ok {
+		d = &FakeDomain{Name: x.Name, State: "shut off"}
+		f.Domains[x.Name] = d
+	}
+	d.VCPU = x.VCPU.Value
+	d.MemoryMiB = int(x.Memory.MiB())
+	d.Disks = x.DiskPaths()
+	d.XML = slices.Clone(data)
+	return nil
+}
+
+// setState changes the state of an existing domain
+func (f *Fake) setState(op, name string, allowed func(*FakeDomain) error, apply func(*FakeDomain)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record("%s %s", op, name)
+	d, err := f.lookup(name)
+	if err != nil {
+		return err
+	}
+	if err := allowed(d); err != nil {
+		return fmt.Errorf("%s %s: %w", op, name, err)
+	}
+	apply(d)
+	return nil
+}
+
+func mustRun(d *FakeDomain) error {
+	if d.State != "running" {
+		return fmt.Errorf("domain is not running")
+	}
+	return nil
+}
+
+func mustBeOff(d *FakeDomain) error {
+	if d.State == "running" {
+		return fmt.Errorf("domain is already running")
+	}
+	return nil
+}
+
+func (f *Fake) Start(name string) error {
+	return f.setState("start", name, mustBeOff, func(d *FakeDomain) {
+		d.State, d.ID = "running", f.nextID
+		f.nextID++
+	})
+}
+
+func (f *Fake) Reboot(name string) error {
+	return f.setState("reboot", name, mustRun, func(*FakeDomain) {})
+}
+
+func (f *Fake) Shutdown(name string) error {
+	return f.setState("shutdown", name, mustRun, func(d *FakeDomain) { d.State, d.ID = "shut off", 0 })
+}
+
+func (f *Fake) Destroy(name string) error {
+	return f.setState("destroy", name, mustRun, func(d *FakeDomain) { d.State, d.ID = "shut off", 0 })
+}
+
+func (f *Fake) Undefine(name string) error {
+	return f.setState("undefine", name, func(*FakeDomain) error { return nil }, func(d *FakeDomain) {
+		delete(f.Domains, d.Name)
+	})
+}
+
+func (f *Fake) Rename(oldName, newName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record("rename %s %s", oldName, newName)
+	d, err := f.lookup(oldName)
+	if err != nil {
+		return err
+	}
+	if err := mustBeOff(d); err != nil {
+		return fmt.Errorf("rename %s: %w", oldName, err)
+	}
+	if _, taken := f.Domains[newName]; taken {
+		return fmt.Errorf("rename %s: domain %s already exists", oldName, newName)
+	}
+	// libvirt rewrites <name> in the stored definition as well
+	if len(d.XML) > 0 {
+		dom, err := domxml.Parse(d.XML)
+		if err != nil {
+			return fmt.Errorf("rename %s: %w", oldName, err)
+		}
+		dom.Name = newName
+		if d.XML, err = dom.Marshal(); err != nil {
+			return fmt.Errorf("rename %s: %w", oldName, err)
+		}
+	}
+	delete(f.Domains, oldName)
+	d.Name = newName
+	f.Domains[newName] = d
+	return nil
+}
+
+// defaultNetworkXML is the network libvirt ships
+const defaultNetworkXML = `<network>
+  <name>default</name>
+  <forward mode="nat"/>
+  <bridge name="virbr0" stp="on" delay="0"/>
+  <ip address="192.168.122.1" netmask="255.255.255.0">
+    <dhcp>
+      <range start="192.168.122.2" end="192.168.122.254"/>
+    </dhcp>
+  </ip>
+</network>
+`
+
+func (f *Fake) ListNetworks() ([]Network, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]Network, 0, len(f.Networks))
+	for _, n := range f.Networks {
+		out = append(out, n.Network)
+	}
+	slices.SortFunc(out, func(a, b Network) int { return strings.Compare(a.Name, b.Name) })
+	return out, nil
+}
+
+// network must be called with f.mu held
+func (f *Fake) network(name string) (*FakeNetwork, error) {
+	n, ok := f.Networks[name]
+	if !ok {
+		return nil, fmt.Errorf("network %s: %w", name, ErrNotFound)
+	}
+	return n, nil
+}
+
+func (f *Fake) NetworkXML(name string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n, err := f.network(name)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(n.XML), nil
+}
+
+func (f *Fake) DefineNetwork(data []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	x, err := netxml.Parse(data)
+	if err != nil {
+		return fmt.Errorf("define network: %w", err)
+	}
+	if x.Name == "" {
+		return fmt.Errorf("invalid network XML: missing <name>")
+	}
+	f.record("net-define %s", x.Name)
+	n, ok := f.Networks[x.Name]
+	if !ok {
+		n = &FakeNetwork{Network: Network{Name: x.Name}}
+		f.Networks[x.Name] = n
+	}
+	n.XML = slices.Clone(data)
+	return nil
+}
+
+// setNetwork runs a state change on a network (f.mu held inside)
+func (f *Fake) setNetwork(op, name string, apply func(*FakeNetwork) error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record("%s %s", op, name)
+	n, err := f.network(name)
+	if err != nil {
+		return err
+	}
+	return apply(n)
+}
+
+func (f *Fake) StartNetwork(name string) error {
+	return f.setNetwork("net-start", name, func(n *FakeNetwork) error {
+		if n.Active {
+			return fmt.Errorf("net-start %s: network is already active", name)
+		}
+		n.Active = true
+		return nil
+	})
+}
+
+func (f *Fake) StopNetwork(name string) error {
+	return f.setNetwork("net-destroy", name, func(n *FakeNetwork) error {
+		if !n.Active {
+			return fmt.Errorf("net-destroy %s: network is not active", name)
+		}
+		n.Active = false
+		return nil
+	})
+}
+
+func (f *Fake) UndefineNetwork(name string) error {
+	return f.setNetwork("net-undefine", name, func(n *FakeNetwork) error {
+		delete(f.Networks, name)
+		return nil
+	})
+}
+
+func (f *Fake) SetNetworkAutostart(name string, on bool) error {
+	return f.setNetwork("net-autostart", name, func(n *FakeNetwork) error {
+		n.Autostart = on
+		return nil
+	})
+}
+
+// defaultPoolXML is the image directory virt-manager sets up
+const defaultPoolXML = `<pool type="dir">
+  <name>default</name>
+  <target>
+    <path>/var/lib/libvirt/images</path>
+  </target>
+</pool>
+`
+
+// fakePoolSize is the capacity every fake pool reports
+const fakePoolSize = 500 << 30
+
+// fakeQcow2Allocation is what a fresh qcow2 image occupies
+const fakeQcow2Allocation = 196 << 10
+
+// volumes returns the images inside the pool directory (f.mu held)
+func (f *Fake) volumes(p *FakePool) []Volume {
+	var out []Volume
+	for path, img := range f.Images {
+		if filepath.Dir(path) != p.Path {
+			continue
+		}
+		out = append(out, Volume{
+			Name:       filepath.Base(path),
+			Path:       path,
+			Capacity:   uint64(img.SizeGiB) << 30,
+			Allocation: fakeQcow2Allocation,
+		})
+	}
+	slices.SortFunc(out, func(a, b Volume) int { return strings.Compare(a.Name, b.Name) })
+	return out
+}
+
+func (f *Fake) ListPools() ([]Pool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]Pool, 0, len(f.Pools))
+	for _, p := range f.Pools {
+		pool := p.Pool
+		if pool.Active {
+			pool.Capacity = fakePoolSize
+			for _, v := range f.volumes(p) {
+				pool.Allocation += v.Allocation
+			}
+			pool.Available = pool.Capacity - pool.Allocation
+		}
+		out = append(out, pool)
+	}
+	slices.SortFunc(out, func(a, b Pool) int { return strings.Compare(a.Name, b.Name) })
+	return out, nil
+}
+
+// pool must be called with f.mu held
+func (f *Fake) pool(name string) (*FakePool, error) {
+	p, ok := f.Pools[name]
+	if !ok {
+		return nil, fmt.Errorf("pool %s: %w", name, ErrNotFound)
+	}
+	return p, nil
+}
+
+func (f *Fake) PoolXML(name string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, err := f.pool(name)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(p.XML), nil
+}
+
+func (f *Fake) DefinePool(data []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	x, err := poolxml.Parse(data)
+	if err != nil {
+		return fmt.Errorf("define pool: %w", err)
+	}
+	if x.Name == "" {
+		return fmt.Errorf("invalid pool XML: missing <name>")
+	}
+	f.record("pool-define %s", x.Name)
+	p, ok := f.Pools[x.Name]
+	if !ok {
+		p = &FakePool{Pool: Pool{Name: x.Name}}
+		f.Pools[x.Name] = p
+	}
+	p.XML, p.Path = slices.Clone(data), x.Path()
+	return nil
+}
+
+// setPool runs a state change on a pool (f.mu held inside)
+func (f *Fake) setPool(op, name string, apply func(*FakePool) error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record("%s %s", op, name)
+	p, err := f.pool(name)
+	if err != nil {
+		return err
+	}
+	return apply(p)
+}
+
+func (f *Fake) BuildPool(name string) error {
+	return f.setPool("pool-build", name, func(p *FakePool) error {
+		p.Built = true
+		return nil
+	})
+}
+
+func (f *Fake) StartPool(name string) error {
+	return f.setPool("pool-start", name, func(p *FakePool) error {
+		switch {
+		case p.Active:
+			return fmt.Errorf("pool-start %s: pool is already active", name)
+		case !p.Built:
+			return fmt.Errorf("pool-start %s: cannot open directory %s", name, p.Path)
+		}
+		p.Active = true
+		return nil
+	})
+}
+
+func (f *Fake) StopPool(name string) error {
+	return f.setPool("pool-destroy", name, func(p *FakePool) error {
+		if !p.Active {
+			return fmt.Errorf("pool-destroy %s: pool is not active", name)
+		}
+		p.Active = false
+		return nil
+	})
+}
+
+func (f *Fake) DeletePool(name string) error {
+	return f.setPool("pool-delete", name, func(p *FakePool) error {
+		switch {
+		case p.Active:
+			return fmt.Errorf("pool-delete %s: pool is still active", name)
+		case len(f.volumes(p)) > 0:
+			return fmt.Errorf("pool-delete %s: directory %s is not empty", name, p.Path)
+		}
+		p.Built = false
+		return nil
+	})
+}
+
+func (f *Fake) UndefinePool(name string) error {
+	return f.setPool("pool-undefine", name, func(p *FakePool) error {
+		delete(f.Pools, name)
+		return nil
+	})
+}
+
+func (f *Fake) SetPoolAutostart(name string, on bool) error {
+	return f.setPool("pool-autostart", name, func(p *FakePool) error {
+		p.Autostart = on
+		return nil
+	})
+}
+
+func (f *Fake) ListVolumes(pool string) ([]Volume, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, err := f.pool(pool)
+	if err != nil {
+		return nil, err
+	}
+	if !p.Active {
+		return nil, fmt.Errorf("vol-list %s: pool is not active", pool)
+	}
+	return f.volumes(p), nil
+}
+
+func (f *Fake) CreateVolume(pool, name string, sizeGiB int, format string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record("vol-create-as %s %s %d %s", pool, name, sizeGiB, format)
+	p, err := f.pool(pool)
+	if err != nil {
+		return false, err
+	}
+	if !p.Active {
+		return false, fmt.Errorf("vol-create-as %s: pool is not active", pool)
+	}
+	path := filepath.Join(p.Path, name)
+	if _, ok := f.Images[path]; ok {
+		return false, nil
+	}
+	f.Images[path] = &FakeImage{Format: format, SizeGiB: sizeGiB}
+	return true, nil
+}
+
+func (f *Fake) DeleteVolume(pool, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record("vol-delete %s %s", pool, name)
+	p, err := f.pool(pool)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(p.Path, name)
+	if _, ok := f.Images[path]; !ok {
+		return fmt.Errorf("volume %s: %w", name, ErrNotFound)
+	}
+	delete(f.Images, path)
+	return nil
+}
+
+func (f *Fake) CreateDisk(path string, sizeGiB int, format string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record("create-disk %s %d %s", path, sizeGiB, format)
+	if _, ok := f.Images[path]; ok {
+		return false, nil
+	}
+	f.Images[path] = &FakeImage{Format: format, SizeGiB: sizeGiB}
+	return true, nil
+}
+
+func (f *Fake) DiskInfo(path string) (DiskInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	img, err := f.image(path)
+	if err != nil {
+		return DiskInfo{}, err
+	}
+	return DiskInfo{Format: img.Format, VirtualSize: uint64(img.SizeGiB) << 30, ActualSize: fakeQcow2Allocation, Backing: img.Backing}, nil
+}
+
+func (f *Fake) CreateOverlay(path, backing, backingFormat string, sizeGiB int) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record("create-overlay %s %s %s %d", path, backing, backingFormat, sizeGiB)
+	base, err := f.image(backing)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := f.Images[path]; ok {
+		return false, nil
+	}
+	f.Images[path] = &FakeImage{Format: "qcow2", SizeGiB: max(sizeGiB, base.SizeGiB), Backing: backing}
+	return true, nil
+}
+
+// image must be called with f.mu held
+func (f *Fake) image(path string) (*FakeImage, error) {
+	img, ok := f.Images[path]
+	if !ok {
+		return nil, fmt.Errorf("image %s not found", path)
+	}
+	return img, nil
+}
+
+func (f *Fake) ResizeDisk(path string, addGiB int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record("resize %s +%d", path, addGiB)
+	img, err := f.image(path)
+	if err != nil {
+		return err
+	}
+	img.SizeGiB += addGiB
+	return nil
+}
+
+func (f *Fake) ConvertDisk(src, dst, format string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record("convert %s %s %s", src, dst, format)
+	img, err := f.image(src)
+	if err != nil {
+		return err
+	}
+	f.Images[dst] = &FakeImage{Format: format, SizeGiB: img.SizeGiB}
+	return nil
+}
+
+func (f *Fake) CheckDisk(path string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record("check %s", path)
+	img, err := f.image(path)
+	if err != nil {
+		return "", err
+	}
+	if img.Corrupt {
+		return "1 errors were found on the image.", fmt.Errorf("image %s is corrupt", path)
+	}
+	return "No errors were found on the image.", nil
+}
+
+func (f *Fake) RepairDisk(path string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record("repair %s", path)
+	img, err := f.image(path)
+	if err != nil {
+		return "", err
+	}
+	img.Corrupt = false
+	return "The following inconsistencies were found and repaired: 1", nil
+}
+
+// compile-time interface checks
+var (
+	_ Backend = (*Shell)(nil)
+	_ Backend = (*Fake)(nil)
+	_ Backend = (*Native)(nil)
+)
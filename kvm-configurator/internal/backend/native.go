@@ -0,0 +1,425 @@
+// backend/native.go
+// last modified: Oct 16 2026
+package backend
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	// internal
+	"configurator/internal/domxml"
+	"configurator/internal/libvirt"
+	"configurator/internal/poolxml"
+)
+
+/*
+Native talks the libvirt remote protocol directly over the daemon socket.
+Domain operations never fork virsh; disk image work (qemu-img) is still
+delegated to the embedded Shell backend.
+*/
+type Native struct {
+	*Shell
+	conn *libvirt.Conn
+}
+
+// NewNative connects to socket (empty = default location for uri) and opens uri;
+// only local URIs work, remote ones need the shell backend
+func NewNative(socket, uri string) (*Native, error) {
+	if uri == "" {
+		uri = libvirt.DefaultURI
+	}
+	conn, err := libvirt.Connect(socket, uri)
+	if err != nil {
+		return nil, err
+	}
+	return &Native{Shell: NewShell(uri), conn: conn}, nil
+}
+
+// NewNativeConn wraps an already opened connection
+func NewNativeConn(conn *libvirt.Conn, uri string) *Native {
+	return &Native{Shell: NewShell(uri), conn: conn}
+}
+
+// Close ends the libvirt session
+func (n *Native) Close() error { return n.conn.Close() }
+
+// wrap adds the domain name and maps "no such domain" to ErrNotFound;
+// the *libvirt.Error stays in the chain so callers can inspect the code
+func wrap(op, name string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if libvirt.IsNotFound(err) {
+		return fmt.Errorf("%s %s: %w (%w)", op, name, ErrNotFound, err)
+	}
+	return fmt.Errorf("%s %s: %w", op, name, err)
+}
+
+// stateNames – virDomainState in the spelling virsh uses
+var stateNames = map[uint8]string{
+	libvirt.StateNoState:     "no state",
+	libvirt.StateRunning:     "running",
+	libvirt.StateBlocked:     "idle",
+	libvirt.StatePaused:      "paused",
+	libvirt.StateShutdown:    "in shutdown",
+	libvirt.StateShutoff:     "shut off",
+	libvirt.StateCrashed:     "crashed",
+	libvirt.StatePMSuspended: "pmsuspended",
+}
+
+func stateName(s uint8) string {
+	if name, ok := stateNames[s]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+func (n *Native) lookup(name string) (libvirt.Domain, error) {
+	dom, err := n.conn.LookupByName(name)
+	return dom, wrap("lookup", name, err)
+}
+
+func (n *Native) ListDomains() ([]Domain, error) {
+	doms, err := n.conn.ListAllDomains()
+	if err != nil {
+		return nil, fmt.Errorf("list domains: %w", err)
+	}
+	out := make([]Domain, 0, len(doms))
+	for _, d := range doms {
+		info, err := n.conn.GetInfo(d)
+		if libvirt.IsNotFound(err) {
+			continue // undefined since the list was read
+		}
+		if err != nil {
+			return nil, wrap("info", d.Name, err)
+		}
+		dom := Domain{Name: d.Name, State: stateName(info.State)}
+		if d.ID > 0 {
+			dom.ID = strconv.Itoa(int(d.ID))
+		}
+		out = append(out, dom)
+	}
+	// running first, like virsh list --all
+	slices.SortStableFunc(out, func(a, b Domain) int {
+		if (a.ID == "") != (b.ID == "") {
+			if a.ID != "" {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return out, nil
+}
+
+// ListNetworks asks for the active and the autostart networks separately –
+// the listing itself carries no state
+func (n *Native) ListNetworks() ([]Network, error) {
+	all, err := n.conn.ListAllNetworks(0)
+	if err != nil {
+		return nil, fmt.Errorf("list networks: %w", err)
+	}
+	active, err := n.conn.ListAllNetworks(libvirt.ListNetworksActive)
+	if err != nil {
+		return nil, fmt.Errorf("list networks: %w", err)
+	}
+	auto, err := n.conn.ListAllNetworks(libvirt.ListNetworksAutostart)
+	if err != nil {
+		return nil, fmt.Errorf("list networks: %w", err)
+	}
+	has := func(list []libvirt.Network, name string) bool {
+		return slices.ContainsFunc(list, func(nw libvirt.Network) bool { return nw.Name == name })
+	}
+	out := make([]Network, 0, len(all))
+	for _, nw := range all {
+		out = append(out, Network{Name: nw.Name, Active: has(active, nw.Name), Autostart: has(auto, nw.Name)})
+	}
+	slices.SortFunc(out, func(a, b Network) int { return strings.Compare(a.Name, b.Name) })
+	return out, nil
+}
+
+// networkOp resolves a network and runs op on it
+func (n *Native) networkOp(verb, name string, op func(libvirt.Network) error) error {
+	nw, err := n.conn.NetworkLookupByName(name)
+	if err == nil {
+		err = op(nw)
+	}
+	if err != nil {
+		return fmt.Errorf("%s network %s: %w", verb, name, err)
+	}
+	return nil
+}
+
+func (n *Native) NetworkXML(name string) ([]byte, error) {
+	var out []byte
+	err := n.networkOp("dumpxml", name, func(nw libvirt.Network) error {
+		var err error
+		out, err = n.conn.NetworkGetXMLDesc(nw, 0)
+		return err
+	})
+	return out, err
+}
+
+func (n *Native) DefineNetwork(xml []byte) error {
+	if _, err := n.conn.NetworkDefineXML(xml); err != nil {
+		return fmt.Errorf("define network: %w", err)
+	}
+	return nil
+}
+
+func (n *Native) StartNetwork(name string) error {
+	return n.networkOp("start", name, n.conn.NetworkCreate)
+}
+
+func (n *Native) StopNetwork(name string) error {
+	return n.networkOp("stop", name, n.conn.NetworkDestroy)
+}
+
+func (n *Native) UndefineNetwork(name string) error {
+	return n.networkOp("undefine", name, n.conn.NetworkUndefine)
+}
+
+func (n *Native) SetNetworkAutostart(name string, on bool) error {
+	return n.networkOp("autostart", name, func(nw libvirt.Network) error {
+		return n.conn.NetworkSetAutostart(nw, on)
+	})
+}
+
+// ListPools reads state and sizes of every pool with GetInfo
+func (n *Native) ListPools() ([]Pool, error) {
+	all, err := n.conn.ListAllStoragePools()
+	if err != nil {
+		return nil, fmt.Errorf("list pools: %w", err)
+	}
+	out := make([]Pool, 0, len(all))
+	for _, p := range all {
+		info, err := n.conn.StoragePoolGetInfo(p)
+		if err != nil {
+			return nil, fmt.Errorf("pool %s: %w", p.Name, err)
+		}
+		auto, err := n.conn.StoragePoolGetAutostart(p)
+		if err != nil {
+			return nil, fmt.Errorf("pool %s: %w", p.Name, err)
+		}
+		out = append(out, Pool{
+			Name:       p.Name,
+			Active:     info.State == libvirt.PoolRunning || info.State == libvirt.PoolDegraded,
+			Autostart:  auto,
+			Capacity:   info.Capacity,
+			Allocation: info.Allocation,
+			Available:  info.Available,
+		})
+	}
+	slices.SortFunc(out, func(a, b Pool) int { return strings.Compare(a.Name, b.Name) })
+	return out, nil
+}
+
+// poolOp resolves a storage pool and runs op on it
+func (n *Native) poolOp(verb, name string, op func(libvirt.StoragePool) error) error {
+	p, err := n.conn.StoragePoolLookupByName(name)
+	if err == nil {
+		err = op(p)
+	}
+	if err != nil {
+		return fmt.Errorf("%s pool %s: %w", verb, name, err)
+	}
+	return nil
+}
+
+func (n *Native) PoolXML(name string) ([]byte, error) {
+	var out []byte
+	err := n.poolOp("dumpxml", name, func(p libvirt.StoragePool) error {
+		var err error
+		out, err = n.conn.StoragePoolGetXMLDesc(p, 0)
+		return err
+	})
+	return out, err
+}
+
+func (n *Native) DefinePool(xml []byte) error {
+	if _, err := n.conn.StoragePoolDefineXML(xml); err != nil {
+		return fmt.Errorf("define pool: %w", err)
+	}
+	return nil
+}
+
+func (n *Native) BuildPool(name string) error {
+	return n.poolOp("build", name, n.conn.StoragePoolBuild)
+}
+
+func (n *Native) StartPool(name string) error {
+	return n.poolOp("start", name, n.conn.StoragePoolCreate)
+}
+
+func (n *Native) StopPool(name string) error {
+	return n.poolOp("stop", name, n.conn.StoragePoolDestroy)
+}
+
+func (n *Native) DeletePool(name string) error {
+	return n.poolOp("delete", name, n.conn.StoragePoolDelete)
+}
+
+func (n *Native) UndefinePool(name string) error {
+	return n.poolOp("undefine", name, n.conn.StoragePoolUndefine)
+}
+
+func (n *Native) SetPoolAutostart(name string, on bool) error {
+	return n.poolOp("autostart", name, func(p libvirt.StoragePool) error {
+		return n.conn.StoragePoolSetAutostart(p, on)
+	})
+}
+
+func (n *Native) ListVolumes(pool string) ([]Volume, error) {
+	var out []Volume
+	err := n.poolOp("list volumes of", pool, func(p libvirt.StoragePool) error {
+		if err := n.conn.StoragePoolRefresh(p); err != nil {
+			return err
+		}
+		vols, err := n.conn.StoragePoolListAllVolumes(p)
+		if err != nil {
+			return err
+		}
+		for _, v := range vols {
+			info, err := n.conn.StorageVolGetInfo(v)
+			if err != nil {
+				return fmt.Errorf("volume %s: %w", v.Name, err)
+			}
+			path, err := n.conn.StorageVolGetPath(v)
+			if err != nil {
+				return fmt.Errorf("volume %s: %w", v.Name, err)
+			}
+			out = append(out, Volume{Name: v.Name, Path: path, Capacity: info.Capacity, Allocation: info.Allocation})
+		}
+		return nil
+	})
+	slices.SortFunc(out, func(a, b Volume) int { return strings.Compare(a.Name, b.Name) })
+	return out, err
+}
+
+// CreateVolume refreshes the pool and looks the volume up first; only
+// "no such volume" leads to a new one
+func (n *Native) CreateVolume(pool, name string, sizeGiB int, format string) (bool, error) {
+	created := false
+	err := n.poolOp("create volume in", pool, func(p libvirt.StoragePool) error {
+		if err := n.conn.StoragePoolRefresh(p); err != nil {
+			return err
+		}
+		_, err := n.conn.StorageVolLookupByName(p, name)
+		if err == nil || !libvirt.IsNotFound(err) {
+			return err
+		}
+		xml, err := poolxml.NewVolume(name, sizeGiB, format).Marshal()
+		if err != nil {
+			return err
+		}
+		if _, err := n.conn.StorageVolCreateXML(p, xml); err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	return created, err
+}
+
+func (n *Native) DeleteVolume(pool, name string) error {
+	return n.poolOp("delete volume in", pool, func(p libvirt.StoragePool) error {
+		v, err := n.conn.StorageVolLookupByName(p, name)
+		if err != nil {
+			return err
+		}
+		return n.conn.StorageVolDelete(v)
+	})
+}
+
+func (n *Native) DomainInfo(name string) (DomainInfo, error) {
+	dom, err := n.lookup(name)
+	if err != nil {
+		return DomainInfo{}, err
+	}
+	info, err := n.conn.GetInfo(dom)
+	if err != nil {
+		return DomainInfo{}, wrap("info", name, err)
+	}
+	auto, err := n.conn.GetAutostart(dom)
+	if err != nil {
+		return DomainInfo{}, wrap("autostart", name, err)
+	}
+	return DomainInfo{
+		State:     stateName(info.State),
+		VCPU:      int(info.VCPUs),
+		MemoryMiB: int(info.MaxMemKiB / 1024),
+		Autostart: auto,
+	}, nil
+}
+
+func (n *Native) DomainDisks(name string) ([]string, error) {
+	raw, err := n.DumpXML(name)
+	if err != nil {
+		return nil, err
+	}
+	dom, err := domxml.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("XML of %s: %w", name, err)
+	}
+	return dom.DiskPaths(), nil
+}
+
+func (n *Native) DumpXML(name string) ([]byte, error) {
+	dom, err := n.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := n.conn.GetXMLDesc(dom, 0)
+	return raw, wrap("dumpxml", name, err)
+}
+
+// DefinitionXML – the persistent definition (VIR_DOMAIN_XML_INACTIVE)
+func (n *Native) DefinitionXML(name string) ([]byte, error) {
+	dom, err := n.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := n.conn.GetXMLDesc(dom, libvirt.XMLInactive)
+	return raw, wrap("dumpxml --inactive", name, err)
+}
+
+func (n *Native) DefineXML(xml []byte) error {
+	_, err := n.conn.DefineXML(xml)
+	if err != nil {
+		return fmt.Errorf("define: %w", err)
+	}
+	return nil
+}
+
+// domainOp resolves the name and runs op on it
+func (n *Native) domainOp(verb, name string, op func(libvirt.Domain) error) error {
+	dom, err := n.lookup(name)
+	if err != nil {
+		return err
+	}
+	return wrap(verb, name, op(dom))
+}
+
+func (n *Native) Start(name string) error    { return n.domainOp("start", name, n.conn.Create) }
+func (n *Native) Reboot(name string) error   { return n.domainOp("reboot", name, n.conn.Reboot) }
+func (n *Native) Shutdown(name string) error { return n.domainOp("shutdown", name, n.conn.Shutdown) }
+func (n *Native) Destroy(name string) error  { return n.domainOp("destroy", name, n.conn.Destroy) }
+
+func (n *Native) Undefine(name string) error {
+	return n.domainOp("undefine", name, func(d libvirt.Domain) error {
+		// older daemons reject the TPM flag (and remove the state anyway)
+		err := n.conn.UndefineFlags(d, libvirt.UndefineNVRAM|libvirt.UndefineTPM)
+		if err == nil || !libvirt.IsUnsupportedFlags(err) {
+			return err
+		}
+		return n.conn.UndefineFlags(d, libvirt.UndefineNVRAM)
+	})
+}
+
+func (n *Native) Rename(oldName, newName string) error {
+	return n.domainOp("rename", oldName, func(d libvirt.Domain) error {
+		return n.conn.Rename(d, newName)
+	})
+}
@@ -0,0 +1,399 @@
+// backend/native_test.go
+// last modified: Oct 16 2026
+package backend
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"slices"
+	"testing"
+
+	// internal
+	"configurator/internal/libvirt"
+)
+
+// standIn connects a Native backend to srv over an in-memory pipe
+func standIn(t *testing.T, srv *libvirt.Server) *Native {
+	t.Helper()
+	client, server := net.Pipe()
+	go srv.ServeConn(server)
+	conn := libvirt.NewConn(client)
+	if types, err := conn.AuthList(); err != nil || !slices.Equal(types, []int32{libvirt.AuthNone}) {
+		t.Fatalf("auth list %v, %v", types, err)
+	}
+	if err := conn.ConnectOpen("qemu:///system"); err != nil {
+		t.Fatal(err)
+	}
+	n := NewNativeConn(conn, "qemu:///system")
+	t.Cleanup(func() { n.Close() })
+	return n
+}
+
+func domainXML(name string) []byte {
+	return []byte(`<domain type="kvm"><name>` + name + `</name><memory unit="MiB">2048</memory><vcpu>2</vcpu></domain>`)
+}
+
+// libvirtCode is the code of the *libvirt.Error in err's chain (0 = none)
+func libvirtCode(err error) int32 {
+	var le *libvirt.Error
+	if errors.As(err, &le) {
+		return le.Code
+	}
+	return 0
+}
+
+func TestNativeDefineStartList(t *testing.T) {
+	st := libvirt.NewStandIn()
+	n := standIn(t, st.Server)
+
+	for _, name := range []string{"web", "db"} {
+		if err := n.DefineXML(domainXML(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := n.Start("web"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := n.ListDomains()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Domain{
+		{ID: "1", Name: "web", State: "running"}, // running first, like virsh
+		{ID: "", Name: "db", State: "shut off"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ListDomains = %v, want %v", got, want)
+	}
+
+	info, err := n.DomainInfo("db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info != (DomainInfo{State: "shut off", VCPU: 2, MemoryMiB: 2048}) {
+		t.Errorf("DomainInfo(db) = %+v", info)
+	}
+	raw, err := n.DefinitionXML("db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(raw, domainXML("db")) {
+		t.Errorf("DefinitionXML(db) = %s", raw)
+	}
+}
+
+func TestNativeErrors(t *testing.T) {
+	st := libvirt.NewStandIn()
+	n := standIn(t, st.Server)
+	if err := n.DefineXML(domainXML("web")); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Start("web"); err != nil {
+		t.Fatal(err)
+	}
+
+	err := n.Start("missing")
+	if !errors.Is(err, ErrNotFound) || libvirtCode(err) != libvirt.ErrNoDomain {
+		t.Errorf("start of a missing domain: %v", err)
+	}
+	err = n.Start("web")
+	if errors.Is(err, ErrNotFound) || libvirtCode(err) != libvirt.ErrOperationInvalid {
+		t.Errorf("start of a running domain: %v", err)
+	}
+	if err := n.DefineXML([]byte("<domain>")); libvirtCode(err) != 27 { // VIR_ERR_XML_ERROR
+		t.Errorf("define of broken XML: %v", err)
+	}
+	if err := n.StartNetwork("default"); libvirtCode(err) != libvirt.ErrOperationInvalid {
+		t.Errorf("start of an active network: %v", err)
+	}
+	if _, err := n.PoolXML("missing"); !libvirt.IsNotFound(err) || libvirtCode(err) != libvirt.ErrNoStoragePool {
+		t.Errorf("XML of a missing pool: %v", err)
+	}
+
+	// a bare server: unknown procedures and plain Go errors
+	srv := libvirt.NewServer()
+	srv.Handle(libvirt.ProcConnectListAllNetworks, func(*libvirt.Decoder) ([]byte, error) {
+		return nil, errors.New("disk on fire")
+	})
+	bare := standIn(t, srv)
+	if _, err := bare.ListDomains(); libvirtCode(err) != 3 { // VIR_ERR_NO_SUPPORT
+		t.Errorf("unknown procedure: %v", err)
+	}
+	if _, err := bare.ListNetworks(); libvirtCode(err) != 1 || !bytes.Contains([]byte(err.Error()), []byte("disk on fire")) {
+		t.Errorf("internal error: %v", err)
+	}
+}
+
+func TestNativeListNetworks(t *testing.T) {
+	st := libvirt.NewStandIn()
+	n := standIn(t, st.Server)
+	if err := n.DefineNetwork([]byte(`<network><name>lab</name></network>`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.SetNetworkAutostart("lab", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.DefineNetwork([]byte(`<network><name>iso</name></network>`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.StartNetwork("iso"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := n.ListNetworks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Network{
+		{Name: "default", Active: true, Autostart: true},
+		{Name: "iso", Active: true},
+		{Name: "lab", Autostart: true},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ListNetworks = %v, want %v", got, want)
+	}
+}
+
+func TestNativeListPools(t *testing.T) {
+	st := libvirt.NewStandIn()
+	n := standIn(t, st.Server)
+	if err := n.DefinePool([]byte(`<pool type="dir"><name>iso</name><target><path>/srv/iso</path></target></pool>`)); err != nil {
+		t.Fatal(err)
+	}
+	// the second call finds the volume and leaves it alone
+	for i, want := range []bool{true, false} {
+		created, err := n.CreateVolume("default", "web.qcow2", 10, "qcow2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if created != want {
+			t.Errorf("CreateVolume #%d: created %v", i+1, created)
+		}
+	}
+
+	got, err := n.ListPools()
+	if err != nil {
+		t.Fatal(err)
+	}
+	const size = 500 << 30
+	want := []Pool{
+		{Name: "default", Active: true, Autostart: true, Capacity: size, Allocation: 196 << 10, Available: size - 196<<10},
+		{Name: "iso"}, // defined, not started: no sizes
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ListPools = %v, want %v", got, want)
+	}
+
+	vols, err := n.ListVolumes("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantVols := []Volume{{Name: "web.qcow2", Path: "/var/lib/libvirt/images/web.qcow2", Capacity: 10 << 30, Allocation: 196 << 10}}
+	if !slices.Equal(vols, wantVols) {
+		t.Errorf("ListVolumes = %v, want %v", vols, wantVols)
+	}
+	if _, err := n.ListVolumes("iso"); libvirtCode(err) != libvirt.ErrOperationInvalid {
+		t.Errorf("volumes of an inactive pool: %v", err)
+	}
+
+	if err := n.DeleteVolume("default", "web.qcow2"); err != nil {
+		t.Fatal(err)
+	}
+	if vols, err := n.ListVolumes("default"); err != nil || len(vols) != 0 {
+		t.Errorf("after DeleteVolume: %v, %v", vols, err)
+	}
+	if err := n.DeleteVolume("default", "web.qcow2"); !libvirt.IsNotFound(err) {
+		t.Errorf("delete of a missing volume: %v", err)
+	}
+}
+
+func TestNativeUndefineTPMFallback(t *testing.T) {
+	// a daemon that knows VIR_DOMAIN_UNDEFINE_TPM
+	st := libvirt.NewStandIn()
+	n := standIn(t, st.Server)
+	if err := n.DefineXML(domainXML("web")); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Undefine("web"); err != nil {
+		t.Fatal(err)
+	}
+	if doms := st.Domains(); len(doms) != 0 {
+		t.Errorf("still defined: %v", doms)
+	}
+
+	unsupported := &libvirt.Error{Code: libvirt.ErrInvalidArg, Message: "invalid argument: unsupported flags (0x20) in function qemuDomainUndefineFlags"}
+	for _, tc := range []struct {
+		name      string
+		fail      func(flags uint32) error // answer of the daemon, nil = undefined
+		wantFlags []uint32
+		wantCode  int32 // of the returned error, 0 = none
+	}{
+		// older daemons reject the unknown flag: once more without it
+		{"without TPM flag", func(f uint32) error {
+			if f&libvirt.UndefineTPM != 0 {
+				return unsupported
+			}
+			return nil
+		}, []uint32{36, 4}, 0},
+		{"no support", func(f uint32) error {
+			if f&libvirt.UndefineTPM != 0 {
+				return &libvirt.Error{Code: libvirt.ErrNoSupport, Message: "this function is not supported by the connection driver"}
+			}
+			return nil
+		}, []uint32{36, 4}, 0},
+		{"retry failing", func(uint32) error { return unsupported }, []uint32{36, 4}, libvirt.ErrInvalidArg},
+		// every other error is the answer – no second call
+		{"missing domain", func(uint32) error {
+			return &libvirt.Error{Code: libvirt.ErrNoDomain, Message: "Domain not found: no domain with matching name 'web'"}
+		}, []uint32{36}, libvirt.ErrNoDomain},
+		{"access denied", func(uint32) error {
+			return &libvirt.Error{Code: 88, Message: "access denied: 'undefine' not allowed"} // VIR_ERR_ACCESS_DENIED
+		}, []uint32{36}, 88},
+		{"invalid argument", func(uint32) error {
+			return &libvirt.Error{Code: libvirt.ErrInvalidArg, Message: "invalid argument: cannot undefine domain with nvram"}
+		}, []uint32{36}, libvirt.ErrInvalidArg},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			st := libvirt.NewStandIn()
+			var flags []uint32
+			st.Handle(libvirt.ProcDomainUndefineFlags, func(args *libvirt.Decoder) ([]byte, error) {
+				args.Domain()
+				f := args.Uint32()
+				flags = append(flags, f)
+				if err := tc.fail(f); err != nil {
+					return nil, err
+				}
+				return nil, args.Err()
+			})
+			n := standIn(t, st.Server)
+			if err := n.DefineXML(domainXML("web")); err != nil {
+				t.Fatal(err)
+			}
+			err := n.Undefine("web")
+			if libvirtCode(err) != tc.wantCode || (err != nil) != (tc.wantCode != 0) {
+				t.Errorf("Undefine: %v, want code %d", err, tc.wantCode)
+			}
+			if tc.wantCode == libvirt.ErrNoDomain && !errors.Is(err, ErrNotFound) {
+				t.Errorf("missing domain not ErrNotFound: %v", err)
+			}
+			if !slices.Equal(flags, tc.wantFlags) {
+				t.Errorf("undefine flags %v, want %v", flags, tc.wantFlags)
+			}
+		})
+	}
+}
+
+func TestNativeListDomainsSkipsVanished(t *testing.T) {
+	st := libvirt.NewStandIn()
+	// "gone" is undefined between the listing and its GetInfo
+	st.Handle(libvirt.ProcConnectListAllDomains, func(*libvirt.Decoder) ([]byte, error) {
+		doms := append(st.Domains(), libvirt.StandInDomain{Domain: libvirt.Domain{Name: "gone", ID: -1}})
+		var e libvirt.Encoder
+		e.Uint32(uint32(len(doms)))
+		for _, d := range doms {
+			e.Domain(d.Domain)
+		}
+		e.Uint32(uint32(len(doms)))
+		return e.Bytes(), nil
+	})
+	n := standIn(t, st.Server)
+	if err := n.DefineXML(domainXML("web")); err != nil {
+		t.Fatal(err)
+	}
+	got, err := n.ListDomains()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Domain{{Name: "web", State: "shut off"}}; !slices.Equal(got, want) {
+		t.Errorf("ListDomains = %v, want %v", got, want)
+	}
+}
+
+func TestSocketsFor(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	session := []string{"/run/user/1000/libvirt/virtqemud-sock", "/run/user/1000/libvirt/libvirt-sock"}
+	for _, tc := range []struct {
+		uri  string
+		want []string // nil: error
+	}{
+		{"", libvirt.DefaultSockets},
+		{"qemu:///system", libvirt.DefaultSockets},
+		{"qemu+unix:///system", libvirt.DefaultSockets},
+		{"qemu:///session", session},
+		{"qemu+unix:///session", session},
+		{"qemu+unix:///system?socket=/srv/libvirt/sock", []string{"/srv/libvirt/sock"}},
+		{"qemu+ssh://kvm01/system", nil},
+		{"qemu+tcp://kvm01/system", nil},
+		{"qemu+unix://kvm01/system", nil},
+		{"qemu://kvm01/system", nil},
+	} {
+		got, err := libvirt.SocketsFor(tc.uri)
+		if tc.want == nil {
+			if err == nil {
+				t.Errorf("%q: sockets %v, want an error", tc.uri, got)
+			}
+			continue
+		}
+		if err != nil || !slices.Equal(got, tc.want) {
+			t.Errorf("%q: %v, %v; want %v", tc.uri, got, err, tc.want)
+		}
+	}
+}
+
+func TestXDREncoding(t *testing.T) {
+	var e libvirt.Encoder
+	e.String("abcde")
+	e.OptString("")
+	e.OptString("vm")
+	e.Bool(true)
+	e.Int32(-1)
+	e.Uint64(1 << 32)
+	e.Domain(libvirt.Domain{Name: "web", UUID: [16]byte{15: 1}, ID: 7})
+	want := []byte{
+		0, 0, 0, 5, 'a', 'b', 'c', 'd', 'e', 0, 0, 0, // length, bytes, padding
+		0, 0, 0, 0, // absent remote_string
+		0, 0, 0, 1, 0, 0, 0, 2, 'v', 'm', 0, 0,
+		0, 0, 0, 1,
+		0xff, 0xff, 0xff, 0xff,
+		0, 0, 0, 1, 0, 0, 0, 0,
+		0, 0, 0, 3, 'w', 'e', 'b', 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, // UUID, no padding
+		0, 0, 0, 7,
+	}
+	if !bytes.Equal(e.Bytes(), want) {
+		t.Fatalf("encoded\n% x\nwant\n% x", e.Bytes(), want)
+	}
+
+	d := libvirt.NewDecoder(want)
+	if s := d.String(); s != "abcde" {
+		t.Errorf("String = %q", s)
+	}
+	if s := d.OptString(); s != "" {
+		t.Errorf("absent OptString = %q", s)
+	}
+	if s := d.OptString(); s != "vm" {
+		t.Errorf("OptString = %q", s)
+	}
+	if !d.Bool() || d.Int32() != -1 || d.Uint64() != 1<<32 {
+		t.Error("Bool, Int32 or Uint64 decoded wrong")
+	}
+	if dom := d.Domain(); dom != (libvirt.Domain{Name: "web", UUID: [16]byte{15: 1}, ID: 7}) {
+		t.Errorf("Domain = %+v", dom)
+	}
+	if err := d.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the first error sticks
+	d = libvirt.NewDecoder([]byte{0, 0, 0, 9, 'x', 0, 0, 0, 1})
+	_ = d.String()
+	if d.Bool(); d.Err() == nil {
+		t.Error("short message not reported")
+	}
+	d = libvirt.NewDecoder([]byte{0xff, 0xff, 0xff, 0xff})
+	if _ = d.String(); d.Err() == nil {
+		t.Error("oversized string length accepted")
+	}
+}
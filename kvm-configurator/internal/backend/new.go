@@ -0,0 +1,37 @@
+// backend/new.go
+// last modified: Oct 16 2026
+package backend
+
+import (
+	"fmt"
+	"strings"
+
+	// internal
+	"configurator/internal/config"
+)
+
+// New returns the backend selected in the "connection" block of the config
+func New(c config.Connection) (Backend, error) {
+	switch strings.ToLower(strings.TrimSpace(c.Backend)) {
+	case "", "shell", "virsh":
+		return NewShell(c.URI), nil
+	case "native", "libvirt":
+		n, err := NewNative(c.Socket, c.URI)
+		if err != nil {
+			return nil, fmt.Errorf("native backend: %w", err)
+		}
+		return n, nil
+	default:
+		return nil, fmt.Errorf("unknown backend %q (use shell or native)", c.Backend)
+	}
+}
+
+// Requires lists the external commands the selected backend runs
+func Requires(c config.Connection) []string {
+	switch strings.ToLower(strings.TrimSpace(c.Backend)) {
+	case "native", "libvirt":
+		return []string{config.CmdQemuImg}
+	default:
+		return []string{config.CmdVirsh, config.CmdQemuImg}
+	}
+}
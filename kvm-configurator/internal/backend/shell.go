@@ -0,0 +1,553 @@
+// backend/shell.go
+// last modified: Oct 16 2026
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	// internal
+	"configurator/internal/config"
+	"configurator/internal/style"
+)
+
+// Shell implements Backend by running virsh and qemu-img
+type Shell struct {
+	uri string // passed as --connect; empty = libvirt's default
+}
+
+// NewShell returns the shell-out backend for the given connection URI
+func NewShell(uri string) *Shell { return &Shell{uri: uri} }
+
+// URI returns the configured connection URI (empty = libvirt default)
+func (s *Shell) URI() string { return s.uri }
+
+// command builds the exec.Cmd; virsh gets --connect
+func (s *Shell) command(name string, args ...string) *exec.Cmd {
+	if s.uri != "" && name == config.CmdVirsh {
+		args = append([]string{"--connect", s.uri}, args...)
+	}
+	cmd := exec.Command(name, args...)
+	cmd.Env = append(os.Environ(), "LC_ALL=C") // stable, parseable output
+	return cmd
+}
+
+// run executes a command and returns its combined output;
+// the error carries the output so callers can show it as-is
+func (s *Shell) run(name string, args ...string) ([]byte, error) {
+	out, err := s.command(name, args...).CombinedOutput()
+	if err != nil {
+		return out, fmt.Errorf("%s %s failed: %w – %s",
+			name, firstArg(args), err, strings.TrimSpace(string(out)))
+	}
+	return out, nil
+}
+
+func (s *Shell) virsh(args ...string) ([]byte, error) {
+	return s.run(config.CmdVirsh, args...)
+}
+
+func firstArg(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
+// ListDomains – `virsh list --all`
+func (s *Shell) ListDomains() ([]Domain, error) {
+	if _, err := exec.LookPath(config.CmdVirsh); err != nil {
+		return nil, fmt.Errorf("virsh not found – Please check PATH")
+	}
+	out, err := s.virsh("list", "--all")
+	if err != nil {
+		return nil, err
+	}
+	return parseDomainList(out)
+}
+
+// states that virsh prints as two words
+var twoWordStates = map[string]bool{"shut off": true, "in shutdown": true}
+
+// parseDomainList – converts raw `virsh list --all` output
+func parseDomainList(raw []byte) ([]Domain, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	var doms []Domain
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		// Skip header/divider line/empty lines
+		if strings.HasPrefix(line, "Id") ||
+			strings.HasPrefix(line, "---") ||
+			line == "" {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue // malformed – ignore
+		}
+
+		id := fields[0] // may be "-"
+		stateLen := 1   // "running", "paused", …
+		if len(fields) >= 4 && twoWordStates[strings.ToLower(strings.Join(fields[len(fields)-2:], " "))] {
+			stateLen = 2 // "shut off", "in shutdown"
+		}
+		if id == "-" {
+			id = ""
+		}
+		doms = append(doms, Domain{
+			ID:    id,
+			Name:  strings.Join(fields[1:len(fields)-stateLen], " "),                       // everything in between
+			State: style.NormalizeStatus(strings.Join(fields[len(fields)-stateLen:], " ")), // last column
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error parsing virsh output: %w", err)
+	}
+	return doms, nil
+}
+
+// ListNetworks – `virsh net-list --all`
+func (s *Shell) ListNetworks() ([]Network, error) {
+	out, err := s.virsh("net-list", "--all")
+	if err != nil {
+		return nil, err
+	}
+	return parseNetworkList(out), nil
+}
+
+// parseNetworkList – " Name  State  Autostart  Persistent" table, header and
+// separator skipped
+func parseNetworkList(raw []byte) []Network {
+	var nets []Network
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		f := strings.Fields(scanner.Text())
+		if len(f) < 2 || f[0] == "Name" || strings.HasPrefix(f[0], "---") {
+			continue
+		}
+		n := Network{Name: f[0], Active: f[1] == "active"}
+		n.Autostart = len(f) > 2 && f[2] == "yes"
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+// NetworkXML – `virsh net-dumpxml` (stdout only, like dumpXML)
+func (s *Shell) NetworkXML(name string) ([]byte, error) {
+	cmd := s.command(config.CmdVirsh, "net-dumpxml", name)
+	var out, errOut bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &errOut
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("virsh net-dumpxml failed: %w – %s", err, strings.TrimSpace(errOut.String()))
+	}
+	return out.Bytes(), nil
+}
+
+// DefineNetwork – `virsh net-define` with the XML on stdin
+func (s *Shell) DefineNetwork(xml []byte) error {
+	cmd := s.command(config.CmdVirsh, "net-define", "/dev/stdin")
+	cmd.Stdin = bytes.NewReader(xml)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("virsh net-define failed: %w – %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (s *Shell) StartNetwork(name string) error { _, err := s.virsh("net-start", name); return err }
+func (s *Shell) StopNetwork(name string) error  { _, err := s.virsh("net-destroy", name); return err }
+
+// UndefineNetwork – `virsh net-undefine`; an active network keeps running
+// (transient) until it is stopped
+func (s *Shell) UndefineNetwork(name string) error {
+	_, err := s.virsh("net-undefine", name)
+	return err
+}
+
+// SetNetworkAutostart – `virsh net-autostart [--disable]`
+func (s *Shell) SetNetworkAutostart(name string, on bool) error {
+	args := []string{"net-autostart", name}
+	if !on {
+		args = append(args, "--disable")
+	}
+	_, err := s.virsh(args...)
+	return err
+}
+
+// ListPools – `virsh pool-list --all`, sizes of active pools from `pool-info --bytes`
+func (s *Shell) ListPools() ([]Pool, error) {
+	out, err := s.virsh("pool-list", "--all")
+	if err != nil {
+		return nil, err
+	}
+	pools := parsePoolList(out)
+	for i := range pools {
+		if !pools[i].Active {
+			continue
+		}
+		info, err := s.virsh("pool-info", "--bytes", pools[i].Name)
+		if err != nil {
+			return nil, err
+		}
+		parsePoolInfo(info, &pools[i])
+	}
+	return pools, nil
+}
+
+// parsePoolList – " Name  State  Autostart" table, header and separator skipped
+func parsePoolList(raw []byte) []Pool {
+	var pools []Pool
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		f := strings.Fields(scanner.Text())
+		if len(f) < 2 || f[0] == "Name" || strings.HasPrefix(f[0], "---") {
+			continue
+		}
+		p := Pool{Name: f[0], Active: f[1] == "active"}
+		p.Autostart = len(f) > 2 && f[2] == "yes"
+		pools = append(pools, p)
+	}
+	return pools
+}
+
+// parsePoolInfo – the byte counts of `pool-info --bytes`
+func parsePoolInfo(raw []byte, p *Pool) {
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		key, val, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		n, _ := strconv.ParseUint(strings.TrimSpace(val), 10, 64)
+		switch strings.TrimSpace(key) {
+		case "Capacity":
+			p.Capacity = n
+		case "Allocation":
+			p.Allocation = n
+		case "Available":
+			p.Available = n
+		}
+	}
+}
+
+// PoolXML – `virsh pool-dumpxml` (stdout only, like dumpXML)
+func (s *Shell) PoolXML(name string) ([]byte, error) {
+	cmd := s.command(config.CmdVirsh, "pool-dumpxml", name)
+	var out, errOut bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &errOut
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("virsh pool-dumpxml failed: %w – %s", err, strings.TrimSpace(errOut.String()))
+	}
+	return out.Bytes(), nil
+}
+
+// DefinePool – `virsh pool-define` with the XML on stdin
+func (s *Shell) DefinePool(xml []byte) error {
+	cmd := s.command(config.CmdVirsh, "pool-define", "/dev/stdin")
+	cmd.Stdin = bytes.NewReader(xml)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("virsh pool-define failed: %w – %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (s *Shell) BuildPool(name string) error    { _, err := s.virsh("pool-build", name); return err }
+func (s *Shell) StartPool(name string) error    { _, err := s.virsh("pool-start", name); return err }
+func (s *Shell) StopPool(name string) error     { _, err := s.virsh("pool-destroy", name); return err }
+func (s *Shell) DeletePool(name string) error   { _, err := s.virsh("pool-delete", name); return err }
+func (s *Shell) UndefinePool(name string) error { _, err := s.virsh("pool-undefine", name); return err }
+
+// SetPoolAutostart – `virsh pool-autostart [--disable]`
+func (s *Shell) SetPoolAutostart(name string, on bool) error {
+	args := []string{"pool-autostart", name}
+	if !on {
+		args = append(args, "--disable")
+	}
+	_, err := s.virsh(args...)
+	return err
+}
+
+// ListVolumes – `virsh pool-refresh` + `vol-list`, sizes from `vol-info --bytes`
+func (s *Shell) ListVolumes(pool string) ([]Volume, error) {
+	if _, err := s.virsh("pool-refresh", pool); err != nil {
+		return nil, err
+	}
+	out, err := s.virsh("vol-list", "--pool", pool)
+	if err != nil {
+		return nil, err
+	}
+	var vols []Volume
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		// " Name   Path" – the path may contain blanks, the name does not
+		f := strings.Fields(scanner.Text())
+		if len(f) < 2 || (f[0] == "Name" && f[1] == "Path") || strings.HasPrefix(f[0], "---") {
+			continue
+		}
+		v := Volume{Name: f[0], Path: strings.Join(f[1:], " ")}
+		info, err := s.virsh("vol-info", "--bytes", "--pool", pool, v.Name)
+		if err != nil {
+			return nil, err
+		}
+		parseVolumeInfo(info, &v)
+		vols = append(vols, v)
+	}
+	return vols, scanner.Err()
+}
+
+// parseVolumeInfo – the byte counts of `vol-info --bytes`
+func parseVolumeInfo(raw []byte, v *Volume) {
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		key, val, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		n, _ := strconv.ParseUint(strings.TrimSpace(val), 10, 64)
+		switch strings.TrimSpace(key) {
+		case "Capacity":
+			v.Capacity = n
+		case "Allocation":
+			v.Allocation = n
+		}
+	}
+}
+
+// CreateVolume – `virsh vol-create-as`; an existing volume is left alone
+// (the pool is refreshed first, so an image copied there counts, too)
+func (s *Shell) CreateVolume(pool, name string, sizeGiB int, format string) (bool, error) {
+	if _, err := s.virsh("pool-refresh", pool); err != nil {
+		return false, err
+	}
+	if _, err := s.virsh("vol-path", "--pool", pool, name); err == nil {
+		return false, nil
+	}
+	if _, err := s.virsh("vol-create-as", pool, name, fmt.Sprintf("%dG", sizeGiB), "--format", format); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// DeleteVolume – `virsh vol-delete --pool`
+func (s *Shell) DeleteVolume(pool, name string) error {
+	_, err := s.virsh("vol-delete", "--pool", pool, name)
+	return err
+}
+
+// DomainInfo – `virsh dominfo`
+func (s *Shell) DomainInfo(name string) (DomainInfo, error) {
+	out, err := s.virsh("dominfo", name)
+	if err != nil {
+		return DomainInfo{}, fmt.Errorf("%w: %s (%v)", ErrNotFound, name, err)
+	}
+
+	var info DomainInfo
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		key, val, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		val = strings.TrimSpace(val)
+		switch strings.TrimSpace(key) {
+		case "State":
+			info.State = style.NormalizeStatus(val)
+		case "CPU(s)":
+			info.VCPU, _ = strconv.Atoi(val)
+		case "Max memory":
+			// e.g. "2097152 KiB"
+			if f := strings.Fields(val); len(f) > 0 {
+				kib, _ := strconv.Atoi(f[0])
+				info.MemoryMiB = kib / 1024
+			}
+		case "Autostart":
+			info.Autostart = val == "enable"
+		}
+	}
+	return info, scanner.Err()
+}
+
+// DomainDisks – `virsh domblklist --details`
+func (s *Shell) DomainDisks(name string) ([]string, error) {
+	out, err := s.virsh("domblklist", name, "--details")
+	if err != nil {
+		return nil, err
+	}
+
+	// go through line by line
+	var paths []string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		// skip header line and empty lines
+		if line == "" || strings.HasPrefix(line, "Type") || strings.HasPrefix(line, "---") {
+			continue
+		}
+
+		// expected Layout: Type Device Target Source
+		// example: file disk vda /var/lib/libvirt/images/my-vm-system.qcow2
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			// malformed – ignore, but not fatal
+			continue
+		}
+
+		device := fields[1]                     // second field = "disk" or "cdrom"
+		source := strings.Join(fields[3:], " ") // fourth field = path (may contain blanks)
+
+		if device == "disk" && source != "" && source != "-" {
+			paths = append(paths, source)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error scanning virsh output: %w", err)
+	}
+	return paths, nil
+}
+
+// DumpXML – `virsh dumpxml`
+func (s *Shell) DumpXML(name string) ([]byte, error) {
+	return s.dumpXML(name)
+}
+
+// DefinitionXML – `virsh dumpxml --inactive`
+func (s *Shell) DefinitionXML(name string) ([]byte, error) {
+	return s.dumpXML(name, "--inactive")
+}
+
+func (s *Shell) dumpXML(name string, flags ...string) ([]byte, error) {
+	cmd := s.command(config.CmdVirsh, append([]string{"dumpxml", name}, flags...)...)
+	var out, errOut bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &errOut
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("virsh dumpxml failed: %w – %s", err, strings.TrimSpace(errOut.String()))
+	}
+	return out.Bytes(), nil
+}
+
+// DefineXML – `virsh define` with the XML on stdin
+func (s *Shell) DefineXML(xml []byte) error {
+	cmd := s.command(config.CmdVirsh, "define", "/dev/stdin")
+	cmd.Stdin = bytes.NewReader(xml)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("virsh define failed: %w – %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (s *Shell) Start(name string) error    { _, err := s.virsh("start", name); return err }
+func (s *Shell) Reboot(name string) error   { _, err := s.virsh("reboot", name); return err }
+func (s *Shell) Shutdown(name string) error { _, err := s.virsh("shutdown", name); return err }
+func (s *Shell) Destroy(name string) error  { _, err := s.virsh("destroy", name); return err }
+
+// Undefine – --nvram deletes the UEFI variable store with the definition
+// (without it virsh refuses to undefine UEFI guests), --tpm the swtpm state;
+// virsh older than 8.9 does not know --tpm and removes the state anyway
+func (s *Shell) Undefine(name string) error {
+	if _, err := s.virsh("undefine", "--nvram", "--tpm", name); err == nil {
+		return nil
+	}
+	_, err := s.virsh("undefine", "--nvram", name)
+	return err
+}
+
+// Rename – `virsh domrename` (domain must be shut off)
+func (s *Shell) Rename(oldName, newName string) error {
+	_, err := s.virsh("domrename", oldName, newName)
+	return err
+}
+
+// DiskInfo – `qemu-img info --output=json`; -U reads images that are in use, too
+func (s *Shell) DiskInfo(path string) (DiskInfo, error) {
+	cmd := s.command(config.CmdQemuImg, "info", "--output=json", "-U", path)
+	var out, errOut bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &errOut
+	if err := cmd.Run(); err != nil {
+		return DiskInfo{}, fmt.Errorf("qemu-img info %s failed: %w – %s", path, err, strings.TrimSpace(errOut.String()))
+	}
+	return parseDiskInfo(out.Bytes())
+}
+
+// parseDiskInfo reads the JSON of qemu-img info
+func parseDiskInfo(out []byte) (DiskInfo, error) {
+	var raw struct {
+		Filename    string `json:"filename"`
+		Format      string `json:"format"`
+		VirtualSize uint64 `json:"virtual-size"`
+		ActualSize  uint64 `json:"actual-size"`
+		Backing     string `json:"backing-filename"`
+		FullBacking string `json:"full-backing-filename"`
+	}
+	if err := json.Unmarshal(out, &raw); err != nil {
+		return DiskInfo{}, fmt.Errorf("parse qemu-img info: %w", err)
+	}
+	info := DiskInfo{Format: raw.Format, VirtualSize: raw.VirtualSize, ActualSize: raw.ActualSize, Backing: raw.FullBacking}
+	// older qemu-img only report the name as written into the overlay
+	if info.Backing == "" && raw.Backing != "" {
+		info.Backing = raw.Backing
+		if !filepath.IsAbs(info.Backing) {
+			info.Backing = filepath.Join(filepath.Dir(raw.Filename), info.Backing)
+		}
+	}
+	return info, nil
+}
+
+// CreateDisk – `qemu-img create`; an existing image is left alone
+func (s *Shell) CreateDisk(path string, sizeGiB int, format string) (bool, error) {
+	if _, err := os.Stat(path); err == nil {
+		return false, nil
+	}
+	if _, err := s.run(config.CmdQemuImg, "create", "-f", format, path, fmt.Sprintf("%dG", sizeGiB)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CreateOverlay – `qemu-img create -b <backing> -F <format>`; the overlay
+// only stores what the guest changes. An existing image is left alone.
+func (s *Shell) CreateOverlay(path, backing, backingFormat string, sizeGiB int) (bool, error) {
+	if _, err := os.Stat(path); err == nil {
+		return false, nil
+	}
+	args := []string{"create", "-f", "qcow2", "-b", backing, "-F", backingFormat, path}
+	if sizeGiB > 0 {
+		args = append(args, fmt.Sprintf("%dG", sizeGiB))
+	}
+	if _, err := s.run(config.CmdQemuImg, args...); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ResizeDisk – `qemu-img resize <img> +<n>G`
+func (s *Shell) ResizeDisk(path string, addGiB int) error {
+	_, err := s.run(config.CmdQemuImg, "resize", path, fmt.Sprintf("+%dG", addGiB))
+	return err
+}
+
+// ConvertDisk – `qemu-img convert -O <format>`
+func (s *Shell) ConvertDisk(src, dst, format string) error {
+	_, err := s.run(config.CmdQemuImg, "convert", "-O", format, src, dst)
+	return err
+}
+
+// CheckDisk – `qemu-img check`
+func (s *Shell) CheckDisk(path string) (string, error) {
+	out, err := s.run(config.CmdQemuImg, "check", path)
+	return string(out), err
+}
+
+// RepairDisk – `qemu-img amend` (the easiest way)
+func (s *Shell) RepairDisk(path string) (string, error) {
+	out, err := s.run(config.CmdQemuImg, "amend", "-f", "qcow2", path)
+	return string(out), err
+}
@@ -0,0 +1,51 @@
+// backend/shell_test.go
+// last modified: Oct 16 2026
+package backend
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseDomainList(t *testing.T) {
+	raw := []byte(` Id   Name            State
+---------------------------------
+ 1    web-01          running
+ 2    db              paused
+ 3    mail            in shutdown
+ -    win 11 test     shut off
+ -    debian13        shut off
+
+`)
+	want := []Domain{
+		{ID: "1", Name: "web-01", State: "running"},
+		{ID: "2", Name: "db", State: "paused"},
+		{ID: "3", Name: "mail", State: "in shutdown"},
+		{ID: "", Name: "win 11 test", State: "shut off"},
+		{ID: "", Name: "debian13", State: "shut off"},
+	}
+	got, err := parseDomainList(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("parseDomainList =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestParsePoolList(t *testing.T) {
+	raw := []byte(` Name      State      Autostart
+-----------------------------------
+ default   active     yes
+ iso       inactive   no
+ backup    active     no
+`)
+	want := []Pool{
+		{Name: "default", Active: true, Autostart: true},
+		{Name: "iso"},
+		{Name: "backup", Active: true},
+	}
+	if got := parsePoolList(raw); !slices.Equal(got, want) {
+		t.Errorf("parsePoolList =\n%v\nwant\n%v", got, want)
+	}
+}
@@ -0,0 +1,165 @@
+// cli/cli.go
+// last modified: Oct 16 2026
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	// internal
+	"configurator/internal/backend"
+	"configurator/internal/config"
+)
+
+// ErrUsage signals that the command line could not be parsed;
+// the usage text has already been printed at that point
+var ErrUsage = errors.New("invalid command line")
+
+// command is a single non-interactive subcommand
+type command struct {
+	Name        string
+	Description string
+	Run         func(args []string, cfg *config.FullConfig, be backend.Backend) error
+}
+
+var commands = []command{
+	{"create", "Create a VM from an OS profile without prompts", runCreate},
+	{"list", "List all VMs (table, JSON or YAML)", runList},
+	{"plan", "Show what apply would change for a lab spec", runPlan},
+	{"apply", "Create the missing VMs of a lab spec", runApply},
+	{"destroy", "Undefine all VMs of a lab spec", runDestroy},
+}
+
+// standalone commands work on local files (config, osinfo-db); they run
+// before the config is loaded and need no hypervisor connection
+var standalone = []struct {
+	Name        string
+	Description string
+	Run         func(args []string) error
+}{
+	{"config", "Check or show the layered configuration (config validate, config show)", runConfig},
+	{"osinfo", "List or search the os-variants of the local osinfo database", runOSInfo},
+}
+
+// Global holds the flags that come before the command
+type Global struct {
+	Connect string // libvirt URI, overrides connection.uri
+}
+
+// Load merges all config layers and puts --connect on top; every (re)load
+// of the config goes through it, so the override is never lost
+func (g Global) Load() (*config.FullConfig, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	if g.Connect != "" {
+		cfg.Connection.URI = g.Connect
+	}
+	return cfg, nil
+}
+
+// ParseGlobal splits "--connect URI" (and -c URI) off the front of args;
+// the rest is the command line for Run (or empty for the interactive menu)
+func ParseGlobal(args []string) (Global, []string, error) {
+	var g Global
+	fs := flag.NewFlagSet("configurator", flag.ContinueOnError)
+	fs.StringVar(&g.Connect, "connect", "", "libvirt connection URI, e.g. qemu:///session or qemu+ssh://host/system")
+	fs.StringVar(&g.Connect, "c", "", "shorthand for --connect")
+	fs.Usage = func() {} // -h becomes the help command, errors print the usage below
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return g, []string{"help"}, nil
+		}
+		printUsage(fs.Output())
+		return g, nil, ErrUsage
+	}
+	return g, fs.Args(), nil
+}
+
+// RunStandalone runs args if they name a standalone command (or ask for
+// help) and reports whether it did
+func RunStandalone(args []string) (bool, error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		printUsage(os.Stdout)
+		return true, nil
+	}
+	for _, c := range standalone {
+		if c.Name == args[0] {
+			err := c.Run(args[1:])
+			if errors.Is(err, flag.ErrHelp) {
+				err = nil // -h was requested, usage already printed
+			}
+			return true, err
+		}
+	}
+	return false, nil
+}
+
+// Run dispatches args (without the program name) to the matching subcommand
+func Run(args []string, cfg *config.FullConfig, be backend.Backend) error {
+	if len(args) == 0 {
+		printUsage(os.Stderr)
+		return ErrUsage
+	}
+	for _, c := range commands {
+		if c.Name == args[0] {
+			err := c.Run(args[1:], cfg, be)
+			if errors.Is(err, flag.ErrHelp) {
+				return nil // -h was requested, usage already printed
+			}
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+	printUsage(os.Stderr)
+	return ErrUsage
+}
+
+// printUsage lists all subcommands
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: configurator [--connect URI] [command] [flags]")
+	fmt.Fprintln(w, "\nWithout a command the interactive menu is started.")
+	fmt.Fprintln(w, "--connect (or -c) selects the libvirt URI, e.g. qemu:///session or qemu+ssh://host/system.")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.Name, c.Description)
+	}
+	for _, c := range standalone {
+		fmt.Fprintf(w, "  %-10s %s\n", c.Name, c.Description)
+	}
+	fmt.Fprintln(w, "\nRun 'configurator <command> -h' for the flags of a command.")
+}
+
+// newFlagSet creates a flag set that reports errors instead of exiting
+func newFlagSet(name, synopsis string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: configurator %s %s\n\n", name, synopsis)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses args and maps parse errors to ErrUsage
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return ErrUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "unexpected arguments: %v\n", fs.Args())
+		fs.Usage()
+		return ErrUsage
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+// cli/config.go
+// last modified: Oct 16 2026
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	// internal
+	"configurator/internal/config"
+	"configurator/internal/style"
+	// external
+	"gopkg.in/yaml.v3"
+)
+
+// configCommands are the subcommands of "configurator config"
+var configCommands = []struct {
+	Name        string
+	Description string
+	Run         func(args []string) error
+}{
+	{"validate", "Check all config layers (and the ids against osinfo-db) with line and column", runConfigValidate},
+	{"show", "Print the merged config (--origin: with the source of each value)", runConfigShow},
+}
+
+// runConfig dispatches "configurator config <subcommand>"
+func runConfig(args []string) error {
+	if len(args) == 0 {
+		printConfigUsage(os.Stderr)
+		return ErrUsage
+	}
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		printConfigUsage(os.Stdout)
+		return nil
+	}
+	for _, c := range configCommands {
+		if c.Name == args[0] {
+			return c.Run(args[1:])
+		}
+	}
+	fmt.Fprintf(os.Stderr, "unknown config command %q\n\n", args[0])
+	printConfigUsage(os.Stderr)
+	return ErrUsage
+}
+
+func printConfigUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: configurator config <command> [flags]")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, c := range configCommands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.Name, c.Description)
+	}
+}
+
+// runConfigValidate prints all issues of the config layers (or of one file
+// with -f); warnings alone do not fail the command
+func runConfigValidate(args []string) error {
+	var file string
+	fs := newFlagSet("config validate", "[-f oslist.yaml]")
+	fs.StringVar(&file, "f", "", "check only this file (default: all layers and KVMCONF_* variables)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if file != "" {
+		issues, err := config.Validate(file)
+		if err != nil {
+			return err
+		}
+		printIssues(issues)
+		if config.HasErrors(issues) {
+			return fmt.Errorf("%s is not valid", file)
+		}
+		fmt.Println(style.Ok(file + " is valid"))
+		return nil
+	}
+
+	layers := config.DefaultLayers()
+	if len(layers) == 0 {
+		return fmt.Errorf("no config found – see 'configurator config show'")
+	}
+	m, err := config.Merge(layers, os.Environ())
+	var verr *config.ValidationError
+	if errors.As(err, &verr) {
+		printIssues(verr.Issues)
+		return fmt.Errorf("config is not valid")
+	} else if err != nil {
+		return err
+	}
+	printIssues(m.Issues)
+	// checks across layers (families, ids of extended entries)
+	cfg, err := m.Config()
+	if err != nil {
+		return err
+	}
+	if err := checkVariants(cfg); err != nil {
+		return err
+	}
+	for _, l := range layers {
+		fmt.Printf("%-8s %s\n", l.Kind, l.Path)
+	}
+	fmt.Println(style.Ok("config is valid"))
+	return nil
+}
+
+func printIssues(issues []config.Issue) {
+	for _, i := range issues {
+		if i.Warning {
+			fmt.Println(style.Colourise(i.String(), style.ColYellow))
+		} else {
+			fmt.Println(style.Err(i.String()))
+		}
+	}
+}
+
+// runConfigShow prints the merged config as YAML
+func runConfigShow(args []string) error {
+	var origin bool
+	fs := newFlagSet("config show", "[--origin]")
+	fs.BoolVar(&origin, "origin", false, "add the source (file:line or env variable) of each value")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	m, err := config.Merge(config.DefaultLayers(), os.Environ())
+	if err != nil {
+		return err
+	}
+	for _, i := range m.Issues {
+		fmt.Fprintln(os.Stderr, style.Colourise(i.String(), style.ColYellow))
+	}
+	fmt.Println("# merged from (lowest priority first):")
+	for _, l := range m.Layers {
+		fmt.Printf("#   %-8s %s\n", l.Kind, l.Path)
+	}
+	fmt.Printf("#   %-8s %s*\n", "env", config.EnvPrefix)
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(m.Annotate(origin)); err != nil {
+		return err
+	}
+	return enc.Close()
+}
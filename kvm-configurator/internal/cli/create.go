@@ -0,0 +1,127 @@
+// cli/create.go
+// last modified: Oct 16 2026
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	// internal
+	"configurator/internal/backend"
+	"configurator/internal/cloudinit"
+	"configurator/internal/config"
+	"configurator/internal/engine"
+	"configurator/internal/model"
+	"configurator/internal/utils"
+)
+
+// runCreate builds a DomainConfig from a profile plus flag overrides
+// and hands it to engine.CreateVM – no prompts involved
+func runCreate(args []string, cfg *config.FullConfig, be backend.Backend) error {
+	var (
+		profile string
+		xmlDir  string
+		opts    engine.CreateOptions
+		o       model.Overrides
+		ci      model.CloudInit
+		cloud   string
+	)
+	fs := newFlagSet("create", "--profile <id|name> --name <vm> [flags]")
+	fs.StringVar(&profile, "profile", "", "OS profile from oslist.yaml (id or name, required)")
+	fs.StringVar(&o.Name, "name", "", "name of the new VM (default: profile name)")
+	fs.IntVar(&o.MemMiB, "ram", 0, "RAM in MiB (default: from profile)")
+	fs.IntVar(&o.VCPU, "vcpus", 0, "number of vCPUs (default: from profile)")
+	fs.IntVar(&o.DiskGiB, "disk", 0, "size of the system disk in GiB (default: from profile)")
+	fs.StringVar(&o.DiskPath, "disk-path", "", "directory, file, pool:<name> or attach:<existing image|device> of the system disk (default: from profile)")
+	fs.StringVar(&o.Import, "import", "", "boot from this existing image, block device or LVM volume instead of installing from an ISO")
+	fs.StringVar(&o.Backing, "backing", "", "create the system disk as linked clone (qcow2 overlay) of this base image")
+	fs.StringVar(&cloud, "cloud-image", "", "like --backing for a cloud image, configured on first boot by a generated cloud-init seed")
+	fs.StringVar(&ci.Hostname, "hostname", "", "cloud-init: host name (default: VM name)")
+	fs.StringVar(&ci.User, "user", "", "cloud-init: user with sudo and SSH key login (default: $USER, cloud for root)")
+	fs.Func("ssh-key", "cloud-init: public key or key file, repeatable (default: ~/.ssh/id_*.pub)", func(v string) error {
+		ci.SSHKeys = append(ci.SSHKeys, v)
+		return nil
+	})
+	fs.Func("packages", "cloud-init: packages to install, comma separated", func(v string) error {
+		ci.Packages = append(ci.Packages, cloudinit.SplitList(v)...)
+		return nil
+	})
+	fs.StringVar(&ci.Address, "ip", "", "cloud-init: static IPv4 address in CIDR notation (default: DHCP)")
+	fs.StringVar(&ci.Gateway, "gateway", "", "cloud-init: gateway for --ip")
+	fs.StringVar(&ci.DNS, "dns", "", "cloud-init: DNS servers for --ip, comma separated")
+	fs.StringVar(&o.ISOPath, "iso", "", "installation ISO, absolute or relative to the ISO directory")
+	fs.StringVar(&o.Network, "network", "", "NICs separated by ';': default | none | bridge=<br> | network=<name> | direct=<dev>, each with optional ,model=…,mac=… (default: from profile)")
+	fs.StringVar(&o.Graphics, "graphics", "", "spice | vnc | none (default: from profile)")
+	fs.StringVar(&o.Sound, "sound", "", "none | ac97 | ich6 | ich9 (default: from profile)")
+	fs.StringVar(&o.BootOrder, "boot", "", "boot order, e.g. cdrom,hd (default: from profile)")
+	fs.StringVar(&o.NestedVirt, "nvirt", "", "nested virtualisation: vmx | svm (default: from profile)")
+	fs.StringVar(&o.Firmware, "firmware", "", "bios | efi | efi-secure (default: from profile)")
+	fs.StringVar(&o.TPM, "tpm", "", "none | tpm-crb | tpm-tis (default: from profile)")
+	fs.StringVar(&xmlDir, "xml-dir", cfg.XmlDir, "directory for the generated XML definition")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "print the disk images and domain XML, create nothing")
+	fs.BoolVar(&opts.Start, "start", cfg.Advanced.StartInit, "start the VM after it was registered (default: advanced_features.start_init)")
+	fs.StringVar(&opts.Console, "console", cfg.Advanced.StartConsole, "with --start: none | viewer | serial")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := config.CheckAdvanced("start_console", opts.Console); opts.Console != "" && err != nil {
+		return fmt.Errorf("--console: %w", err)
+	}
+	opts.Start = opts.Start || (opts.Console != "" && opts.Console != config.ConsoleNone)
+	if profile == "" {
+		fmt.Fprintln(fs.Output(), "--profile is required")
+		fs.Usage()
+		return ErrUsage
+	}
+	// any cloud-init flag turns the seed on (for --import or --backing as well)
+	if cloud != "" || !reflect.DeepEqual(ci, model.CloudInit{}) {
+		if cloud != "" {
+			o.Backing = cloud
+		}
+		o.CloudInit = &ci
+	}
+
+	distro, err := config.FindProfile(cfg.OSList, profile)
+	if err != nil {
+		return err
+	}
+	dom, err := buildDomain(distro, cfg, o)
+	if err != nil {
+		return err
+	}
+	warnResources(dom, distro.ID)
+	return engine.CreateVM(be, dom, distro.ID, dom.ISOPath, xmlDir, opts)
+}
+
+// buildDomain applies the overrides on top of the profile values
+// and makes sure the ISO exists (an imported VM may do without one)
+func buildDomain(distro config.VMConfig, cfg *config.FullConfig, o model.Overrides) (model.DomainConfig, error) {
+	dom := model.NewDomainConfig(distro, cfg.Defaults)
+	o.ISOPath = utils.ResolveInDir(os.ExpandEnv(o.ISOPath), cfg.IsoPath)
+	for flag, path := range map[string]*string{"--import": &o.Import, "--backing": &o.Backing} {
+		if *path == "" {
+			continue
+		}
+		abs, err := filepath.Abs(os.ExpandEnv(*path))
+		if err != nil {
+			return dom, fmt.Errorf("%s: %w", flag, err)
+		}
+		*path = abs
+	}
+	if err := dom.ApplyOverrides(o); err != nil {
+		return dom, err
+	}
+	if strings.TrimSpace(dom.ISOPath) == "" {
+		if dom.Imported() {
+			return dom, nil
+		}
+		return dom, fmt.Errorf("no ISO given – use --iso, --import, --backing, --cloud-image or set isopath in the profile")
+	}
+	if _, err := os.Stat(dom.ISOPath); err != nil {
+		return dom, fmt.Errorf("ISO %q not usable: %w", dom.ISOPath, err)
+	}
+	return dom, nil
+}
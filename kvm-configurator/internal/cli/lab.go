@@ -0,0 +1,111 @@
+// cli/lab.go
+// last modified: Oct 16 2026
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	// internal
+	"configurator/internal/backend"
+	"configurator/internal/config"
+	"configurator/internal/lab"
+	"configurator/kvmtools"
+)
+
+const defaultLabFile = "lab.yaml"
+
+// runPlan prints what apply would create, change or remove
+func runPlan(args []string, cfg *config.FullConfig, be backend.Backend) error {
+	var file string
+	fs := newFlagSet("plan", "[-f lab.yaml]")
+	fs.StringVar(&file, "f", defaultLabFile, "lab spec file")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	spec, err := lab.Load(file)
+	if err != nil {
+		return err
+	}
+	plan, err := lab.Compute(be, spec, cfg)
+	if err != nil {
+		return err
+	}
+	plan.Print(os.Stdout)
+	return nil
+}
+
+// runApply creates the missing VMs of a lab
+func runApply(args []string, cfg *config.FullConfig, be backend.Backend) error {
+	var (
+		file string
+		yes  bool
+	)
+	fs := newFlagSet("apply", "[-f lab.yaml] [-yes]")
+	fs.StringVar(&file, "f", defaultLabFile, "lab spec file")
+	fs.BoolVar(&yes, "yes", false, "do not ask for confirmation")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	spec, err := lab.Load(file)
+	if err != nil {
+		return err
+	}
+	plan, err := lab.Compute(be, spec, cfg)
+	if err != nil {
+		return err
+	}
+	plan.Print(os.Stdout)
+	if plan.Pending() == 0 {
+		fmt.Println("Nothing to do.")
+		return nil
+	}
+	if ok, err := confirm(yes, "Apply this plan?"); err != nil || !ok {
+		return err
+	}
+	return lab.Apply(be, plan, cfg)
+}
+
+// runDestroy undefines the VMs a lab created
+func runDestroy(args []string, cfg *config.FullConfig, be backend.Backend) error {
+	var (
+		file        string
+		yes         bool
+		deleteDisks bool
+	)
+	fs := newFlagSet("destroy", "[-f lab.yaml] [-yes] [-delete-disks]")
+	fs.StringVar(&file, "f", defaultLabFile, "lab spec file")
+	fs.BoolVar(&yes, "yes", false, "do not ask for confirmation")
+	fs.BoolVar(&deleteDisks, "delete-disks", false, "also delete the disk images")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	spec, err := lab.Load(file)
+	if err != nil {
+		return err
+	}
+	prompt := fmt.Sprintf("Undefine the VMs created by lab %s?", spec.Lab)
+	if deleteDisks {
+		prompt = fmt.Sprintf("Undefine the VMs created by lab %s and DELETE their disks?", spec.Lab)
+	}
+	if ok, err := confirm(yes, prompt); err != nil || !ok {
+		return err
+	}
+	return lab.Destroy(be, spec, cfg, deleteDisks)
+}
+
+// confirm asks on stdin unless -yes was given
+func confirm(yes bool, prompt string) (bool, error) {
+	if yes {
+		return true, nil
+	}
+	ok, err := kvmtools.AskYesNo(bufio.NewReader(os.Stdin), prompt)
+	if err != nil {
+		return false, fmt.Errorf("no confirmation (use -yes in scripts): %w", err)
+	}
+	if !ok {
+		fmt.Println("Aborted.")
+	}
+	return ok, nil
+}
@@ -0,0 +1,74 @@
+// cli/list.go
+// last modified: Oct 16 2026
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	// internal
+	"configurator/internal/backend"
+	"configurator/internal/config"
+	"configurator/internal/style"
+	"configurator/kvmtools"
+	// external
+	"gopkg.in/yaml.v3"
+)
+
+// runList prints all VMs as table, JSON or YAML
+func runList(args []string, cfg *config.FullConfig, be backend.Backend) error {
+	var output string
+	fs := newFlagSet("list", "[--output table|json|yaml]")
+	fs.StringVar(&output, "output", "table", "output format: table | json | yaml")
+	fs.StringVar(&output, "o", "table", "shorthand for --output")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	switch strings.ToLower(output) {
+	case "table", "json", "yaml":
+	default:
+		return fmt.Errorf("unknown output format %q (table, json or yaml)", output)
+	}
+
+	vms, err := kvmtools.FetchVMDetails(be)
+	if err != nil {
+		return err
+	}
+	return writeVMs(os.Stdout, vms, output)
+}
+
+// writeVMs renders the VM details in the requested format
+func writeVMs(w io.Writer, vms []*kvmtools.VMDetails, output string) error {
+	if vms == nil {
+		vms = []*kvmtools.VMDetails{} // [] instead of null
+	}
+	switch strings.ToLower(output) {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(vms)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(vms); err != nil {
+			return err
+		}
+		return enc.Close()
+	case "table":
+		lines := style.MustTableToLines(func(tw *tabwriter.Writer) {
+			fmt.Fprintln(tw, "Name\tState\tvCPU\tRAM (MiB)\tAutostart\tDisks")
+			for _, vm := range vms {
+				fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%t\t%d\n",
+					vm.Name, vm.State, vm.VCPU, vm.MemoryMiB, vm.Autostart, len(vm.Disks))
+			}
+		})
+		fmt.Fprintln(w, style.Box(70, lines))
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q (table, json or yaml)", output)
+}
@@ -0,0 +1,87 @@
+// cli/osinfo.go
+// last modified: Oct 16 2026
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	// internal
+	"configurator/internal/config"
+	"configurator/internal/model"
+	"configurator/internal/osinfo"
+	"configurator/internal/style"
+)
+
+// runOSInfo lists the os-variants of the local osinfo database; words
+// narrow the list down (all must match short id, name or vendor)
+func runOSInfo(args []string) error {
+	fs := newFlagSet("osinfo", "[search words…]")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return ErrUsage
+	}
+	db, err := osinfo.Default()
+	if err != nil {
+		return err
+	}
+	found := db.Search(strings.Join(fs.Args(), " "))
+	if len(found) == 0 {
+		return fmt.Errorf("no OS matches %q", strings.Join(fs.Args(), " "))
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tVENDOR\tEOL")
+	for _, o := range found {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", strings.Join(o.ShortIDs, ", "), o.Name, o.Vendor, o.EOL)
+	}
+	return w.Flush()
+}
+
+// checkVariants reports every oslist id that is not in the osinfo database;
+// without a database there is nothing to check against
+func checkVariants(cfg *config.FullConfig) error {
+	db, err := osinfo.Default()
+	if errors.Is(err, osinfo.ErrNoDatabase) {
+		fmt.Println(style.Colourise("osinfo-db not installed – ids not checked", style.ColYellow))
+		return nil
+	} else if err != nil {
+		return err
+	}
+	bad := 0
+	for _, p := range cfg.OSList {
+		if err := db.Check(p.ID); err != nil {
+			fmt.Println(style.Err(fmt.Sprintf("oslist %q: %v", p.Name, err)))
+			bad++
+		}
+	}
+	if bad > 0 {
+		return fmt.Errorf("%d unknown os-variant(s) – see 'configurator osinfo <words>'", bad)
+	}
+	return nil
+}
+
+// warnResources prints to stderr what is below the osinfo minimum of the
+// variant; the VM is created anyway (scripts may know better)
+func warnResources(dom model.DomainConfig, variant string) {
+	db, err := osinfo.Default()
+	if err != nil {
+		return
+	}
+	o, ok := db.Lookup(variant)
+	if !ok {
+		return
+	}
+	disk := 0
+	if d := dom.PrimaryDisk(); d != nil {
+		disk = d.SizeGiB
+	}
+	for _, s := range o.Warnings(dom.MemMiB, dom.VCPU, disk) {
+		fmt.Fprintln(os.Stderr, style.Colourise("warning: "+s, style.ColYellow))
+	}
+}
@@ -0,0 +1,334 @@
+// cloudinit/cloudinit.go
+// last modified: Oct 16 2026
+package cloudinit
+
+import (
+	"bytes"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	// external
+	"gopkg.in/yaml.v3"
+)
+
+/*
+Config is what a cloud image needs on its first boot: host name, one user
+who logs in with SSH keys (password login stays locked), packages to
+install and – optionally – a static IPv4 address instead of DHCP.
+*/
+type Config struct {
+	Hostname string
+	User     string
+	SSHKeys  []string // public keys ("ssh-ed25519 AAAA… comment")
+	Packages []string
+	Network  *Static // nil = DHCP
+}
+
+// Static is a fixed IPv4 configuration of the first NIC
+type Static struct {
+	Address netip.Prefix // 192.168.122.10/24
+	Gateway netip.Addr   // invalid = none
+	DNS     []netip.Addr
+}
+
+var (
+	hostRe = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+	userRe = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
+	keyRe  = regexp.MustCompile(`^(ssh-(rsa|ed25519|dss)|ecdsa-sha2-nistp(256|384|521)|sk-(ssh-ed25519|ecdsa-sha2-nistp256)@openssh\.com) [A-Za-z0-9+/=]+( .*)?$`)
+)
+
+// Validate checks the values before anything is written
+func (c *Config) Validate() error {
+	if err := CheckHostname(c.Hostname); err != nil {
+		return err
+	}
+	if err := CheckUser(c.User); err != nil {
+		return err
+	}
+	if len(c.SSHKeys) == 0 {
+		return fmt.Errorf("at least one SSH public key is needed – the password login is locked")
+	}
+	for _, k := range c.SSHKeys {
+		if !keyRe.MatchString(k) {
+			return fmt.Errorf("not an SSH public key: %.40q", k)
+		}
+	}
+	for _, p := range c.Packages {
+		if p == "" || strings.ContainsAny(p, " \t,") {
+			return fmt.Errorf("invalid package name %q", p)
+		}
+	}
+	if c.Network != nil {
+		return c.Network.Validate()
+	}
+	return nil
+}
+
+// Validate – an IPv4 address with prefix, the gateway inside its subnet
+func (s *Static) Validate() error {
+	if !s.Address.IsValid() || !s.Address.Addr().Is4() {
+		return fmt.Errorf("static address must be IPv4 in CIDR notation (192.168.122.10/24)")
+	}
+	if s.Gateway.IsValid() && !s.Address.Masked().Contains(s.Gateway) {
+		return fmt.Errorf("gateway %s is not in %s", s.Gateway, s.Address.Masked())
+	}
+	return nil
+}
+
+// CheckHostname – a single DNS label
+func CheckHostname(name string) error {
+	if !hostRe.MatchString(name) {
+		return fmt.Errorf("hostname %q: letters, digits and - only, at most 63 characters", name)
+	}
+	return nil
+}
+
+// CheckUser – a portable Linux user name
+func CheckUser(name string) error {
+	if !userRe.MatchString(name) {
+		return fmt.Errorf("user %q: lower case letters, digits, _ and - only", name)
+	}
+	return nil
+}
+
+/*
+ParseStatic reads a static configuration: address in CIDR notation,
+gateway and DNS servers (space or comma separated). An empty gateway is the
+first host of the subnet, empty DNS the gateway; an empty address means
+DHCP (nil).
+*/
+func ParseStatic(address, gateway, dns string) (*Static, error) {
+	if strings.TrimSpace(address) == "" {
+		return nil, nil
+	}
+	var st Static
+	var err error
+	if st.Address, err = netip.ParsePrefix(strings.TrimSpace(address)); err != nil {
+		return nil, fmt.Errorf("address: %w", err)
+	}
+	if gw := strings.TrimSpace(gateway); gw != "" {
+		if st.Gateway, err = netip.ParseAddr(gw); err != nil {
+			return nil, fmt.Errorf("gateway: %w", err)
+		}
+	} else {
+		st.Gateway = st.Address.Masked().Addr().Next()
+	}
+	if strings.TrimSpace(dns) == "" {
+		dns = st.Gateway.String()
+	}
+	for _, d := range SplitList(dns) {
+		a, err := netip.ParseAddr(d)
+		if err != nil {
+			return nil, fmt.Errorf("DNS: %w", err)
+		}
+		st.DNS = append(st.DNS, a)
+	}
+	return &st, nil
+}
+
+// SplitList splits "a, b c" into its items
+func SplitList(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+}
+
+// Summary is a one-line description for the menus
+// ("alice@web-01, 2 keys, 3 packages, DHCP")
+func (c *Config) Summary() string {
+	net := "DHCP"
+	if c.Network != nil {
+		net = c.Network.Address.String()
+	}
+	return fmt.Sprintf("%s@%s, %d keys, %d packages, %s", c.User, c.Hostname, len(c.SSHKeys), len(c.Packages), net)
+}
+
+// cloud-config user entry
+type user struct {
+	Name       string   `yaml:"name"`
+	Sudo       string   `yaml:"sudo"`
+	Shell      string   `yaml:"shell"`
+	LockPasswd bool     `yaml:"lock_passwd"`
+	Keys       []string `yaml:"ssh_authorized_keys"`
+}
+
+type cloudConfig struct {
+	Hostname      string   `yaml:"hostname"`
+	ManageHosts   bool     `yaml:"manage_etc_hosts"`
+	Users         []user   `yaml:"users"`
+	SSHPwauth     bool     `yaml:"ssh_pwauth"`
+	PackageUpdate bool     `yaml:"package_update,omitempty"`
+	Packages      []string `yaml:"packages,omitempty"`
+}
+
+// UserData renders the #cloud-config document (cloud-init grows the root
+// partition to the size of the disk by itself)
+func (c *Config) UserData() ([]byte, error) {
+	cc := cloudConfig{
+		Hostname:    c.Hostname,
+		ManageHosts: true,
+		Users: []user{{
+			Name: c.User, Sudo: "ALL=(ALL) NOPASSWD:ALL", Shell: "/bin/bash",
+			LockPasswd: true, Keys: c.SSHKeys,
+		}},
+		PackageUpdate: len(c.Packages) > 0,
+		Packages:      c.Packages,
+	}
+	return render("#cloud-config\n", cc)
+}
+
+type metaData struct {
+	InstanceID    string `yaml:"instance-id"`
+	LocalHostname string `yaml:"local-hostname"`
+}
+
+// MetaData renders the NoCloud meta-data; the instance id is bound to the
+// VM so cloud-init runs once per VM (a libvirt name may need YAML quoting)
+func (c *Config) MetaData(vmName string) ([]byte, error) {
+	return render("", metaData{InstanceID: "iid-" + vmName, LocalHostname: c.Hostname})
+}
+
+// network-config version 2 (netplan syntax)
+type networkConfig struct {
+	Version   int                 `yaml:"version"`
+	Ethernets map[string]ethernet `yaml:"ethernets"`
+}
+
+type ethernet struct {
+	Match struct {
+		Name string `yaml:"name"`
+	} `yaml:"match"`
+	DHCP4       bool     `yaml:"dhcp4"`
+	Addresses   []string `yaml:"addresses"`
+	Routes      []route  `yaml:"routes,omitempty"`
+	Nameservers *struct {
+		Addresses []string `yaml:"addresses"`
+	} `yaml:"nameservers,omitempty"`
+}
+
+type route struct {
+	To  string `yaml:"to"`
+	Via string `yaml:"via"`
+}
+
+// NetworkConfig renders the static configuration of the first NIC
+// (nil for DHCP – cloud images default to it)
+func (c *Config) NetworkConfig() ([]byte, error) {
+	n := c.Network
+	if n == nil {
+		return nil, nil
+	}
+	eth := ethernet{Addresses: []string{n.Address.String()}}
+	eth.Match.Name = "e*"
+	if n.Gateway.IsValid() {
+		eth.Routes = []route{{To: "0.0.0.0/0", Via: n.Gateway.String()}}
+	}
+	if len(n.DNS) > 0 {
+		eth.Nameservers = &struct {
+			Addresses []string `yaml:"addresses"`
+		}{}
+		for _, d := range n.DNS {
+			eth.Nameservers.Addresses = append(eth.Nameservers.Addresses, d.String())
+		}
+	}
+	return render("", networkConfig{Version: 2, Ethernets: map[string]ethernet{"primary": eth}})
+}
+
+func render(header string, v any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("render cloud-init: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("render cloud-init: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Files are the seed files in the order they go onto the ISO
+func (c *Config) Files(vmName string) ([]File, error) {
+	md, err := c.MetaData(vmName)
+	if err != nil {
+		return nil, err
+	}
+	ud, err := c.UserData()
+	if err != nil {
+		return nil, err
+	}
+	files := []File{{Name: "meta-data", Data: md}, {Name: "user-data", Data: ud}}
+	nc, err := c.NetworkConfig()
+	if err != nil {
+		return nil, err
+	}
+	if nc != nil {
+		files = append(files, File{Name: "network-config", Data: nc})
+	}
+	return files, nil
+}
+
+// WriteSeed writes the NoCloud seed ISO of c to path
+func (c *Config) WriteSeed(path, vmName string) error {
+	files, err := c.Files(vmName)
+	if err != nil {
+		return err
+	}
+	iso, err := BuildISO(VolumeID, files)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, iso, 0644); err != nil {
+		return fmt.Errorf("write seed ISO: %w", err)
+	}
+	return nil
+}
+
+// DefaultUser is $USER – or "cloud" for root and names that do not fit
+func DefaultUser() string {
+	if u := os.Getenv("USER"); u != "root" && CheckUser(u) == nil {
+		return u
+	}
+	return "cloud"
+}
+
+// LocalKeys returns the public keys in ~/.ssh (id_*.pub) – the defaults
+// the workflow offers
+func LocalKeys() []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	paths, _ := filepath.Glob(filepath.Join(home, ".ssh", "id_*.pub"))
+	var keys []string
+	for _, p := range paths {
+		if k, err := ReadKey(p); err == nil {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// LoadKey takes a public key as it is or reads it from a file
+func LoadKey(keyOrPath string) (string, error) {
+	if k := strings.TrimSpace(keyOrPath); keyRe.MatchString(k) {
+		return k, nil
+	}
+	return ReadKey(os.ExpandEnv(keyOrPath))
+}
+
+// ReadKey reads a public key file (first line)
+func ReadKey(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	key, _, _ := strings.Cut(strings.TrimSpace(string(data)), "\n")
+	if !keyRe.MatchString(key) {
+		return "", fmt.Errorf("%s is not an SSH public key", path)
+	}
+	return key, nil
+}
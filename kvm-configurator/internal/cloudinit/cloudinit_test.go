@@ -0,0 +1,107 @@
+// cloudinit/cloudinit_test.go
+// last modified: Oct 16 2026
+package cloudinit
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	// external
+	"gopkg.in/yaml.v3"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// golden compares got with testdata/<name> (or rewrites it with -update)
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs:\n--- got\n%s--- want\n%s", name, got, want)
+	}
+}
+
+func testConfig(t *testing.T) *Config {
+	t.Helper()
+	st, err := ParseStatic("192.168.122.10/24", "", "1.1.1.1, 9.9.9.9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Config{
+		Hostname: "web-01",
+		User:     "alice",
+		SSHKeys:  []string{"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIExampleKey alice@laptop"},
+		Packages: []string{"qemu-guest-agent", "htop"},
+		Network:  st,
+	}
+}
+
+func TestGoldenFiles(t *testing.T) {
+	c := testConfig(t)
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	files, err := c.Files("web-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("%d files, want meta-data, user-data and network-config", len(files))
+	}
+	for _, f := range files {
+		golden(t, f.Name, f.Data)
+	}
+
+	// DHCP: no network-config at all
+	c.Network = nil
+	if files, _ := c.Files("web-01"); len(files) != 2 {
+		t.Errorf("DHCP seed has %d files, want 2", len(files))
+	}
+}
+
+func TestMetaDataQuotesVMName(t *testing.T) {
+	c := testConfig(t)
+	for _, name := range []string{"web-01", "web: 01", "db #2", "'quoted'", "a\nlocal-hostname: evil", "yes"} {
+		raw, err := c.MetaData(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var md map[string]string
+		if err := yaml.Unmarshal(raw, &md); err != nil {
+			t.Fatalf("meta-data of %q is no YAML: %v\n%s", name, err, raw)
+		}
+		if md["instance-id"] != "iid-"+name || md["local-hostname"] != "web-01" || len(md) != 2 {
+			t.Errorf("meta-data of %q reads back as %v", name, md)
+		}
+	}
+}
+
+func TestParseStaticDefaults(t *testing.T) {
+	st, err := ParseStatic("10.0.5.20/16", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Gateway.String() != "10.0.0.1" || len(st.DNS) != 1 || st.DNS[0] != st.Gateway {
+		t.Errorf("defaults: gateway %s, DNS %v", st.Gateway, st.DNS)
+	}
+	if st, err := ParseStatic(" ", "10.0.0.1", ""); st != nil || err != nil {
+		t.Errorf("empty address: %v, %v – want DHCP", st, err)
+	}
+	st, _ = ParseStatic("10.0.5.20/24", "10.0.6.1", "")
+	if err := st.Validate(); err == nil {
+		t.Error("gateway outside the subnet accepted")
+	}
+}
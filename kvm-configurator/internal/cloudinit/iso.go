@@ -0,0 +1,229 @@
+// cloudinit/iso.go
+// last modified: Oct 16 2026
+package cloudinit
+
+import (
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// VolumeID is the label cloud-init looks for on a NoCloud seed
+const VolumeID = "cidata"
+
+// File is one file in the root directory of the ISO
+type File struct {
+	Name string
+	Data []byte
+}
+
+const sector = 2048
+
+/*
+BuildISO writes a minimal ISO 9660 image: all files in the root directory,
+with a Joliet tree so Linux sees the names as given (user-data instead of
+USER_DATA.;1). Layout in sectors:
+
+	0-15  system area
+	16    primary volume descriptor
+	17    Joliet (supplementary) volume descriptor
+	18    terminator
+	19-22 path tables (primary L/M, Joliet L/M)
+	23    primary root directory
+	24    Joliet root directory
+	25…   file data
+*/
+func BuildISO(volumeID string, files []File) ([]byte, error) {
+	files = slices.Clone(files)
+	slices.SortFunc(files, func(a, b File) int { return strings.Compare(a.Name, b.Name) })
+
+	const (
+		pvdSector     = 16
+		svdSector     = 17
+		termSector    = 18
+		pathSector    = 19 // 19 L, 20 M, 21 Joliet L, 22 Joliet M
+		rootSector    = 23
+		jolietSector  = 24
+		firstDataSect = 25
+	)
+	extents := make([]uint32, len(files))
+	next := uint32(firstDataSect)
+	for i, f := range files {
+		extents[i] = next
+		next += uint32((len(f.Data) + sector - 1) / sector)
+	}
+	total := next
+	img := make([]byte, int(total)*sector)
+	now := time.Now().UTC()
+
+	// root directories – ".", ".." and one record per file
+	primary := dirSector(rootSector, now, files, extents, isoName)
+	joliet := dirSector(jolietSector, now, files, extents, jolietName)
+	if primary == nil || joliet == nil {
+		return nil, fmt.Errorf("seed ISO: too many files for one directory sector")
+	}
+	copy(img[rootSector*sector:], primary)
+	copy(img[jolietSector*sector:], joliet)
+
+	// path tables: only the root
+	for i, root := range []uint32{rootSector, rootSector, jolietSector, jolietSector} {
+		pt := img[(pathSector+i)*sector:]
+		pt[0] = 1 // length of the directory identifier
+		if i%2 == 0 {
+			binary.LittleEndian.PutUint32(pt[2:], root)
+			binary.LittleEndian.PutUint16(pt[6:], 1)
+		} else {
+			binary.BigEndian.PutUint32(pt[2:], root)
+			binary.BigEndian.PutUint16(pt[6:], 1)
+		}
+	}
+
+	writeVD(img[pvdSector*sector:], 1, volumeID, total, pathSector, rootSector, now)
+	writeVD(img[svdSector*sector:], 2, volumeID, total, pathSector+2, jolietSector, now)
+	term := img[termSector*sector:]
+	term[0] = 255
+	copy(term[1:], "CD001")
+	term[6] = 1
+
+	for i, f := range files {
+		copy(img[int(extents[i])*sector:], f.Data)
+	}
+	return img, nil
+}
+
+// writeVD fills a primary (1) or Joliet supplementary (2) volume descriptor
+func writeVD(vd []byte, typ byte, volumeID string, total, pathTable, root uint32, now time.Time) {
+	vd[0] = typ
+	copy(vd[1:], "CD001")
+	vd[6] = 1
+	text := func(off, n int, s string) {
+		if typ == 1 {
+			copy(vd[off:off+n], padRight(strings.ToUpper(s), n))
+			return
+		}
+		copy(vd[off:off+n], ucs2(s, n))
+	}
+	text(8, 32, "LINUX")
+	// the label as cloud-init expects it – lower case like genisoimage -V cidata writes it
+	if typ == 1 {
+		copy(vd[40:72], padRight(volumeID, 32))
+	} else {
+		text(40, 32, volumeID)
+		copy(vd[88:], "%/E") // UCS-2 level 3
+	}
+	bothEndian32(vd[80:], total)
+	bothEndian16(vd[120:], 1) // volume set size
+	bothEndian16(vd[124:], 1) // volume sequence number
+	bothEndian16(vd[128:], sector)
+	bothEndian32(vd[132:], 10) // path table size: the root entry
+	binary.LittleEndian.PutUint32(vd[140:], pathTable)
+	binary.BigEndian.PutUint32(vd[148:], pathTable+1)
+	dirRecord(vd[156:], root, sector, 0x02, []byte{0}, now)
+	for _, f := range [][2]int{{190, 128}, {318, 128}, {446, 128}, {574, 128}, {702, 37}, {739, 37}, {776, 37}} {
+		text(f[0], f[1], "")
+	}
+	stamp := decDate(now)
+	copy(vd[813:], stamp)
+	copy(vd[830:], stamp)
+	copy(vd[847:], decDate(time.Time{}))
+	copy(vd[864:], stamp)
+	vd[881] = 1 // file structure version
+}
+
+// dirSector builds a root directory; nil if the records do not fit
+func dirSector(self uint32, now time.Time, files []File, extents []uint32, name func(string) []byte) []byte {
+	buf := make([]byte, sector)
+	off := dirRecord(buf, self, sector, 0x02, []byte{0}, now)
+	off += dirRecord(buf[off:], self, sector, 0x02, []byte{1}, now)
+	for i, f := range files {
+		id := name(f.Name)
+		if off+33+len(id)+1 > sector {
+			return nil
+		}
+		off += dirRecord(buf[off:], extents[i], uint32(len(f.Data)), 0, id, now)
+	}
+	return buf
+}
+
+// dirRecord writes one directory record and returns its length
+func dirRecord(b []byte, extent, size uint32, flags byte, id []byte, now time.Time) int {
+	n := 33 + len(id)
+	if n%2 == 1 {
+		n++
+	}
+	b[0] = byte(n)
+	bothEndian32(b[2:], extent)
+	bothEndian32(b[10:], size)
+	b[18] = byte(now.Year() - 1900)
+	b[19] = byte(now.Month())
+	b[20] = byte(now.Day())
+	b[21] = byte(now.Hour())
+	b[22] = byte(now.Minute())
+	b[23] = byte(now.Second())
+	b[25] = flags
+	bothEndian16(b[28:], 1)
+	b[32] = byte(len(id))
+	copy(b[33:], id)
+	return n
+}
+
+// isoName – primary tree: upper case, d-characters, version suffix
+// ("user-data" → "USER_DATA.;1")
+func isoName(name string) []byte {
+	up := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, name)
+	return []byte(up + ".;1")
+}
+
+// jolietName – Joliet tree: the name as UCS-2 big endian
+func jolietName(name string) []byte {
+	return ucs2(name, 2*len(utf16.Encode([]rune(name))))
+}
+
+// ucs2 encodes s as UCS-2 big endian, padded with blanks to n bytes
+func ucs2(s string, n int) []byte {
+	out := make([]byte, 0, n)
+	for _, c := range utf16.Encode([]rune(s)) {
+		out = binary.BigEndian.AppendUint16(out, c)
+	}
+	for len(out)+1 < n {
+		out = append(out, 0, ' ')
+	}
+	return out[:min(len(out), n)]
+}
+
+func padRight(s string, n int) string {
+	if len(s) >= n {
+		return s[:n]
+	}
+	return s + strings.Repeat(" ", n-len(s))
+}
+
+// decDate is the 17 byte date of a volume descriptor ("YYYYMMDDHHMMSScc" + GMT offset);
+// the zero time is "not specified"
+func decDate(t time.Time) []byte {
+	if t.IsZero() {
+		return append([]byte(strings.Repeat("0", 16)), 0)
+	}
+	return append([]byte(t.Format("20060102150405")+"00"), 0)
+}
+
+func bothEndian16(b []byte, v uint16) {
+	binary.LittleEndian.PutUint16(b, v)
+	binary.BigEndian.PutUint16(b[2:], v)
+}
+
+func bothEndian32(b []byte, v uint32) {
+	binary.LittleEndian.PutUint32(b, v)
+	binary.BigEndian.PutUint32(b[4:], v)
+}
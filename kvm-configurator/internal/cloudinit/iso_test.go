@@ -0,0 +1,121 @@
+// cloudinit/iso_test.go
+// last modified: Oct 16 2026
+package cloudinit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// isoVolume is what a reader sees through one volume descriptor
+type isoVolume struct {
+	label string
+	files map[string][]byte // file identifier → contents
+}
+
+// readVolume follows the volume descriptor in sector vd to its root directory
+func readVolume(t *testing.T, img []byte, vd int, joliet bool) isoVolume {
+	t.Helper()
+	d := img[vd*sector : (vd+1)*sector]
+	if !bytes.Equal(d[1:6], []byte("CD001")) || d[6] != 1 {
+		t.Fatalf("sector %d: no volume descriptor", vd)
+	}
+	decode := func(b []byte) string { return string(b) }
+	if joliet {
+		if !bytes.Equal(d[88:91], []byte("%/E")) {
+			t.Fatalf("sector %d: no Joliet escape sequence", vd)
+		}
+		decode = func(b []byte) string {
+			u := make([]uint16, len(b)/2)
+			for i := range u {
+				u[i] = binary.BigEndian.Uint16(b[2*i:])
+			}
+			return string(utf16.Decode(u))
+		}
+	}
+	if total := binary.LittleEndian.Uint32(d[80:]); int(total)*sector != len(img) {
+		t.Errorf("volume space %d sectors, image has %d bytes", total, len(img))
+	}
+	if binary.LittleEndian.Uint32(d[80:]) != binary.BigEndian.Uint32(d[84:]) {
+		t.Error("volume space size: little and big endian differ")
+	}
+
+	vol := isoVolume{label: strings.TrimRight(decode(d[40:72]), " "), files: map[string][]byte{}}
+	root := d[156:]
+	extent, size := binary.LittleEndian.Uint32(root[2:]), binary.LittleEndian.Uint32(root[10:])
+	if root[25]&0x02 == 0 {
+		t.Fatal("root record is no directory")
+	}
+	dir := img[int(extent)*sector : int(extent)*sector+int(size)]
+	for off := 0; off < len(dir) && dir[off] != 0; off += int(dir[off]) {
+		rec := dir[off:]
+		id := rec[33 : 33+int(rec[32])]
+		if len(id) == 1 && id[0] <= 1 {
+			continue // "." and ".."
+		}
+		ext, n := binary.LittleEndian.Uint32(rec[2:]), binary.LittleEndian.Uint32(rec[10:])
+		if binary.BigEndian.Uint32(rec[6:]) != ext || binary.BigEndian.Uint32(rec[14:]) != n {
+			t.Errorf("record %q: little and big endian differ", decode(id))
+		}
+		vol.files[decode(id)] = img[int(ext)*sector : int(ext)*sector+int(n)]
+	}
+	return vol
+}
+
+func TestBuildISO(t *testing.T) {
+	big := bytes.Repeat([]byte("#cloud-config\n"), 400) // more than one sector
+	files := []File{
+		{Name: "user-data", Data: big},
+		{Name: "meta-data", Data: []byte("instance-id: iid-web\n")},
+		{Name: "network-config", Data: []byte("version: 2\n")},
+	}
+	img, err := BuildISO(VolumeID, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(img)%sector != 0 {
+		t.Fatalf("image size %d is no multiple of %d", len(img), sector)
+	}
+	if term := img[18*sector:]; term[0] != 255 || !bytes.Equal(term[1:6], []byte("CD001")) {
+		t.Error("no volume descriptor set terminator in sector 18")
+	}
+
+	primary := readVolume(t, img, 16, false)
+	joliet := readVolume(t, img, 17, true)
+	if primary.label != "cidata" || joliet.label != "cidata" {
+		t.Errorf("labels %q / %q, want cidata", primary.label, joliet.label)
+	}
+
+	want := map[string]string{"META_DATA.;1": "meta-data", "NETWORK_CONFIG.;1": "network-config", "USER_DATA.;1": "user-data"}
+	if got := slices.Sorted(maps.Keys(primary.files)); !slices.Equal(got, slices.Sorted(maps.Keys(want))) {
+		t.Errorf("primary tree: %v", got)
+	}
+	if got := slices.Sorted(maps.Keys(joliet.files)); !slices.Equal(got, []string{"meta-data", "network-config", "user-data"}) {
+		t.Errorf("Joliet tree: %v", got)
+	}
+	for _, f := range files {
+		if !bytes.Equal(joliet.files[f.Name], f.Data) {
+			t.Errorf("Joliet %s: %d bytes, want %d", f.Name, len(joliet.files[f.Name]), len(f.Data))
+		}
+	}
+	for iso, name := range want {
+		if !bytes.Equal(primary.files[iso], joliet.files[name]) {
+			t.Errorf("%s and %s differ", iso, name)
+		}
+	}
+}
+
+func TestBuildISOTooManyFiles(t *testing.T) {
+	var files []File
+	for i := range 60 {
+		files = append(files, File{Name: strings.Repeat("x", 20) + string(rune('a'+i%26)) + string(rune('a'+i/26))})
+	}
+	if _, err := BuildISO(VolumeID, files); err == nil {
+		t.Error("60 files fit into one directory sector")
+	}
+}
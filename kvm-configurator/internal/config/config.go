@@ -1,10 +1,11 @@
 // internal/config/config.go
-// last modified: Feb 22 2026
+// last modified: Oct 16 2026
 package config
 
 import (
 	"fmt"
 	"os"
+	"strings"
 	// internal
 	"configurator/internal/utils"
 	// external
@@ -12,10 +13,33 @@ import (
 )
 
 type FullConfig struct {
-	IsoPath  string     // path to iso dir
-	XmlDir   string     // path to xml save dir
-	Defaults Defaults   // global‑defaults (DiskPath, DiskSize …)
-	OSList   []VMConfig // OS‑Liste
+	IsoPath    string              // path to iso dir
+	XmlDir     string              // path to xml save dir
+	Defaults   Defaults            // global‑defaults (DiskPath, DiskSize …)
+	Families   map[string]VMConfig // family blocks (common_linux, windows_base, …)
+	OSList     []VMConfig          // OS‑Liste, every entry already resolved
+	Connection Connection          // how to reach libvirt
+	Advanced   Advanced            // what happens after a VM is created
+}
+
+// consoles that can be opened after the VM was started
+const (
+	ConsoleNone   = "none"
+	ConsoleViewer = "viewer" // virt-viewer (graphical)
+	ConsoleSerial = "serial" // virsh console
+)
+
+// Advanced holds the advanced_features section
+type Advanced struct {
+	StartInit    bool   `yaml:"start_init"`    // start the VM right after it was defined
+	StartConsole string `yaml:"start_console"` // none | viewer | serial (only with start_init)
+}
+
+// Connection selects the hypervisor and the backend used to reach it
+type Connection struct {
+	URI     string `yaml:"uri"`     // qemu:///system, qemu:///session, qemu+ssh://host/system; empty = libvirt default
+	Backend string `yaml:"backend"` // shell (virsh, default) | native (libvirt socket)
+	Socket  string `yaml:"socket"`  // libvirt socket for native; empty = default location
 }
 
 // VMConfig represents a single operating‑system or guest definition coming from the YAML file
@@ -27,22 +51,25 @@ type VMConfig struct {
 	DiskSize   int    `yaml:"disksize"`   // disk size in GiB
 	DiskPath   string `yaml:"diskpath"`   // path disk image
 	ISOPath    string `yaml:"isopath"`    // path iso image
-	NestedVirt string `yaml:"nvirt"`      // vmx (intel), smx (amd)
+	NestedVirt string `yaml:"nvirt"`      // vmx (intel), svm (amd)
 	Network    string `yaml:"network"`    // bridge | nat | none
 	Graphics   string `yaml:"graphics"`   // graphic driver / mode: spice | vnc | none
 	Sound      string `yaml:"sound"`      // ac97 | ich6 | ich9 (default)
 	FileSystem string `yaml:"filesystem"` // virtiofs | 9p | none
 	BootOrder  string `yaml:"bootorder"`  // stored as a string for backward compatibility
-	Firmware   string `yaml:"firmware"`   // BIOS | EFI (not working yet)
-}
+	Firmware   string `yaml:"firmware"`   // bios | efi | efi-secure (Secure Boot)
+	TPM        string `yaml:"tpm"`        // none | tpm-crb | tpm-tis (TPM 2.0, swtpm)
+	Family     string `yaml:"family"`     // family block the entry inherits from, e.g. windows_base
 
-// global defaults (are overwritten by every VM entry)
-type Defaults struct {
-	DiskPath string
-	DiskSize int
+	// Origin maps a yaml key (ram, graphics, …) to where the effective
+	// value came from: "profile", the family name or "defaults"
+	Origin map[string]string `yaml:"-"`
 }
 
-// load yaml
+// global defaults (every key of an OS entry, overwritten by family and entry)
+type Defaults VMConfig
+
+// LoadAll loads a single config file (no layers, no environment)
 func LoadAll(path string) (*FullConfig, error) {
 	// read config file
 	data, err := os.ReadFile(path)
@@ -50,6 +77,24 @@ func LoadAll(path string) (*FullConfig, error) {
 		return nil, fmt.Errorf("cannot read config %q: %w", path, err)
 	}
 
+	// schema check first – a typo should not surface later inside libvirt
+	if issues := ValidateData(path, data); HasErrors(issues) {
+		return nil, &ValidationError{Issues: issues}
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse config %q: %w", path, err)
+	}
+	cfg, err := decode(flatten(&doc))
+	if err != nil {
+		return nil, fmt.Errorf("config %q: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// decode turns the (merged) top-level mapping into a resolved FullConfig
+func decode(root *yaml.Node) (*FullConfig, error) {
 	// Unmarshall into a temporary root struct containing both filepaths and oslist
 	var raw struct {
 		Filepaths struct {
@@ -57,21 +102,74 @@ func LoadAll(path string) (*FullConfig, error) {
 			XmlDir  string `yaml:"xmlpath"`
 		} `yaml:"filepaths"`
 
-		Defaults Defaults   `yaml:"defaults"`
-		OSList   []VMConfig `yaml:"oslist"`
-	}
+		Defaults   Defaults   `yaml:"defaults"`
+		OSList     []VMConfig `yaml:"oslist"`
+		Connection Connection `yaml:"connection"`
+		Advanced   Advanced   `yaml:"advanced_features"`
 
-	if err := yaml.Unmarshal(data, &raw); err != nil {
-		return nil, fmt.Errorf("parse config %q: %w", path, err)
+		// everything else: family blocks
+		Rest map[string]yaml.Node `yaml:",inline"`
+	}
+	if err := root.Decode(&raw); err != nil {
+		return nil, err
 	}
 
+	families, err := decodeFamilies(raw.Rest)
+	if err != nil {
+		return nil, err
+	}
 	utils.ExpandEnvInStruct(&raw)
+	utils.ExpandEnvInStruct(&families)
 
 	// assemble result
-	return &FullConfig{
-		IsoPath:  raw.Filepaths.IsoPath,
-		XmlDir:   raw.Filepaths.XmlDir,
-		Defaults: raw.Defaults,
-		OSList:   raw.OSList,
-	}, nil
+	cfg := &FullConfig{
+		IsoPath:    raw.Filepaths.IsoPath,
+		XmlDir:     raw.Filepaths.XmlDir,
+		Defaults:   raw.Defaults,
+		Families:   families,
+		OSList:     raw.OSList,
+		Connection: raw.Connection,
+		Advanced:   raw.Advanced,
+	}
+	if err := cfg.resolveProfiles(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// UsesTPM reports whether any OS entry asks for an emulated TPM
+func (c *FullConfig) UsesTPM() bool {
+	for _, p := range c.OSList {
+		if p.TPM != "" && p.TPM != "none" {
+			return true
+		}
+	}
+	return false
+}
+
+// FindProfile looks up an OS entry by its id or display name (case-insensitive).
+// An id shared by several entries is ambiguous unless the name matches exactly.
+func FindProfile(list []VMConfig, key string) (VMConfig, error) {
+	key = strings.TrimSpace(key)
+	var byID []VMConfig
+	for _, d := range list {
+		if strings.EqualFold(d.Name, key) {
+			return d, nil
+		}
+		if strings.EqualFold(d.ID, key) {
+			byID = append(byID, d)
+		}
+	}
+	switch len(byID) {
+	case 0:
+		return VMConfig{}, fmt.Errorf("no OS profile %q in config", key)
+	case 1:
+		return byID[0], nil
+	}
+	names := make([]string, 0, len(byID))
+	for _, d := range byID {
+		names = append(names, d.Name)
+	}
+	return VMConfig{}, fmt.Errorf("profile id %q is ambiguous (%s) – use the name instead",
+		key, strings.Join(names, ", "))
 }
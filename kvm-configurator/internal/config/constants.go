@@ -1,5 +1,5 @@
 // internal/constants.go
-// last modified: Feb 27 2026
+// last modified: Oct 16 2026
 package config
 
 import (
@@ -13,6 +13,9 @@ import (
 const (
     CmdVirtInstall  = "virt-install"
     CmdVirsh        = "virsh"
+    CmdQemuImg      = "qemu-img"
+    CmdVirtViewer   = "virt-viewer"
+    CmdSwtpm        = "swtpm"
     ConfigFolder       = ".config/kvm-configurator"
     ConfigFile      = "oslist.yaml"
 		InstalledTemplate = "/usr/share/doc/kvm-configurator/oslist.yaml"
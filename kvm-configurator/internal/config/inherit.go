@@ -0,0 +1,150 @@
+// internal/config/inherit.go
+// last modified: Oct 16 2026
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	// external
+	"gopkg.in/yaml.v3"
+)
+
+// origins of an effective profile value
+const (
+	OriginProfile  = "profile"  // set in the oslist entry itself
+	OriginDefaults = "defaults" // from the defaults block
+)
+
+// sections are the top-level keys with a fixed meaning; every other
+// top-level mapping is a family block
+var sections = map[string]bool{
+	"filepaths":         true,
+	"connection":        true,
+	"advanced_features": true,
+	"defaults":          true,
+	"oslist":            true,
+}
+
+// keys an entry never inherits
+var ownKeys = map[string]bool{"name": true, "id": true, "family": true}
+
+// decodeFamilies turns the remaining top-level mappings into family blocks
+func decodeFamilies(rest map[string]yaml.Node) (map[string]VMConfig, error) {
+	families := map[string]VMConfig{}
+	for name, node := range rest {
+		if sections[name] || node.Kind != yaml.MappingNode {
+			continue
+		}
+		var f VMConfig
+		if err := node.Decode(&f); err != nil {
+			return nil, fmt.Errorf("family %s: %w", name, err)
+		}
+		families[name] = f
+	}
+	return families, nil
+}
+
+// FamilyNames returns the names of all family blocks, sorted
+func (c *FullConfig) FamilyNames() []string {
+	names := make([]string, 0, len(c.Families))
+	for n := range c.Families {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/*
+resolveProfiles fills every unset key of every oslist entry: first from
+its family block (family: windows_base), then from defaults. YAML anchors
+and <<: merges still work, but are no longer needed – what they merge in
+simply counts as set in the entry.
+*/
+func (c *FullConfig) resolveProfiles() error {
+	for i := range c.OSList {
+		e := &c.OSList[i]
+		if e.ID == "" {
+			// layers may extend an entry by name only – the id must come from somewhere
+			return fmt.Errorf("oslist entry %q: missing id", e.Name)
+		}
+		if err := c.resolve(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Resolve fills the unset keys of an entry that is not part of the
+// oslist (e.g. an OS picked from the osinfo database)
+func (c *FullConfig) Resolve(p VMConfig) (VMConfig, error) {
+	err := c.resolve(&p)
+	return p, err
+}
+
+func (c *FullConfig) resolve(e *VMConfig) error {
+	e.Origin = map[string]string{}
+	inherit(e, *e, OriginProfile)
+	if e.Family != "" {
+		fam, ok := c.Families[e.Family]
+		if !ok {
+			return fmt.Errorf("oslist entry %q: unknown family %q", e.Name, e.Family)
+		}
+		inherit(e, fam, e.Family)
+	}
+	inherit(e, VMConfig(c.Defaults), OriginDefaults)
+	return nil
+}
+
+/*
+Minimal returns p without the keys it would inherit anyway (same value in
+its family or the defaults), so a saved profile only carries what differs
+and keeps following later changes of the family and defaults.
+*/
+func (c *FullConfig) Minimal(p VMConfig) VMConfig {
+	base := VMConfig{Name: p.Name, ID: p.ID, Family: p.Family, Origin: map[string]string{}}
+	if fam, ok := c.Families[p.Family]; ok {
+		inherit(&base, fam, p.Family)
+	}
+	inherit(&base, VMConfig(c.Defaults), OriginDefaults)
+	out := p
+	out.Origin = nil
+	for _, key := range ProfileKeys() {
+		if !ownKeys[key] && out.Get(key) == base.Get(key) {
+			out.Set(key, "")
+		}
+	}
+	return out
+}
+
+// inherit copies every key that is still zero in dst from src and records
+// origin for it (src == *dst only records the origins of the own values)
+func inherit(dst *VMConfig, src VMConfig, origin string) {
+	dv := reflect.ValueOf(dst).Elem()
+	sv := reflect.ValueOf(src)
+	for i := 0; i < dv.NumField(); i++ {
+		key := yamlKey(dv.Type().Field(i))
+		if key == "" || ownKeys[key] {
+			continue
+		}
+		if _, done := dst.Origin[key]; done {
+			continue
+		}
+		if v := sv.Field(i); !v.IsZero() {
+			dv.Field(i).Set(v)
+			dst.Origin[key] = origin
+		}
+	}
+}
+
+// yamlKey returns the yaml name of a field ("" for yaml:"-")
+func yamlKey(f reflect.StructField) string {
+	tag := f.Tag.Get("yaml")
+	if tag == "" || tag == "-" {
+		return ""
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	return name
+}
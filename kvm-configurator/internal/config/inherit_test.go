@@ -0,0 +1,88 @@
+// internal/config/inherit_test.go
+// last modified: Oct 16 2026
+package config
+
+import (
+	"maps"
+	"reflect"
+	"testing"
+)
+
+func TestResolveProfiles(t *testing.T) {
+	cfg, err := LoadAll("testdata/inherit.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name   string
+		want   map[string]string // key → effective value
+		origin map[string]string
+	}{
+		{"Windows 11",
+			map[string]string{"cpu": "4", "ram": "8192", "disksize": "80", "firmware": "efi-secure", "tpm": "tpm-crb", "graphics": "spice", "network": "network=default", "sound": ""},
+			map[string]string{
+				"cpu": OriginProfile, "disksize": OriginProfile,
+				"ram": "windows_base", "firmware": "windows_base", "tpm": "windows_base",
+				"graphics": OriginDefaults, "network": OriginDefaults,
+			}},
+		{"Debian 13",
+			map[string]string{"cpu": "2", "ram": "2048", "disksize": "20", "firmware": "", "graphics": "none"},
+			map[string]string{
+				"graphics": OriginProfile,
+				"cpu":      OriginDefaults, "ram": OriginDefaults, "disksize": OriginDefaults, "network": OriginDefaults,
+			}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := FindProfile(cfg.OSList, tc.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for key, want := range tc.want {
+				if got := p.Get(key); got != want {
+					t.Errorf("%s = %q, want %q", key, got, want)
+				}
+			}
+			// unset keys have no origin; name, id and family are never inherited
+			if !maps.Equal(p.Origin, tc.origin) {
+				t.Errorf("Origin = %v, want %v", p.Origin, tc.origin)
+			}
+		})
+	}
+}
+
+func TestResolveUnknownFamily(t *testing.T) {
+	cfg, err := LoadAll("testdata/inherit.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = cfg.Resolve(VMConfig{Name: "Windows Server", ID: "win2k22", Family: "windows_server"})
+	if err == nil || err.Error() != `oslist entry "Windows Server": unknown family "windows_server"` {
+		t.Errorf("Resolve: %v", err)
+	}
+
+	// a picked OS without family: only the defaults
+	p, err := cfg.Resolve(VMConfig{Name: "FreeBSD 14", ID: "freebsd14.0", RAM: 1024})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.RAM != 1024 || p.CPU != 2 || p.Origin["ram"] != OriginProfile || p.Origin["cpu"] != OriginDefaults {
+		t.Errorf("Resolve = %+v", p)
+	}
+}
+
+func TestMinimal(t *testing.T) {
+	cfg, err := LoadAll("testdata/inherit.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := FindProfile(cfg.OSList, "Windows 11")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// what equals the family or the defaults is dropped, the own values stay
+	got := cfg.Minimal(p)
+	want := VMConfig{Name: "Windows 11", ID: "win11", Family: "windows_base", CPU: 4, DiskSize: 80}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Minimal = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,339 @@
+// internal/config/layers.go
+// last modified: Oct 16 2026
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	// external
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	SystemConfigDir = "/etc/kvm-configurator"  // team-wide profiles shipped by admins
+	ProjectFile     = ".kvm-configurator.yaml" // optional, in the working directory
+	DropInDir       = "oslist.d"               // *.yaml drop-ins below the system and user dir
+	EnvPrefix       = "KVMCONF_"               // KVMCONF_<SECTION>_<KEY>, e.g. KVMCONF_CONNECTION_URI
+	OriginEnvPrefix = "env "                   // origin of values from the environment
+)
+
+// layer kinds, lowest priority first
+const (
+	LayerSystem  = "system"
+	LayerUser    = "user"
+	LayerProject = "project"
+	LayerDropIn  = "drop-in"
+)
+
+// Layer is one config file that takes part in the merge
+type Layer struct {
+	Kind string
+	Path string
+}
+
+/*
+DefaultLayers returns the existing config files in merge order:
+
+	/etc/kvm-configurator/oslist.yaml
+	~/.config/kvm-configurator/oslist.yaml
+	./.kvm-configurator.yaml
+	/etc/kvm-configurator/oslist.d/*.yaml, ~/.config/kvm-configurator/oslist.d/*.yaml
+
+KVMCONF_* environment variables are applied on top by Merge.
+*/
+func DefaultLayers() []Layer {
+	var layers []Layer
+	add := func(kind, path string) {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			layers = append(layers, Layer{Kind: kind, Path: path})
+		}
+	}
+	add(LayerSystem, filepath.Join(SystemConfigDir, ConfigFile))
+	add(LayerUser, ConfigFilePath())
+	add(LayerProject, ProjectFile)
+	for _, dir := range []string{SystemConfigDir, filepath.Dir(ConfigFilePath())} {
+		files, _ := filepath.Glob(filepath.Join(dir, DropInDir, "*.yaml"))
+		sort.Strings(files)
+		for _, f := range files {
+			add(LayerDropIn, f)
+		}
+	}
+	return layers
+}
+
+// Merged is the combination of all layers
+type Merged struct {
+	Root    *yaml.Node            // merged top-level mapping
+	Origins map[*yaml.Node]string // scalar value → "file:line" or "env KVMCONF_…"
+	Layers  []Layer               // the files that were read
+	Issues  []Issue               // warnings of all layers (errors abort Merge)
+}
+
+/*
+Merge reads the layers in order and lays them over each other: mappings are
+merged key by key, oslist entries by name (an entry with a known name only
+changes the keys it sets, a new name is appended), everything else is
+replaced. environ (os.Environ()) adds the KVMCONF_* overrides last.
+*/
+func Merge(layers []Layer, environ []string) (*Merged, error) {
+	m := &Merged{
+		Root:    &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"},
+		Origins: map[*yaml.Node]string{},
+		Layers:  layers,
+	}
+	var issues []Issue
+	for _, l := range layers {
+		data, err := os.ReadFile(l.Path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read config %q: %w", l.Path, err)
+		}
+		layerIssues := validateLayer(l.Path, data)
+		issues = append(issues, layerIssues...)
+		if HasErrors(layerIssues) {
+			continue // keep going to report the other layers, too
+		}
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("parse config %q: %w", l.Path, err)
+		}
+		if len(doc.Content) == 0 {
+			continue // empty drop-in
+		}
+		path := l.Path
+		m.merge(m.Root, flatten(&doc), true, func(n *yaml.Node) string {
+			return fmt.Sprintf("%s:%d", path, n.Line)
+		})
+	}
+
+	env, names, envIssues := envLayer(environ)
+	issues = append(issues, envIssues...)
+	if !HasErrors(envIssues) && len(env.Content) > 0 {
+		m.merge(m.Root, env, true, func(n *yaml.Node) string { return OriginEnvPrefix + names[n] })
+	}
+
+	if HasErrors(issues) {
+		return nil, &ValidationError{Issues: issues}
+	}
+	m.Issues = issues
+	return m, nil
+}
+
+// Load merges all default layers and the environment into one config
+func Load() (*FullConfig, error) {
+	m, err := Merge(DefaultLayers(), os.Environ())
+	if err != nil {
+		return nil, err
+	}
+	if len(m.Layers) == 0 {
+		return nil, fmt.Errorf("no config found (%s or %s)", ConfigFilePath(), filepath.Join(SystemConfigDir, ConfigFile))
+	}
+	return m.Config()
+}
+
+// Config decodes and resolves the merged tree
+func (m *Merged) Config() (*FullConfig, error) {
+	cfg, err := decode(m.Root)
+	if err != nil {
+		return nil, fmt.Errorf("merged config: %w", err)
+	}
+	return cfg, nil
+}
+
+// merge lays src over dst (both mappings) and records the origin of
+// every scalar src brings in
+func (m *Merged) merge(dst, src *yaml.Node, root bool, origin func(*yaml.Node) string) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		k, v := src.Content[i], src.Content[i+1]
+		j := keyIndex(dst, k.Value)
+		switch {
+		case j < 0:
+			dst.Content = append(dst.Content, k, v)
+			m.mark(v, origin)
+		case root && k.Value == "oslist" && dst.Content[j+1].Kind == yaml.SequenceNode && v.Kind == yaml.SequenceNode:
+			m.mergeOSList(dst.Content[j+1], v, origin)
+		case dst.Content[j+1].Kind == yaml.MappingNode && v.Kind == yaml.MappingNode:
+			m.merge(dst.Content[j+1], v, false, origin)
+		default:
+			dst.Content[j+1] = v
+			m.mark(v, origin)
+		}
+	}
+}
+
+// mergeOSList matches entries by name (case-insensitive)
+func (m *Merged) mergeOSList(dst, src *yaml.Node, origin func(*yaml.Node) string) {
+	for _, e := range src.Content {
+		var target *yaml.Node
+		if name := mappingValue(e, "name"); name != nil {
+			for _, d := range dst.Content {
+				if dn := mappingValue(d, "name"); dn != nil && strings.EqualFold(dn.Value, name.Value) {
+					target = d
+					break
+				}
+			}
+		}
+		if target != nil && e.Kind == yaml.MappingNode {
+			m.merge(target, e, false, origin)
+			continue
+		}
+		dst.Content = append(dst.Content, e)
+		m.mark(e, origin)
+	}
+}
+
+// mark records origin for all scalars below n
+func (m *Merged) mark(n *yaml.Node, origin func(*yaml.Node) string) {
+	if n.Kind == yaml.ScalarNode {
+		m.Origins[n] = origin(n)
+		return
+	}
+	for i, c := range n.Content {
+		if n.Kind == yaml.MappingNode && i%2 == 0 {
+			continue // keys
+		}
+		m.mark(c, origin)
+	}
+}
+
+// Annotate returns a copy of the merged tree with "# origin" comments
+// behind every value (and without the comments of the source files)
+func (m *Merged) Annotate(withOrigin bool) *yaml.Node {
+	var walk func(n *yaml.Node) *yaml.Node
+	walk = func(n *yaml.Node) *yaml.Node {
+		c := *n
+		c.HeadComment, c.LineComment, c.FootComment = "", "", ""
+		if n.Kind == yaml.ScalarNode {
+			if o, ok := m.Origins[n]; ok && withOrigin {
+				c.LineComment = o
+			}
+			return &c
+		}
+		c.Content = make([]*yaml.Node, len(n.Content))
+		for i, ch := range n.Content {
+			c.Content[i] = walk(ch)
+		}
+		return &c
+	}
+	return walk(m.Root)
+}
+
+// keyIndex returns the index of key in a mapping (-1 if missing)
+func keyIndex(n *yaml.Node, key string) int {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+/*
+flatten returns a deep copy of a document without aliases and <<: merges,
+so layers can be merged without touching shared (anchored) nodes.
+Own keys win over merged ones, the first merge source over later ones.
+*/
+func flatten(n *yaml.Node) *yaml.Node {
+	n = resolve(n)
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		return flatten(n.Content[0])
+	case yaml.MappingNode:
+		out := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: n.Line, Column: n.Column, Style: n.Style}
+		var merged []*yaml.Node
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			if k.Tag == "!!merge" {
+				for _, src := range mergeSources(v) {
+					merged = append(merged, flatten(src).Content...)
+				}
+				continue
+			}
+			out.Content = append(out.Content, flatten(k), flatten(v))
+		}
+		for i := 0; i+1 < len(merged); i += 2 {
+			if keyIndex(out, merged[i].Value) < 0 {
+				out.Content = append(out.Content, merged[i], merged[i+1])
+			}
+		}
+		return out
+	default:
+		c := *n
+		c.Anchor = ""
+		c.Content = nil
+		for _, ch := range n.Content {
+			c.Content = append(c.Content, flatten(ch))
+		}
+		return &c
+	}
+}
+
+// envSections are the sections that can be overridden by KVMCONF_<SECTION>_<KEY>
+var envSections = []struct {
+	name string
+	keys map[string]keyCheck
+}{
+	{"filepaths", filepathKeys},
+	{"connection", connectionKeys},
+	{"advanced_features", advancedKeys},
+	{"defaults", profileKeys},
+}
+
+// envLayer builds a config document from the KVMCONF_* variables; names maps
+// each value node to its variable
+func envLayer(environ []string) (*yaml.Node, map[*yaml.Node]string, []Issue) {
+	doc := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	names := map[*yaml.Node]string{}
+	var issues []Issue
+	for _, kv := range slices.Sorted(slices.Values(environ)) { // stable order for messages
+
+		name, val, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(name, EnvPrefix) {
+			continue
+		}
+		section, key := envKey(name)
+		if section == "" {
+			issues = append(issues, Issue{File: "environment", Msg: fmt.Sprintf("unknown variable %s", name), Warning: true})
+			continue
+		}
+		sec := mappingValue(doc, section)
+		if sec == nil {
+			sec = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: section}, sec)
+		}
+		v := &yaml.Node{Kind: yaml.ScalarNode, Value: val} // tag resolved like plain YAML
+		sec.Content = append(sec.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, v)
+		names[v] = name
+	}
+	// same checks as for a file
+	v := &validator{file: "environment", partial: true}
+	v.root(doc)
+	return doc, names, append(issues, v.issues...)
+}
+
+// envKey maps KVMCONF_CONNECTION_URI to ("connection", "uri")
+func envKey(name string) (section, key string) {
+	rest := strings.ToLower(strings.TrimPrefix(name, EnvPrefix))
+	for _, s := range envSections {
+		k, ok := strings.CutPrefix(rest, s.name+"_")
+		if !ok {
+			continue
+		}
+		if _, known := s.keys[k]; known && !(s.name == "defaults" && ownKeys[k]) {
+			return s.name, k
+		}
+	}
+	return "", ""
+}
+
+// ExistingLayer reports whether any config file exists at all
+func ExistingLayer() bool {
+	return len(DefaultLayers()) > 0
+}
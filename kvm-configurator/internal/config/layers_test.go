@@ -0,0 +1,154 @@
+// internal/config/layers_test.go
+// last modified: Oct 16 2026
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	// external
+	"gopkg.in/yaml.v3"
+)
+
+// the files of testdata/layers in the order DefaultLayers returns them
+var testLayers = []Layer{
+	{LayerSystem, "testdata/layers/etc/oslist.yaml"},
+	{LayerUser, "testdata/layers/home/.config/kvm-configurator/oslist.yaml"},
+	{LayerProject, "testdata/layers/project/.kvm-configurator.yaml"},
+	{LayerDropIn, "testdata/layers/etc/oslist.d/10-team.yaml"},
+	{LayerDropIn, "testdata/layers/home/.config/kvm-configurator/oslist.d/20-mine.yaml"},
+}
+
+// valueAt follows keys (mapping keys, or an entry name below oslist) in the merged tree
+func valueAt(t *testing.T, root *yaml.Node, keys ...string) *yaml.Node {
+	t.Helper()
+	n := root
+	for _, k := range keys {
+		if n.Kind == yaml.SequenceNode {
+			i := slices.IndexFunc(n.Content, func(e *yaml.Node) bool {
+				name := mappingValue(e, "name")
+				return name != nil && name.Value == k
+			})
+			if i < 0 {
+				t.Fatalf("no entry %q in %v", k, keys)
+			}
+			n = n.Content[i]
+			continue
+		}
+		if n = mappingValue(n, k); n == nil {
+			t.Fatalf("%v not in the merged tree", keys)
+		}
+	}
+	return n
+}
+
+func TestMergePrecedence(t *testing.T) {
+	t.Setenv("KVMCONF_DEFAULTS_CPU", "8")
+	m, err := Merge(testLayers, os.Environ())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// each key is won by the last layer that sets it
+	for _, tc := range []struct {
+		keys          []string
+		value, origin string
+	}{
+		{[]string{"filepaths", "isopath"}, "/srv/iso", "testdata/layers/etc/oslist.yaml:3"},
+		{[]string{"filepaths", "xmlpath"}, "/home/me/vms", "testdata/layers/home/.config/kvm-configurator/oslist.yaml:3"},
+		{[]string{"connection", "uri"}, "qemu+ssh://lab/system", "testdata/layers/project/.kvm-configurator.yaml:3"},
+		{[]string{"defaults", "graphics"}, "spice", "testdata/layers/etc/oslist.yaml:13"},
+		{[]string{"defaults", "ram"}, "8192", "testdata/layers/etc/oslist.d/10-team.yaml:3"},
+		{[]string{"defaults", "disksize"}, "40", "testdata/layers/home/.config/kvm-configurator/oslist.d/20-mine.yaml:2"},
+		{[]string{"defaults", "cpu"}, "8", "env KVMCONF_DEFAULTS_CPU"},
+		// one entry in three layers, merged key by key
+		{[]string{"oslist", "Debian 13", "cpu"}, "2", "testdata/layers/etc/oslist.yaml:18"},
+		{[]string{"oslist", "Debian 13", "ram"}, "4096", "testdata/layers/home/.config/kvm-configurator/oslist.yaml:15"},
+		{[]string{"oslist", "Debian 13", "network"}, "bridge=br0", "testdata/layers/etc/oslist.d/10-team.yaml:8"},
+	} {
+		n := valueAt(t, m.Root, tc.keys...)
+		if n.Value != tc.value || m.Origins[n] != tc.origin {
+			t.Errorf("%v = %q from %q, want %q from %q", tc.keys, n.Value, m.Origins[n], tc.value, tc.origin)
+		}
+	}
+
+	cfg, err := m.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range cfg.OSList {
+		names = append(names, p.Name)
+	}
+	if want := []string{"Debian 13", "Alpine", "Fedora 41"}; !slices.Equal(names, want) {
+		t.Errorf("oslist %v, want %v (same names merged, new ones appended)", names, want)
+	}
+	deb := cfg.OSList[0]
+	if deb.ID != "debian13" || deb.CPU != 2 || deb.RAM != 4096 || deb.DiskSize != 40 || deb.Network != "bridge=br0" {
+		t.Errorf("Debian 13 = %+v", deb)
+	}
+	if cfg.Defaults.CPU != 8 || cfg.Connection.URI != "qemu+ssh://lab/system" || cfg.XmlDir != "/home/me/vms" {
+		t.Errorf("defaults %+v, connection %+v, xml dir %q", cfg.Defaults, cfg.Connection, cfg.XmlDir)
+	}
+}
+
+func TestMergeInvalidEnvironment(t *testing.T) {
+	t.Setenv("KVMCONF_DEFAULTS_RAM", "lots")
+	t.Setenv("KVMCONF_CONNECTION_BACKEND", "libvirt")
+	t.Setenv("KVMCONF_DEFAULTS_NAME", "Debian") // entries only
+	_, err := Merge(testLayers, os.Environ())
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Merge: %v", err)
+	}
+	want := []string{
+		"environment: warning: unknown variable KVMCONF_DEFAULTS_NAME",
+		`environment: connection.backend: unknown value "libvirt" (shell, native)`,
+		`environment: defaults.ram must be a whole number (got "lots")`,
+	}
+	if got := issueLines(verr.Issues); !slices.Equal(got, want) {
+		t.Errorf("issues:\n%q\nwant\n%q", got, want)
+	}
+
+	// a warning alone does not stop the merge
+	t.Setenv("KVMCONF_CONNECTION_BACKEND", "native")
+	os.Unsetenv("KVMCONF_DEFAULTS_RAM") // t.Setenv above restores it
+	m, err := Merge(testLayers, os.Environ())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := issueLines(m.Issues); !slices.Equal(got, want[:1]) {
+		t.Errorf("warnings %q", got)
+	}
+	if n := valueAt(t, m.Root, "connection", "backend"); n.Value != "native" || m.Origins[n] != "env KVMCONF_CONNECTION_BACKEND" {
+		t.Errorf("backend %q from %q", n.Value, m.Origins[n])
+	}
+}
+
+func TestDefaultLayers(t *testing.T) {
+	home, err := filepath.Abs("testdata/layers/home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", home)
+	t.Chdir("testdata/layers/project")
+
+	var got []Layer
+	for _, l := range DefaultLayers() {
+		if !strings.HasPrefix(l.Path, SystemConfigDir) { // not this machine's /etc
+			got = append(got, l)
+		}
+	}
+	want := []Layer{
+		{LayerUser, filepath.Join(home, ".config/kvm-configurator/oslist.yaml")},
+		{LayerProject, ".kvm-configurator.yaml"},
+		{LayerDropIn, filepath.Join(home, ".config/kvm-configurator/oslist.d/20-mine.yaml")},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("DefaultLayers = %v, want %v", got, want)
+	}
+}
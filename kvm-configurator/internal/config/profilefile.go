@@ -0,0 +1,303 @@
+// internal/config/profilefile.go
+// last modified: Oct 16 2026
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	// external
+	"gopkg.in/yaml.v3"
+)
+
+/*
+ProfileFile is an oslist.yaml opened for editing. Changes are spliced into
+the file text at the positions of the yaml.Node tree, so everything that is
+not touched – comments, blank lines, anchors and merges, the order of keys
+and entries, other sections – is written back byte for byte. Edits that
+cannot be spliced are made on the tree and the file is re-encoded.
+*/
+type ProfileFile struct {
+	Path string
+	doc  yaml.Node
+	src  []byte // file text; nil once the tree had to be edited instead
+}
+
+// OpenProfileFile reads path; a missing file starts as an empty oslist
+func OpenProfileFile(path string) (*ProfileFile, error) {
+	f := &ProfileFile{Path: path}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("cannot read config %q: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, &f.doc); err != nil {
+		return nil, fmt.Errorf("parse config %q: %w", path, err)
+	}
+	if len(data) > 0 {
+		f.src = data
+	}
+	if len(f.doc.Content) == 0 {
+		f.src = nil
+		f.doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	if f.doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("config %q: top level is not a mapping", path)
+	}
+	return f, nil
+}
+
+// list returns the oslist sequence, created on demand
+func (f *ProfileFile) list(create bool) *yaml.Node {
+	root := f.doc.Content[0]
+	if i := keyIndex(root, "oslist"); i >= 0 {
+		return resolve(root.Content[i+1])
+	}
+	if !create {
+		return &yaml.Node{Kind: yaml.SequenceNode}
+	}
+	seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	root.Content = append(root.Content, scalar("oslist", 0), seq)
+	return seq
+}
+
+// Profiles returns the entries of this file as written (not resolved;
+// <<: merges are applied)
+func (f *ProfileFile) Profiles() ([]VMConfig, error) {
+	var out []VMConfig
+	for _, e := range f.list(false).Content {
+		var p VMConfig
+		if err := e.Decode(&p); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", f.Path, e.Line, err)
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// index returns the position of the entry called name (-1 if missing)
+func (f *ProfileFile) index(name string) int {
+	for i, e := range f.list(false).Content {
+		if n := mappingValue(resolve(e), "name"); n != nil && strings.EqualFold(n.Value, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Add appends a new entry; only the non-empty keys are written
+func (f *ProfileFile) Add(p VMConfig) error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("profile has no name")
+	}
+	if f.index(p.Name) >= 0 {
+		return fmt.Errorf("profile %q already exists in %s", p.Name, f.Path)
+	}
+	entry := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	setKeys(entry, VMConfig{}, p)
+	if f.src != nil {
+		if ls, ok := f.spliceAdd(entry); ok && f.reload(ls) {
+			return nil
+		}
+	}
+	seq := f.list(true)
+	seq.Content = append(seq.Content, entry)
+	f.src = nil
+	return nil
+}
+
+// Update changes the entry called name to p; only keys whose value differs
+// from the current entry are touched, emptied keys are removed (inherit again)
+func (f *ProfileFile) Update(name string, p VMConfig) error {
+	i := f.index(name)
+	if i < 0 {
+		return fmt.Errorf("no profile %q in %s", name, f.Path)
+	}
+	if !strings.EqualFold(name, p.Name) && f.index(p.Name) >= 0 {
+		return fmt.Errorf("profile %q already exists in %s", p.Name, f.Path)
+	}
+	seq := f.list(false)
+	entry := resolve(seq.Content[i])
+	if entry.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s:%d: profile %q is not a mapping", f.Path, entry.Line, name)
+	}
+	var old VMConfig
+	if err := entry.Decode(&old); err != nil {
+		return err
+	}
+	// an alias entry is edited at its anchor, elsewhere in the file
+	if f.src != nil && entry == seq.Content[i] {
+		ls := lines(f.src)
+		if ls, ok := spliceKeys(ls, entry, f.entryEnd(ls, seq, i), old, p); ok && f.reload(ls) {
+			return nil
+		}
+	}
+	setKeys(entry, old, p)
+	f.src = nil
+	return nil
+}
+
+// Delete removes the entry called name
+func (f *ProfileFile) Delete(name string) error {
+	i := f.index(name)
+	if i < 0 {
+		return fmt.Errorf("no profile %q in %s", name, f.Path)
+	}
+	if f.src != nil {
+		if ls, ok := f.spliceDelete(i); ok && f.reload(ls) {
+			return nil
+		}
+	}
+	seq := f.list(false)
+	seq.Content = append(seq.Content[:i], seq.Content[i+1:]...)
+	f.src = nil
+	return nil
+}
+
+/*
+Save checks the edited file like any other config layer and writes it. The
+previous version is kept next to it as <file>.<yyyymmdd-hhmmss>.bak; the
+backup path is returned ("" for a new file).
+*/
+func (f *ProfileFile) Save() (string, error) {
+	var buf bytes.Buffer
+	if f.src != nil {
+		buf.Write(f.src)
+	} else {
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(&f.doc); err != nil {
+			return "", err
+		}
+		if err := enc.Close(); err != nil {
+			return "", err
+		}
+	}
+	if issues := validateLayer(f.Path, buf.Bytes()); HasErrors(issues) {
+		return "", &ValidationError{Issues: issues}
+	}
+
+	var backup string
+	if old, err := os.ReadFile(f.Path); err == nil {
+		backup = fmt.Sprintf("%s.%s.bak", f.Path, time.Now().Format("20060102-150405"))
+		if err := os.WriteFile(backup, old, 0o644); err != nil {
+			return "", fmt.Errorf("backup %s: %w", backup, err)
+		}
+	} else if err := os.MkdirAll(filepath.Dir(f.Path), 0o755); err != nil {
+		return "", err
+	}
+
+	// write next to the target and rename – never leave a half-written config
+	tmp := f.Path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, f.Path); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return backup, nil
+}
+
+// setKeys writes every key that differs between old and p into entry
+func setKeys(entry *yaml.Node, old, p VMConfig) {
+	ov, nv := reflect.ValueOf(old), reflect.ValueOf(p)
+	for i := 0; i < nv.NumField(); i++ {
+		key := yamlKey(nv.Type().Field(i))
+		if key == "" || nv.Field(i).Equal(ov.Field(i)) {
+			continue
+		}
+		j := keyIndex(entry, key)
+		if nv.Field(i).IsZero() {
+			if j >= 0 {
+				entry.Content = append(entry.Content[:j], entry.Content[j+2:]...)
+			}
+			continue
+		}
+		val := fieldNode(nv.Field(i))
+		if j >= 0 {
+			val.Style = entry.Content[j+1].Style // keep the quoting of the file
+			val.LineComment = entry.Content[j+1].LineComment
+			entry.Content[j+1] = val
+		} else {
+			entry.Content = append(entry.Content, scalar(key, 0), val)
+		}
+	}
+}
+
+// fieldNode turns a string or int field into a scalar node
+func fieldNode(v reflect.Value) *yaml.Node {
+	if v.Kind() == reflect.Int {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.FormatInt(v.Int(), 10)}
+	}
+	return scalar(v.String(), 0)
+}
+
+func scalar(s string, style yaml.Style) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s, Style: style}
+}
+
+// ProfileKeys lists the keys of an OS entry in file order
+func ProfileKeys() []string {
+	var keys []string
+	t := reflect.TypeOf(VMConfig{})
+	for i := 0; i < t.NumField(); i++ {
+		if key := yamlKey(t.Field(i)); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Get returns the value of a profile key as text ("" if unset)
+func (p *VMConfig) Get(key string) string {
+	v, ok := p.field(key)
+	if !ok || v.IsZero() {
+		return ""
+	}
+	if v.Kind() == reflect.Int {
+		return strconv.FormatInt(v.Int(), 10)
+	}
+	return v.String()
+}
+
+// Set checks value like the config validation does and stores it;
+// an empty value clears the key
+func (p *VMConfig) Set(key, value string) error {
+	v, ok := p.field(key)
+	if !ok {
+		return fmt.Errorf("unknown key %q", key)
+	}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		v.SetZero()
+		return nil
+	}
+	if err := CheckValue(key, value); err != nil {
+		return err
+	}
+	if v.Kind() == reflect.Int {
+		i, _ := strconv.Atoi(value) // checked above
+		v.SetInt(int64(i))
+		return nil
+	}
+	v.SetString(value)
+	return nil
+}
+
+func (p *VMConfig) field(key string) (reflect.Value, bool) {
+	v := reflect.ValueOf(p).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if yamlKey(v.Type().Field(i)) == key {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
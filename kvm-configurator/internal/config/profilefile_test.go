@@ -0,0 +1,231 @@
+// internal/config/profilefile_test.go
+// last modified: Oct 16 2026
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// copyFile puts data into a temporary oslist.yaml
+func copyFile(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "oslist.yaml")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// profile returns the entry called name as written in f
+func profile(t *testing.T, f *ProfileFile, name string) VMConfig {
+	t.Helper()
+	list, err := f.Profiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := FindProfile(list, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// replace is strings.Replace that insists on exactly one match
+func replace(t *testing.T, s, old, new string) string {
+	t.Helper()
+	if n := strings.Count(s, old); n != 1 {
+		t.Fatalf("%d matches of %q", n, old)
+	}
+	return strings.Replace(s, old, new, 1)
+}
+
+// saveAndCompare saves f and checks the file and its backup byte for byte
+func saveAndCompare(t *testing.T, f *ProfileFile, orig []byte, want string) {
+	t.Helper()
+	backup, err := f.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(f.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("saved file differs:\n--- got\n%s\n--- want\n%s", got, want)
+	}
+	if !strings.HasSuffix(backup, ".bak") || filepath.Dir(backup) != filepath.Dir(f.Path) {
+		t.Errorf("backup %q is not a .bak next to %s", backup, f.Path)
+	}
+	if old, err := os.ReadFile(backup); err != nil || string(old) != string(orig) {
+		t.Errorf("backup does not hold the previous file (%v)", err)
+	}
+}
+
+func TestProfileFileShippedUnchanged(t *testing.T) {
+	orig, err := os.ReadFile("../../oslist.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := OpenProfileFile(copyFile(t, orig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	saveAndCompare(t, f, orig, string(orig))
+}
+
+func TestProfileFileShippedEdits(t *testing.T) {
+	orig, err := os.ReadFile("../../oslist.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := OpenProfileFile(copyFile(t, orig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.Add(VMConfig{Name: "Alpine Linux", ID: "alpinelinux3.21", CPU: 1, RAM: 512, DiskSize: 4}); err != nil {
+		t.Fatal(err)
+	}
+	deb := profile(t, f, "Debian 13")
+	deb.RAM, deb.DiskSize, deb.Family = 4096, 30, ""
+	if err := f.Update("Debian 13", deb); err != nil {
+		t.Fatal(err)
+	}
+	win := profile(t, f, "Windows 11")
+	win.Firmware, win.TPM = "efi", "tpm-tis"
+	if err := f.Update("Windows 11", win); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Delete("Solus"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := string(orig)
+	want = replace(t, want, "    ram: 3072\n    family: common_linux\n", "    ram: 4096\n    disksize: 30\n")
+	want = replace(t, want, "  - name: Solus\n    id: solus\n    cpu: 2\n    ram: 4096\n    disksize: 20\n    family: common_linux\n\n", "")
+	want = replace(t, want, `    firmware: "efi-secure"`, `    firmware: "efi"`)
+	want = replace(t, want, `    tpm: "tpm-crb" # Windows 11 needs TPM 2.0 (swtpm)`, `    tpm: "tpm-tis" # Windows 11 needs TPM 2.0 (swtpm)`+"\n"+`
+  - name: Alpine Linux
+    id: alpinelinux3.21
+    cpu: 1
+    ram: 512
+    disksize: 4
+`)
+	saveAndCompare(t, f, orig, want)
+}
+
+const mergedProfiles = `# lab profiles
+linux: &linux
+  cpu: 2        # two cores
+  ram: 2048
+
+oslist:
+  - <<: *linux
+    name: Debian 13     # stable
+    id: debian13
+
+  # rolling release
+  - name: Arch Linux
+    <<: *linux
+    id: archlinux
+    ram: 4096   # AUR builds need more
+    nvirt: 'svm'
+
+  - name: Tiny
+    <<: *linux
+    id: alpinelinux3.21
+`
+
+func TestProfileFileMergesAndComments(t *testing.T) {
+	orig := []byte(mergedProfiles)
+	f, err := OpenProfileFile(copyFile(t, orig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deb := profile(t, f, "Debian 13")
+	if deb.CPU != 2 || deb.RAM != 2048 {
+		t.Fatalf("merged keys not applied: %+v", deb)
+	}
+	deb.RAM = 8192 // overrides the merged value
+	if err := f.Update("Debian 13", deb); err != nil {
+		t.Fatal(err)
+	}
+	arch := profile(t, f, "Arch Linux")
+	arch.RAM, arch.NestedVirt = 6144, "vmx"
+	if err := f.Update("Arch Linux", arch); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Delete("Tiny"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := mergedProfiles
+	want = replace(t, want, "    id: debian13\n", "    id: debian13\n    ram: 8192\n")
+	want = replace(t, want, "    ram: 4096   # AUR builds need more\n    nvirt: 'svm'\n", "    ram: 6144   # AUR builds need more\n    nvirt: 'vmx'\n")
+	want = replace(t, want, "\n  - name: Tiny\n    <<: *linux\n    id: alpinelinux3.21\n", "")
+	saveAndCompare(t, f, orig, want)
+
+	// read back: the anchor still feeds both entries
+	f, err = OpenProfileFile(f.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := profile(t, f, "Debian 13"); p.CPU != 2 || p.RAM != 8192 {
+		t.Errorf("Debian 13 after save: %+v", p)
+	}
+	if p := profile(t, f, "Arch Linux"); p.CPU != 2 || p.RAM != 6144 || p.NestedVirt != "vmx" {
+		t.Errorf("Arch Linux after save: %+v", p)
+	}
+}
+
+func TestProfileFileFlowStyleFallsBack(t *testing.T) {
+	f, err := OpenProfileFile(copyFile(t, []byte("oslist: [{name: Tiny, id: archlinux, ram: 512}]\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := profile(t, f, "Tiny")
+	p.RAM = 1024
+	if err := f.Update("Tiny", p); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Add(VMConfig{Name: "Arch Linux", ID: "archlinux"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Save(); err != nil {
+		t.Fatal(err)
+	}
+	f, err = OpenProfileFile(f.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := profile(t, f, "Tiny"); p.RAM != 1024 {
+		t.Errorf("Tiny after save: %+v", p)
+	}
+	profile(t, f, "Arch Linux")
+}
+
+func TestProfileFileNewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conf.d", "oslist.yaml")
+	f, err := OpenProfileFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Add(VMConfig{Name: "Arch Linux", ID: "archlinux", RAM: 2048}); err != nil {
+		t.Fatal(err)
+	}
+	backup, err := f.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backup != "" {
+		t.Errorf("backup %q of a new file", backup)
+	}
+	got, _ := os.ReadFile(path)
+	if want := "oslist:\n  - name: Arch Linux\n    id: archlinux\n    ram: 2048\n"; string(got) != want {
+		t.Errorf("new file:\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,275 @@
+// internal/config/profiletext.go
+// last modified: Oct 16 2026
+package config
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	// external
+	"gopkg.in/yaml.v3"
+)
+
+/*
+Text edits of a profile file. The parser reports line and column of every
+node, so an edit replaces, removes or inserts whole lines (or one value) at
+those positions and leaves every other byte of the file alone – blank
+lines, aligned comments and quoting included. Whatever cannot be placed
+that way (flow style, block scalars, a key on the "- " line) is reported
+with ok=false and the caller edits the node tree instead.
+*/
+
+// lines splits src after every newline, so joining them gives src back
+func lines(src []byte) []string {
+	return strings.SplitAfter(string(src), "\n")
+}
+
+func blank(line string) bool {
+	t := strings.TrimSpace(line)
+	return t == "" || strings.HasPrefix(t, "#")
+}
+
+// entryEnd is the last line (1-based) of entry i of seq: the line before
+// whatever follows it, without trailing blank and comment lines
+func (f *ProfileFile) entryEnd(ls []string, seq *yaml.Node, i int) int {
+	next := len(ls) + 1
+	if i+1 < len(seq.Content) {
+		next = seq.Content[i+1].Line
+	} else {
+		// the last entry ends where the next top-level key starts
+		root := f.doc.Content[0]
+		for k := 0; k+3 < len(root.Content); k += 2 {
+			if resolve(root.Content[k+1]) == seq {
+				next = root.Content[k+2].Line
+			}
+		}
+	}
+	end := next - 1
+	for end > seq.Content[i].Line && blank(ls[end-1]) {
+		end--
+	}
+	return end
+}
+
+// dashLine – the entry starts as "- key: …" on its own line
+func dashLine(ls []string, n *yaml.Node) bool {
+	return n.Style&yaml.FlowStyle == 0 && n.Line >= 1 && n.Line <= len(ls) &&
+		strings.HasPrefix(strings.TrimSpace(ls[n.Line-1]), "- ")
+}
+
+// byteOffset converts a 1-based column (in characters) to a byte offset
+func byteOffset(line string, col int) int {
+	off := 0
+	for range col - 1 {
+		if off >= len(line) {
+			break
+		}
+		_, size := utf8.DecodeRuneInString(line[off:])
+		off += size
+	}
+	return off
+}
+
+// scalarEnd finds the end of the scalar that starts at byte s of line
+func scalarEnd(line string, s int) (int, bool) {
+	switch line[s] {
+	case '"':
+		for i := s + 1; i < len(line); i++ {
+			switch line[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1, true
+			}
+		}
+		return 0, false
+	case '\'':
+		for i := s + 1; i < len(line); i++ {
+			if line[i] != '\'' {
+				continue
+			}
+			if i+1 < len(line) && line[i+1] == '\'' {
+				i++
+				continue
+			}
+			return i + 1, true
+		}
+		return 0, false
+	case '|', '>', '[', '{':
+		return 0, false // block scalar or flow collection
+	}
+	e := len(strings.TrimRight(line, "\r\n"))
+	if i := strings.Index(line[s:e], " #"); i >= 0 {
+		e = s + i
+	}
+	return s + len(strings.TrimRight(line[s:e], " \t")), true
+}
+
+// renderScalar is the text of a single-line scalar node
+func renderScalar(n *yaml.Node) (string, bool) {
+	out, err := yaml.Marshal(n)
+	if err != nil {
+		return "", false
+	}
+	s := strings.TrimSuffix(string(out), "\n")
+	return s, !strings.Contains(s, "\n")
+}
+
+// spliceKeys is setKeys on the text: entry spans the lines start…end
+func spliceKeys(ls []string, entry *yaml.Node, end int, old, p VMConfig) ([]string, bool) {
+	if entry.Style&yaml.FlowStyle != 0 || len(entry.Content) == 0 {
+		return nil, false
+	}
+	ls = append([]string(nil), ls...)
+	indent := strings.Repeat(" ", entry.Content[0].Column-1)
+	drop := map[int]bool{}
+	var added []string
+
+	ov, nv := reflect.ValueOf(old), reflect.ValueOf(p)
+	for i := 0; i < nv.NumField(); i++ {
+		key := yamlKey(nv.Type().Field(i))
+		if key == "" || nv.Field(i).Equal(ov.Field(i)) {
+			continue
+		}
+		j := keyIndex(entry, key)
+		if nv.Field(i).IsZero() {
+			if j < 0 {
+				continue
+			}
+			k, v := entry.Content[j], entry.Content[j+1]
+			if k.Line == entry.Line || v.Line != k.Line || v.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+				return nil, false // first key on the "- " line or a multi-line value
+			}
+			drop[k.Line] = true
+			continue
+		}
+		val := fieldNode(nv.Field(i))
+		if j < 0 {
+			text, ok := renderScalar(val)
+			if !ok {
+				return nil, false
+			}
+			added = append(added, indent+key+": "+text+"\n")
+			continue
+		}
+		v := entry.Content[j+1]
+		val.Style = v.Style & (yaml.DoubleQuotedStyle | yaml.SingleQuotedStyle) // keep the quoting of the file
+		text, ok := renderScalar(val)
+		if !ok || v.Line < 1 || v.Line > len(ls) {
+			return nil, false
+		}
+		line := ls[v.Line-1]
+		s := byteOffset(line, v.Column)
+		if s >= len(line) {
+			return nil, false
+		}
+		e, ok := scalarEnd(line, s)
+		if !ok {
+			return nil, false
+		}
+		ls[v.Line-1] = line[:s] + text + line[e:]
+	}
+
+	withNewline(ls, end)
+	out := make([]string, 0, len(ls)+len(added))
+	for n, line := range ls {
+		if !drop[n+1] {
+			out = append(out, line)
+		}
+		if n+1 == end {
+			out = append(out, added...)
+		}
+	}
+	return out, true
+}
+
+// withNewline makes sure the last line ends with a newline before more is appended
+func withNewline(ls []string, at int) {
+	if at > 0 && at <= len(ls) && !strings.HasSuffix(ls[at-1], "\n") {
+		ls[at-1] += "\n"
+	}
+}
+
+// removeLines drops the lines from…to (1-based) and one of two blank lines
+// that would meet where they were – or the blank lines left at the end
+func removeLines(ls []string, from, to int) []string {
+	out := append(append([]string(nil), ls[:from-1]...), ls[to:]...)
+	at := from - 1 // first line after the gap
+	if strings.TrimSpace(strings.Join(out[at:], "")) == "" {
+		for at > 0 && strings.TrimSpace(out[at-1]) == "" {
+			at--
+		}
+		return out[:at]
+	}
+	if at > 0 && strings.TrimSpace(out[at-1]) == "" && strings.TrimSpace(out[at]) == "" {
+		out = append(out[:at], out[at+1:]...)
+	}
+	return out
+}
+
+// insertLines puts add after line at (1-based)
+func insertLines(ls []string, at int, add []string) []string {
+	ls = append([]string(nil), ls...)
+	withNewline(ls, at)
+	out := append(append([]string(nil), ls[:at]...), add...)
+	return append(out, ls[at:]...)
+}
+
+// spliceAdd appends entry after the last entry of the oslist, indented and
+// separated like the entries before it
+func (f *ProfileFile) spliceAdd(entry *yaml.Node) ([]string, bool) {
+	seq := f.list(false)
+	if len(seq.Content) == 0 || seq.Style&yaml.FlowStyle != 0 {
+		return nil, false
+	}
+	ls := lines(f.src)
+	last := seq.Content[len(seq.Content)-1]
+	if !dashLine(ls, last) {
+		return nil, false
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{entry}}); err != nil {
+		return nil, false
+	}
+	if err := enc.Close(); err != nil {
+		return nil, false
+	}
+	first := ls[last.Line-1]
+	pad := first[:len(first)-len(strings.TrimLeft(first, " "))]
+	var add []string
+	if last.Line >= 2 && strings.TrimSpace(ls[last.Line-2]) == "" {
+		add = append(add, "\n")
+	}
+	for _, l := range lines(buf.Bytes()) {
+		if l != "" {
+			add = append(add, pad+l)
+		}
+	}
+	return insertLines(ls, f.entryEnd(ls, seq, len(seq.Content)-1), add), true
+}
+
+// spliceDelete removes entry i of the oslist with its lines
+func (f *ProfileFile) spliceDelete(i int) ([]string, bool) {
+	seq := f.list(false)
+	ls := lines(f.src)
+	item := seq.Content[i]
+	if seq.Style&yaml.FlowStyle != 0 || !dashLine(ls, item) {
+		return nil, false
+	}
+	return removeLines(ls, item.Line, f.entryEnd(ls, seq, i)), true
+}
+
+// reload takes the edited text as the new state of the file
+func (f *ProfileFile) reload(ls []string) bool {
+	src := []byte(strings.Join(ls, ""))
+	var doc yaml.Node
+	if err := yaml.Unmarshal(src, &doc); err != nil || len(doc.Content) == 0 {
+		return false
+	}
+	f.src, f.doc = src, doc
+	return true
+}
@@ -0,0 +1,550 @@
+// internal/config/validate.go
+// last modified: Oct 16 2026
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	// internal
+	"configurator/internal/netspec"
+	"configurator/internal/poolxml"
+
+	// external
+	"gopkg.in/yaml.v3"
+)
+
+// Issue is one problem found in a config file
+type Issue struct {
+	File    string
+	Line    int // 0 = unknown
+	Column  int // 0 = unknown
+	Msg     string
+	Warning bool // warnings are reported but do not stop loading
+}
+
+// String renders the issue compiler-style: file:line:col: message
+func (i Issue) String() string {
+	pos := i.File
+	if i.Line > 0 {
+		pos += ":" + strconv.Itoa(i.Line)
+		if i.Column > 0 {
+			pos += ":" + strconv.Itoa(i.Column)
+		}
+	}
+	if i.Warning {
+		return pos + ": warning: " + i.Msg
+	}
+	return pos + ": " + i.Msg
+}
+
+// ValidationError is returned by LoadAll and Merge when a file has errors
+type ValidationError struct {
+	Issues []Issue
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "config has %d problem(s):", len(e.Issues))
+	for _, i := range e.Issues {
+		b.WriteString("\n  " + i.String())
+	}
+	return b.String()
+}
+
+// HasErrors reports whether any issue is more than a warning
+func HasErrors(issues []Issue) bool {
+	for _, i := range issues {
+		if !i.Warning {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate reads path and checks it against the oslist.yaml schema
+func Validate(path string) ([]Issue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read config %q: %w", path, err)
+	}
+	return ValidateData(path, data), nil
+}
+
+// "yaml: line 12: did not find expected key"
+var yamlLineRe = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// ValidateData checks data (named file in the messages) and returns every
+// problem: syntax, unknown keys, wrong types, bad values and duplicates
+func ValidateData(file string, data []byte) []Issue {
+	v := &validator{file: file}
+	return v.run(data)
+}
+
+func (v *validator) run(data []byte) []Issue {
+	file := v.file
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		msg := err.Error()
+		line := 0
+		if m := yamlLineRe.FindStringSubmatch(msg); m != nil {
+			line, _ = strconv.Atoi(m[1])
+			msg = m[2]
+		}
+		return []Issue{{File: file, Line: line, Msg: "syntax error: " + msg}}
+	}
+	if len(doc.Content) == 0 {
+		if v.partial {
+			return nil // an empty drop-in is fine
+		}
+		return []Issue{{File: file, Msg: "file is empty"}}
+	}
+	v.root(doc.Content[0])
+	// merged blocks are checked out of order – report top to bottom
+	sort.SliceStable(v.issues, func(a, b int) bool {
+		if v.issues[a].Line != v.issues[b].Line {
+			return v.issues[a].Line < v.issues[b].Line
+		}
+		return v.issues[a].Column < v.issues[b].Column
+	})
+	return v.issues
+}
+
+// validateLayer checks one layer of a merged config: references to other
+// layers (family, id of an entry that is only extended) are checked later
+func validateLayer(file string, data []byte) []Issue {
+	v := &validator{file: file, partial: true}
+	return v.run(data)
+}
+
+// validator collects the issues of one file
+type validator struct {
+	file    string
+	partial bool // only one layer of several – skip cross-layer checks
+	issues  []Issue
+}
+
+func (v *validator) errorf(n *yaml.Node, format string, a ...any) {
+	v.issues = append(v.issues, Issue{File: v.file, Line: n.Line, Column: n.Column, Msg: fmt.Sprintf(format, a...)})
+}
+
+func (v *validator) warnf(n *yaml.Node, format string, a ...any) {
+	v.issues = append(v.issues, Issue{File: v.file, Line: n.Line, Column: n.Column, Msg: fmt.Sprintf(format, a...), Warning: true})
+}
+
+// keyCheck validates the value node of one key
+type keyCheck func(v *validator, key string, val *yaml.Node)
+
+var (
+	filepathKeys = map[string]keyCheck{
+		"isopath":   checkString,
+		"xmlpath":   checkString,
+		"input_dir": checkString, // old name of isopath
+	}
+	connectionKeys = map[string]keyCheck{
+		"uri":     checkString,
+		"backend": checkOneOf("shell", "native"),
+		"socket":  checkString,
+	}
+	advancedKeys = map[string]keyCheck{
+		"start_init":    checkBool,
+		"start_console": checkOneOf(ConsoleNone, ConsoleViewer, ConsoleSerial),
+	}
+	// profileKeys are the keys of an oslist entry (and of defaults / template blocks)
+	profileKeys = map[string]keyCheck{
+		"name":       checkString,
+		"id":         checkString,
+		"cpu":        checkPositiveInt,
+		"ram":        checkPositiveInt,
+		"disksize":   checkPositiveInt,
+		"diskpath":   checkDiskPath,
+		"isopath":    checkString,
+		"nvirt":      checkNestedVirt,
+		"network":    checkNetwork,
+		"graphics":   checkOneOf("spice", "vnc", "none"),
+		"sound":      checkOneOf("none", "ich9", "ich7", "ich6", "ac97"),
+		"filesystem": checkFilesystem,
+		"bootorder":  checkBootOrder,
+		"firmware":   checkOneOf("bios", "efi", "uefi", "efi-secure"),
+		"tpm":        checkOneOf("none", "tpm-crb", "tpm-tis"),
+		"family":     checkString, // checked against the family blocks in osList
+	}
+)
+
+// root checks the top-level mapping
+func (v *validator) root(n *yaml.Node) {
+	if n.Kind != yaml.MappingNode {
+		v.errorf(n, "top level must be a mapping (filepaths, defaults, oslist, …)")
+		return
+	}
+	// family blocks first, oslist entries refer to them
+	families := map[string]*yaml.Node{}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, val := n.Content[i], n.Content[i+1]
+		if !sections[k.Value] && k.Tag != "!!merge" && resolve(val).Kind == yaml.MappingNode {
+			families[k.Value] = k
+		}
+	}
+	used := map[string]bool{}
+	forEachKey(v, n, func(k, val *yaml.Node) {
+		switch k.Value {
+		case "filepaths":
+			v.mapping(k.Value, val, filepathKeys)
+		case "connection":
+			v.mapping(k.Value, val, connectionKeys)
+		case "advanced_features":
+			v.mapping(k.Value, val, advancedKeys)
+		case "defaults":
+			v.profile(k.Value, val, false)
+		case "oslist":
+			v.osList(val, families, used)
+		default:
+			// every other mapping is a family block (common_linux, windows_base, …)
+			if families[k.Value] != nil {
+				v.profile(k.Value, val, false)
+				return
+			}
+			v.errorf(k, "unknown key %q", k.Value)
+		}
+	})
+	for name, k := range families {
+		// anchored blocks may still be used via <<: *name
+		if !used[name] && !anchored(n, name) && !v.partial {
+			v.warnf(k, "family %q is not used by any oslist entry (typo in a section name?)", name)
+		}
+	}
+}
+
+// anchored reports whether the top-level block name carries a YAML anchor
+func anchored(root *yaml.Node, name string) bool {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == name {
+			return root.Content[i+1].Anchor != ""
+		}
+	}
+	return false
+}
+
+// mapping checks a section with a fixed set of keys
+func (v *validator) mapping(section string, n *yaml.Node, keys map[string]keyCheck) {
+	n = resolve(n)
+	if n.Kind == yaml.ScalarNode && n.ShortTag() == "!!null" {
+		return // empty section
+	}
+	if n.Kind != yaml.MappingNode {
+		v.errorf(n, "%s must be a mapping", section)
+		return
+	}
+	forEachKey(v, n, func(k, val *yaml.Node) {
+		check, ok := keys[k.Value]
+		if !ok {
+			v.errorf(k, "unknown key %q in %s", k.Value, section)
+			return
+		}
+		check(v, section+"."+k.Value, resolve(val))
+	})
+}
+
+// profile checks the keys of an OS entry; merged blocks are checked where
+// they are defined. It returns the effective name and id nodes (own or merged).
+func (v *validator) profile(section string, n *yaml.Node, entry bool) (name, id *yaml.Node) {
+	n = resolve(n)
+	if n.Kind != yaml.MappingNode {
+		v.errorf(n, "%s must be a mapping", section)
+		return nil, nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, val := n.Content[i], n.Content[i+1]
+		if k.Tag == "!!merge" {
+			for _, m := range mergeSources(val) {
+				mn, mid := v.lookupNameID(m)
+				name, id = firstOf(name, mn), firstOf(id, mid)
+			}
+		}
+	}
+	forEachKey(v, n, func(k, val *yaml.Node) {
+		check, ok := profileKeys[k.Value]
+		if !ok {
+			v.errorf(k, "unknown key %q in %s", k.Value, section)
+			return
+		}
+		if !entry && ownKeys[k.Value] {
+			v.errorf(k, "%q only makes sense in an oslist entry, not in %s", k.Value, section)
+			return
+		}
+		val = resolve(val)
+		check(v, section+"."+k.Value, val)
+		switch k.Value {
+		case "name":
+			name = val
+		case "id":
+			id = val
+		}
+	})
+	return name, id
+}
+
+// lookupNameID finds name/id inside a merged block without reporting anything
+func (v *validator) lookupNameID(n *yaml.Node) (name, id *yaml.Node) {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		switch n.Content[i].Value {
+		case "name":
+			name = resolve(n.Content[i+1])
+		case "id":
+			id = resolve(n.Content[i+1])
+		}
+	}
+	return name, id
+}
+
+// osList checks every entry, its family and reports duplicate names and ids
+func (v *validator) osList(n *yaml.Node, families map[string]*yaml.Node, used map[string]bool) {
+	n = resolve(n)
+	if n.Kind != yaml.SequenceNode {
+		v.errorf(n, "oslist must be a list of OS entries")
+		return
+	}
+	names := map[string]*yaml.Node{}
+	ids := map[string]*yaml.Node{}
+	for i, e := range n.Content {
+		section := fmt.Sprintf("oslist[%d]", i)
+		name, id := v.profile(section, e, true)
+		if fam := mappingValue(resolve(e), "family"); fam != nil && fam.Value != "" {
+			if families[fam.Value] == nil && !v.partial {
+				v.errorf(fam, "%s: unknown family %q", section, fam.Value)
+			}
+			used[fam.Value] = true
+		}
+		if name == nil || strings.TrimSpace(name.Value) == "" {
+			v.errorf(e, "%s: missing name", section)
+		} else {
+			key := strings.ToLower(strings.TrimSpace(name.Value))
+			if first, dup := names[key]; dup {
+				v.errorf(name, "duplicate name %q (first defined at line %d)", name.Value, first.Line)
+			} else {
+				names[key] = name
+			}
+			section = name.Value
+		}
+		if id == nil || strings.TrimSpace(id.Value) == "" {
+			if v.partial {
+				continue // may extend an entry of another layer
+			}
+			v.errorf(e, "%s: missing id (osinfo short id, see 'configurator osinfo')", section)
+			continue
+		}
+		// a shared id is legal (GuideOS is a debian13), but the profile
+		// can then only be selected by name
+		key := strings.ToLower(strings.TrimSpace(id.Value))
+		if first, dup := ids[key]; dup {
+			v.warnf(id, "id %q is also used at line %d – select %q by name", id.Value, first.Line, section)
+		} else {
+			ids[key] = id
+		}
+	}
+}
+
+// mappingValue returns the value node of key in a mapping (nil if missing)
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	if n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return resolve(n.Content[i+1])
+		}
+	}
+	return nil
+}
+
+// forEachKey calls fn for every key of a mapping (merge keys excluded) and
+// reports keys that appear twice
+func forEachKey(v *validator, n *yaml.Node, fn func(k, val *yaml.Node)) {
+	seen := map[string]*yaml.Node{}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, val := n.Content[i], n.Content[i+1]
+		if k.Tag == "!!merge" {
+			continue
+		}
+		if first, dup := seen[k.Value]; dup {
+			v.errorf(k, "key %q defined twice (first at line %d)", k.Value, first.Line)
+			continue
+		}
+		seen[k.Value] = k
+		fn(k, val)
+	}
+}
+
+// mergeSources returns the mappings behind "<<: *a" or "<<: [*a, *b]"
+func mergeSources(n *yaml.Node) []*yaml.Node {
+	n = resolve(n)
+	switch n.Kind {
+	case yaml.MappingNode:
+		return []*yaml.Node{n}
+	case yaml.SequenceNode:
+		var out []*yaml.Node
+		for _, c := range n.Content {
+			if c = resolve(c); c.Kind == yaml.MappingNode {
+				out = append(out, c)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// resolve follows aliases (*default_vals) to the anchored node
+func resolve(n *yaml.Node) *yaml.Node {
+	for n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	return n
+}
+
+// firstOf returns the first non-nil node
+func firstOf(a, b *yaml.Node) *yaml.Node {
+	if a != nil {
+		return a
+	}
+	return b
+}
+
+// VALUE CHECKS
+
+func checkString(v *validator, key string, n *yaml.Node) {
+	if n.Kind != yaml.ScalarNode {
+		v.errorf(n, "%s must be a string", key)
+	}
+}
+
+func checkBool(v *validator, key string, n *yaml.Node) {
+	if n.Kind != yaml.ScalarNode || n.ShortTag() != "!!bool" {
+		v.errorf(n, "%s must be true or false (got %q)", key, n.Value)
+	}
+}
+
+func checkPositiveInt(v *validator, key string, n *yaml.Node) {
+	if n.Kind != yaml.ScalarNode || n.ShortTag() != "!!int" {
+		v.errorf(n, "%s must be a whole number (got %q)", key, n.Value)
+		return
+	}
+	if i, err := strconv.Atoi(n.Value); err != nil || i <= 0 {
+		v.errorf(n, "%s must be > 0 (got %s)", key, n.Value)
+	}
+}
+
+// checkOneOf accepts the given values (case-insensitive) and the empty string
+func checkOneOf(allowed ...string) keyCheck {
+	return func(v *validator, key string, n *yaml.Node) {
+		if n.Kind != yaml.ScalarNode {
+			v.errorf(n, "%s must be one of %s", key, strings.Join(allowed, ", "))
+			return
+		}
+		val := strings.ToLower(strings.TrimSpace(n.Value))
+		if val == "" {
+			return
+		}
+		for _, a := range allowed {
+			if val == a {
+				return
+			}
+		}
+		v.errorf(n, "%s: unknown value %q (%s)", key, n.Value, strings.Join(allowed, ", "))
+	}
+}
+
+func checkNestedVirt(v *validator, key string, n *yaml.Node) {
+	if n.Kind != yaml.ScalarNode {
+		v.errorf(n, "%s must be vmx or svm", key)
+		return
+	}
+	switch strings.ToLower(strings.TrimSpace(n.Value)) {
+	case "", "vmx", "svm":
+	case "smx":
+		v.warnf(n, "%s: smx is Intel Safer Mode – AMD virtualisation is svm", key)
+	default:
+		v.errorf(n, "%s: unknown value %q (vmx for Intel, svm for AMD)", key, n.Value)
+	}
+}
+
+// checkNetwork mirrors what the domain builder understands
+func checkNetwork(v *validator, key string, n *yaml.Node) {
+	if n.Kind != yaml.ScalarNode {
+		v.errorf(n, "%s must be a string", key)
+		return
+	}
+	if _, err := netspec.Parse(n.Value); err != nil {
+		v.errorf(n, "%s: %v", key, err)
+	}
+}
+
+// checkDiskPath – a directory, an image file, pool:<name> or attach:<path>
+func checkDiskPath(v *validator, key string, n *yaml.Node) {
+	if n.Kind != yaml.ScalarNode {
+		v.errorf(n, "%s must be a string", key)
+		return
+	}
+	if pool, ok := strings.CutPrefix(strings.TrimSpace(n.Value), "pool:"); ok {
+		if err := poolxml.CheckName(strings.TrimSpace(pool)); err != nil {
+			v.errorf(n, "%s: storage pool %v", key, err)
+		}
+	}
+	if path, ok := strings.CutPrefix(strings.TrimSpace(n.Value), "attach:"); ok && !filepath.IsAbs(os.ExpandEnv(strings.TrimSpace(path))) {
+		v.errorf(n, "%s: attached disk %q must be an absolute path", key, strings.TrimSpace(path))
+	}
+}
+
+func checkFilesystem(v *validator, key string, n *yaml.Node) {
+	if n.Kind != yaml.ScalarNode {
+		v.errorf(n, "%s must be a string", key)
+		return
+	}
+	spec := strings.TrimSpace(n.Value)
+	if spec == "" || strings.EqualFold(spec, "none") {
+		return
+	}
+	src, target, ok := strings.Cut(spec, ",")
+	if !ok || strings.TrimSpace(src) == "" || strings.TrimSpace(target) == "" {
+		v.errorf(n, "%s: expected /host/dir,/guest/tag or none (got %q)", key, n.Value)
+	}
+}
+
+var bootDevices = map[string]bool{"hd": true, "cdrom": true, "network": true, "fd": true}
+
+func checkBootOrder(v *validator, key string, n *yaml.Node) {
+	if n.Kind != yaml.ScalarNode {
+		v.errorf(n, "%s must be a string such as cdrom,hd", key)
+		return
+	}
+	for _, dev := range strings.Split(n.Value, ",") {
+		dev = strings.ToLower(strings.TrimSpace(dev))
+		if dev != "" && !bootDevices[dev] {
+			v.errorf(n, "%s: unknown boot device %q (hd, cdrom, network, fd)", key, dev)
+		}
+	}
+}
+
+// CheckAdvanced checks a single advanced_features value (e.g. from a flag)
+func CheckAdvanced(key, value string) error { return checkOne(advancedKeys, key, value) }
+
+// CheckValue checks a single profile value as typed by a user
+func CheckValue(key, value string) error { return checkOne(profileKeys, key, value) }
+
+func checkOne(keys map[string]keyCheck, key, value string) error {
+	check, ok := keys[key]
+	if !ok {
+		return fmt.Errorf("unknown key %q", key)
+	}
+	v := &validator{partial: true}
+	check(v, key, &yaml.Node{Kind: yaml.ScalarNode, Value: value})
+	if len(v.issues) > 0 && !v.issues[0].Warning {
+		return errors.New(v.issues[0].Msg)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// internal/config/validate_test.go
+// last modified: Oct 16 2026
+package config
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"testing"
+)
+
+// issueLines renders issues as the user sees them
+func issueLines(issues []Issue) []string {
+	out := make([]string, len(issues))
+	for i, is := range issues {
+		out[i] = is.String()
+	}
+	return out
+}
+
+// the errors of testdata/validate/oslist.yaml, top to bottom
+var oslistIssues = []string{
+	`testdata/validate/oslist.yaml:4:3: unknown key "xmlpaht" in filepaths`,
+	`testdata/validate/oslist.yaml:7:12: connection.backend: unknown value "libvirt" (shell, native)`,
+	`testdata/validate/oslist.yaml:10:8: defaults.cpu must be a whole number (got "two")`,
+	`testdata/validate/oslist.yaml:19:10: oslist[0].tpm: unknown value "tpm-2.0" (none, tpm-crb, tpm-tis)`,
+	`testdata/validate/oslist.yaml:23:15: oslist[1].disksize must be a whole number (got "64GB")`,
+	`testdata/validate/oslist.yaml:24:12: oslist[1].sound: unknown value "hda" (none, ich9, ich7, ich6, ac97)`,
+	`testdata/validate/oslist.yaml:25:5: unknown key "colour" in oslist[1]`,
+}
+
+func TestValidatePositions(t *testing.T) {
+	issues, err := Validate("testdata/validate/oslist.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := issueLines(issues); !slices.Equal(got, oslistIssues) {
+		t.Errorf("issues:\n%q\nwant\n%q", got, oslistIssues)
+	}
+	if !HasErrors(issues) {
+		t.Error("HasErrors = false")
+	}
+}
+
+func TestValidateDropInPositions(t *testing.T) {
+	_, err := Merge([]Layer{
+		{LayerUser, "testdata/validate/oslist.yaml"},
+		{LayerDropIn, "testdata/validate/oslist.d/50-lab.yaml"},
+	}, nil)
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Merge: %v", err)
+	}
+	// every layer is checked on its own, positions refer to its file
+	want := append(slices.Clone(oslistIssues),
+		`testdata/validate/oslist.d/50-lab.yaml:4:10: oslist[0].ram must be > 0 (got -512)`,
+		`testdata/validate/oslist.d/50-lab.yaml:5:12: warning: oslist[0].nvirt: smx is Intel Safer Mode – AMD virtualisation is svm`,
+		`testdata/validate/oslist.d/50-lab.yaml:8:16: oslist[1].bootorder: unknown boot device "usb" (hd, cdrom, network, fd)`,
+		`testdata/validate/oslist.d/50-lab.yaml:9:5: unknown key "start_init" in oslist[1]`,
+	)
+	if got := issueLines(verr.Issues); !slices.Equal(got, want) {
+		t.Errorf("issues:\n%q\nwant\n%q", got, want)
+	}
+
+	// the drop-in alone: "Debian 13" and its family live in another layer
+	data, err := os.ReadFile("testdata/validate/oslist.d/50-lab.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues := validateLayer("50-lab.yaml", data)
+	if got := issueLines(issues); len(got) != 4 || got[0] != `50-lab.yaml:4:10: oslist[0].ram must be > 0 (got -512)` {
+		t.Errorf("drop-in issues: %q", got)
+	}
+}
+
+func TestValidateSyntaxError(t *testing.T) {
+	issues := ValidateData("broken.yaml", []byte("oslist:\n  - name: Debian\n    id: \"debian13\n  - name: Fedora\n"))
+	if got := issueLines(issues); len(got) != 1 || got[0] != "broken.yaml:3: syntax error: found unexpected end of stream" {
+		t.Errorf("issues: %q", got)
+	}
+}
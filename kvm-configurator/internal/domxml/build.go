@@ -0,0 +1,382 @@
+// domxml/build.go
+// last modified: Oct 16 2026
+package domxml
+
+import (
+	"fmt"
+	"strings"
+
+	// internal
+	"configurator/internal/model"
+	"configurator/internal/netspec"
+)
+
+// Build turns a DomainConfig into a libvirt domain definition.
+// The result is the same for the same input (no random UUIDs or MACs –
+// libvirt assigns those on define).
+func Build(cfg model.DomainConfig, variant string) (*Domain, error) {
+	if strings.TrimSpace(cfg.Name) == "" {
+		return nil, fmt.Errorf("domain has no name")
+	}
+	if cfg.MemMiB <= 0 {
+		return nil, fmt.Errorf("memory must be > 0 MiB (got %d)", cfg.MemMiB)
+	}
+	if cfg.VCPU <= 0 {
+		return nil, fmt.Errorf("vCPUs must be > 0 (got %d)", cfg.VCPU)
+	}
+
+	mem := Memory{Unit: "KiB", Value: uint64(cfg.MemMiB) * 1024}
+	dom := &Domain{
+		Type:          "kvm",
+		Name:          cfg.Name,
+		Memory:        mem,
+		CurrentMemory: &mem,
+		VCPU:          VCPU{Placement: "static", Value: cfg.VCPU},
+		OS: OS{
+			Type: OSType{Arch: "x86_64", Machine: "q35", Value: "hvm"},
+		},
+		Features: &Features{ACPI: &Empty{}, APIC: &Empty{}, VMPort: &State{State: "off"}},
+		CPU:      &CPU{Mode: "host-passthrough", Check: "none", Migratable: "on"},
+		Clock: &Clock{Offset: "utc", Timers: []Timer{
+			{Name: "rtc", TickPolicy: "catchup"},
+			{Name: "pit", TickPolicy: "delay"},
+			{Name: "hpet", Present: "no"},
+		}},
+		OnPoweroff: "destroy",
+		OnReboot:   "restart",
+		OnCrash:    "destroy",
+		PM:         &PM{SuspendToMem: &PMState{Enabled: "no"}, SuspendToDisk: &PMState{Enabled: "no"}},
+	}
+	if variant != "" {
+		dom.meta().Variant = variant
+	}
+
+	// nested virtualisation: vmx (Intel) / svm (AMD)
+	if nv := strings.TrimSpace(cfg.NestedVirt); nv != "" {
+		dom.CPU.Features = append(dom.CPU.Features, CPUFeature{Policy: "require", Name: nv})
+	}
+
+	if err := buildFirmware(dom, cfg.Firmware); err != nil {
+		return nil, err
+	}
+
+	// a cloud image or an imported disk boots as is: no install ISO
+	if cfg.Imported() || cfg.CloudInit != nil {
+		cfg.ISOPath = ""
+	}
+	if err := buildBoot(dom, cfg); err != nil {
+		return nil, err
+	}
+	if err := buildDisks(dom, cfg); err != nil {
+		return nil, err
+	}
+	if err := buildNetwork(dom, cfg.Network); err != nil {
+		return nil, err
+	}
+	if err := buildFilesystem(dom, cfg.FileSystem); err != nil {
+		return nil, err
+	}
+	if err := buildGraphics(dom, cfg.Graphics); err != nil {
+		return nil, err
+	}
+	if err := buildSound(dom, cfg.Sound); err != nil {
+		return nil, err
+	}
+	if err := buildTPM(dom, cfg.TPM); err != nil {
+		return nil, err
+	}
+
+	// always there: USB controller, serial console, tablet, balloon and RNG
+	d := &dom.Devices
+	d.Controllers = append(d.Controllers, Controller{Type: "usb", Model: "qemu-xhci", Ports: 15})
+	port := 0
+	d.Serials = append(d.Serials, Char{Type: "pty", Target: &CharTarget{Port: &port}})
+	d.Consoles = append(d.Consoles, Char{Type: "pty", Target: &CharTarget{Type: "serial", Port: &port}})
+	d.Inputs = append(d.Inputs, Input{Type: "tablet", Bus: "usb"})
+	d.MemBalloon = &MemBalloon{Model: "virtio"}
+	d.RNGs = append(d.RNGs, RNG{Model: "virtio", Backend: RNGBackend{Model: "random", Value: "/dev/urandom"}})
+	return dom, nil
+}
+
+/*
+buildFirmware asks libvirt's auto-selection for BIOS, UEFI or UEFI with
+Secure Boot (enrolled keys, SMM). The engine may pin the OVMF images of a
+firmware descriptor afterwards (UseLoader).
+*/
+func buildFirmware(dom *Domain, firmware string) error {
+	fw := strings.ToLower(strings.TrimSpace(firmware))
+	switch fw {
+	case "", "bios":
+		return nil // libvirt default (SeaBIOS)
+	case "efi", "uefi", "efi-secure":
+	default:
+		return fmt.Errorf("unknown firmware %q (bios, efi or efi-secure)", firmware)
+	}
+	secure := "no"
+	if fw == "efi-secure" {
+		secure = "yes"
+		dom.OS.Loader = &Loader{Secure: "yes"}
+		dom.Features.SMM = &State{State: "on"}
+	}
+	dom.OS.Firmware = "efi"
+	dom.OS.FWInfo = &FWInfo{Features: []FWFeature{
+		{Enabled: secure, Name: "secure-boot"},
+		{Enabled: secure, Name: "enrolled-keys"},
+	}}
+	return nil
+}
+
+var bootDevices = map[string]bool{"hd": true, "cdrom": true, "network": true, "fd": true}
+
+// buildBoot – "cdrom,hd" → <boot dev="cdrom"/><boot dev="hd"/>;
+// without an explicit order the ISO (if any) boots first. Cloud images
+// and imported disks never boot from a CD-ROM, not even the seed.
+func buildBoot(dom *Domain, cfg model.DomainConfig) error {
+	noCDROM := cfg.Imported() || cfg.CloudInit != nil
+	order := strings.TrimSpace(cfg.BootOrder)
+	if order == "" {
+		order = "hd"
+		if cfg.ISOPath != "" {
+			order = "cdrom,hd"
+		}
+	}
+	for _, dev := range strings.Split(order, ",") {
+		dev = strings.ToLower(strings.TrimSpace(dev))
+		if dev == "" {
+			continue
+		}
+		if !bootDevices[dev] {
+			return fmt.Errorf("unknown boot device %q (hd, cdrom, network, fd)", dev)
+		}
+		if dev == "cdrom" && noCDROM {
+			continue
+		}
+		dom.OS.Boot = append(dom.OS.Boot, Boot{Dev: dev})
+	}
+	return nil
+}
+
+// target device prefix per bus
+var busPrefix = map[string]string{
+	"virtio": "vd",
+	"sata":   "sd",
+	"scsi":   "sd",
+	"usb":    "sd",
+	"ide":    "hd",
+}
+
+// targetNames hands out vda, vdb, … per prefix
+type targetNames map[string]int
+
+func (t targetNames) next(prefix string) string {
+	i := t[prefix]
+	t[prefix]++
+	return prefix + diskLetters(i)
+}
+
+// diskLetters – 0 → a, 25 → z, 26 → aa (same scheme as libvirt)
+func diskLetters(i int) string {
+	s := ""
+	for {
+		s = string(rune('a'+i%26)) + s
+		i = i/26 - 1
+		if i < 0 {
+			return s
+		}
+	}
+}
+
+func buildDisks(dom *Domain, cfg model.DomainConfig) error {
+	names := targetNames{}
+	for _, disk := range cfg.Disks {
+		bus := strings.ToLower(strings.TrimSpace(disk.Bus))
+		if bus == "" {
+			bus = "virtio"
+		}
+		prefix, ok := busPrefix[bus]
+		if !ok {
+			return fmt.Errorf("disk %s: unknown bus %q (virtio, sata, scsi, usb, ide)", disk.Name, disk.Bus)
+		}
+		d, err := diskDevice(disk, cfg.Name)
+		if err != nil {
+			return err
+		}
+		d.Target = DiskTarget{Dev: names.next(prefix), Bus: bus}
+		dom.Devices.Disks = append(dom.Devices.Disks, d)
+		if disk.Existing {
+			dom.meta().Attached = append(dom.meta().Attached, d.Path())
+		}
+		if bus == "scsi" && !hasController(dom, "scsi") {
+			dom.Devices.Controllers = append(dom.Devices.Controllers, Controller{Type: "scsi", Model: "virtio-scsi"})
+		}
+	}
+	// installation medium and cloud-init seed as SATA CD-ROMs
+	for _, iso := range []string{cfg.ISOPath, cfg.SeedPath()} {
+		if iso != "" {
+			dom.Devices.Disks = append(dom.Devices.Disks, cdrom(iso, names.next("sd")))
+		}
+	}
+	return nil
+}
+
+// meta – the configurator's metadata block, created on first use
+func (dom *Domain) meta() *ConfiguratorMeta {
+	if dom.Metadata == nil {
+		dom.Metadata = &Metadata{}
+	}
+	if dom.Metadata.Configurator == nil {
+		dom.Metadata.Configurator = &ConfiguratorMeta{}
+	}
+	return dom.Metadata.Configurator
+}
+
+func cdrom(path, dev string) Disk {
+	return Disk{
+		Type:     "file",
+		Device:   "cdrom",
+		Driver:   &DiskDriver{Name: "qemu", Type: "raw"},
+		Source:   &DiskSource{File: path},
+		Target:   DiskTarget{Dev: dev, Bus: "sata"},
+		ReadOnly: &Empty{},
+	}
+}
+
+// diskDevice – a new disk is a qcow2 file; an existing one keeps its format
+// and is attached as block device if it is one (no host page cache then)
+func diskDevice(disk model.DiskSpec, vmName string) (Disk, error) {
+	path := model.DiskImagePath(disk, vmName)
+	if !disk.Existing {
+		return Disk{
+			Type:   "file",
+			Device: "disk",
+			Driver: &DiskDriver{Name: "qemu", Type: "qcow2", Discard: "unmap"},
+			Source: &DiskSource{File: path},
+		}, nil
+	}
+	if disk.Format == "" {
+		return Disk{}, fmt.Errorf("disk %s: format of %s unknown", disk.Name, path)
+	}
+	if model.IsBlockDevice(path) {
+		return Disk{
+			Type:   "block",
+			Device: "disk",
+			Driver: &DiskDriver{Name: "qemu", Type: disk.Format, Cache: "none", IO: "native", Discard: "unmap"},
+			Source: &DiskSource{Dev: path},
+		}, nil
+	}
+	return Disk{
+		Type:   "file",
+		Device: "disk",
+		Driver: &DiskDriver{Name: "qemu", Type: disk.Format, Discard: "unmap"},
+		Source: &DiskSource{File: path},
+	}, nil
+}
+
+func hasController(dom *Domain, typ string) bool {
+	for _, c := range dom.Devices.Controllers {
+		if c.Type == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// buildNetwork adds one interface per NIC of the network setting
+// (syntax: netspec.Parse); MACs left empty are assigned by libvirt
+func buildNetwork(dom *Domain, spec string) error {
+	nics, err := netspec.Parse(spec)
+	if err != nil {
+		return fmt.Errorf("network: %w", err)
+	}
+	for _, n := range nics {
+		iface := Interface{Type: n.Type, Source: &IfaceSource{}, Model: &Model{Type: n.Model}}
+		if iface.Model.Type == "" {
+			iface.Model.Type = netspec.DefaultModel
+		}
+		switch n.Type {
+		case netspec.TypeBridge:
+			iface.Source.Bridge = n.Source
+		case netspec.TypeDirect:
+			iface.Source.Dev = n.Source
+			iface.Source.Mode = n.Mode
+			if iface.Source.Mode == "" {
+				iface.Source.Mode = "bridge"
+			}
+		default:
+			iface.Source.Network = n.Source
+		}
+		if n.MAC != "" {
+			iface.MAC = &MAC{Address: n.MAC}
+		}
+		dom.Devices.Interfaces = append(dom.Devices.Interfaces, iface)
+	}
+	return nil
+}
+
+// buildFilesystem – "/host/dir,/guest/tag" as in virt-install --filesystem
+func buildFilesystem(dom *Domain, spec string) error {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || strings.EqualFold(spec, "none") {
+		return nil
+	}
+	src, target, ok := strings.Cut(spec, ",")
+	if !ok || strings.TrimSpace(src) == "" || strings.TrimSpace(target) == "" {
+		return fmt.Errorf("filesystem %q: expected /host/dir,/guest/tag", spec)
+	}
+	dom.Devices.Filesystems = append(dom.Devices.Filesystems, Filesystem{
+		Type:       "mount",
+		AccessMode: "passthrough",
+		Source:     FSSource{Dir: strings.TrimSpace(src)},
+		Target:     FSTarget{Dir: strings.TrimSpace(target)},
+	})
+	return nil
+}
+
+func buildGraphics(dom *Domain, kind string) error {
+	d := &dom.Devices
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "", "spice":
+		d.Graphics = append(d.Graphics, Graphics{Type: "spice", AutoPort: "yes", Listen: &Listen{Type: "address"}})
+		d.Channels = append(d.Channels, Channel{Type: "spicevmc", Target: ChannelTarget{Type: "virtio", Name: "com.redhat.spice.0"}})
+		d.Videos = append(d.Videos, Video{Model: VideoModel{Type: "virtio"}})
+	case "vnc":
+		d.Graphics = append(d.Graphics, Graphics{Type: "vnc", AutoPort: "yes", Listen: &Listen{Type: "address"}})
+		d.Videos = append(d.Videos, Video{Model: VideoModel{Type: "virtio"}})
+	case "none":
+		// headless – serial console only
+	default:
+		return fmt.Errorf("unknown graphics %q (spice, vnc, none)", kind)
+	}
+	return nil
+}
+
+// buildTPM – an emulated TPM 2.0 (swtpm keeps its state per VM)
+func buildTPM(dom *Domain, model string) error {
+	model = strings.ToLower(strings.TrimSpace(model))
+	switch model {
+	case "", "none":
+		return nil
+	case "tpm-crb", "tpm-tis":
+	default:
+		return fmt.Errorf("unknown TPM model %q (tpm-crb, tpm-tis, none)", model)
+	}
+	dom.Devices.TPMs = append(dom.Devices.TPMs, TPM{
+		Model:   model,
+		Backend: &TPMBackend{Type: "emulator", Version: "2.0"},
+	})
+	return nil
+}
+
+var soundModels = map[string]bool{"ich9": true, "ich6": true, "ac97": true, "ich7": true}
+
+func buildSound(dom *Domain, model string) error {
+	model = strings.ToLower(strings.TrimSpace(model))
+	if model == "" || model == "none" {
+		return nil
+	}
+	if !soundModels[model] {
+		return fmt.Errorf("unknown sound model %q (ich9, ich6, ac97, none)", model)
+	}
+	dom.Devices.Sounds = append(dom.Devices.Sounds, Sound{Model: model})
+	return nil
+}
@@ -0,0 +1,185 @@
+// domxml/build_test.go
+// last modified: Oct 16 2026
+package domxml
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	// internal
+	"configurator/internal/cloudinit"
+	"configurator/internal/model"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// disk is a DiskSpec at location (directory, file or attach:<path>)
+func disk(name, location, bus string, sizeGiB int) model.DiskSpec {
+	d := model.DiskSpec{Name: name, SizeGiB: sizeGiB, Bus: bus}
+	d.SetLocation(location)
+	return d
+}
+
+// base is a small Linux VM; every golden case changes what it is about
+func base() model.DomainConfig {
+	return model.DomainConfig{
+		Name:     "web-01",
+		MemMiB:   2048,
+		VCPU:     2,
+		Disks:    []model.DiskSpec{disk("system", "/var/lib/libvirt/images", "virtio", 20)},
+		ISOPath:  "/srv/iso/debian-13.iso",
+		Network:  "default",
+		Graphics: "spice",
+	}
+}
+
+var goldenCases = []struct {
+	name    string
+	variant string
+	change  func(*model.DomainConfig)
+}{
+	{"bios", "debian13", func(c *model.DomainConfig) {
+		c.Sound = "ich9"
+		c.NestedVirt = "vmx"
+	}},
+	{"efi", "archlinux", func(c *model.DomainConfig) {
+		c.Firmware = "efi"
+		c.Graphics = "vnc"
+		c.BootOrder = "hd,cdrom,network"
+		c.FileSystem = "/srv/share,share"
+	}},
+	{"efi-secure", "win11", func(c *model.DomainConfig) {
+		c.Name = "win11"
+		c.MemMiB, c.VCPU = 8192, 4
+		c.ISOPath = "/srv/iso/Win11_24H2_English_x64.iso"
+		c.Firmware = "efi-secure"
+		c.TPM = "tpm-crb"
+		c.Disks[0].Bus = "sata"
+		c.Network = "default,model=e1000e"
+		c.Sound = "ich9"
+	}},
+	{"disks", "", func(c *model.DomainConfig) {
+		c.ISOPath = ""
+		c.Graphics = "none"
+		data := disk("data", model.AttachPrefix+"/srv/images/data.raw", "scsi", 0)
+		data.Format = "raw"
+		c.Disks = append(c.Disks,
+			disk("logs", "/srv/vms/web-01-logs.qcow2", "virtio", 5),
+			data,
+			disk("scratch", "/srv/vms", "scsi", 50),
+			disk("stick", "/srv/vms", "usb", 1),
+		)
+	}},
+	{"cloud-seed", "debian13", func(c *model.DomainConfig) {
+		c.Disks[0].Backing = "/srv/images/debian-13-genericcloud-amd64.qcow2"
+		c.CloudInit = &cloudinit.Config{Hostname: "web-01", User: "alice"}
+	}},
+	{"networks", "debian13", func(c *model.DomainConfig) {
+		c.ISOPath = ""
+		c.Network = "default; network=lab,mac=52:54:00:12:34:56; bridge=br1,model=e1000e; direct=enp3s0,mode=vepa; macvtap=enp4s0"
+	}},
+	{"no-network", "debian13", func(c *model.DomainConfig) {
+		c.ISOPath = ""
+		c.Network = "none"
+		c.Graphics = "none"
+	}},
+}
+
+func TestBuildGolden(t *testing.T) {
+	for _, tc := range goldenCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := base()
+			tc.change(&cfg)
+			dom, err := Build(cfg, tc.variant)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := dom.Marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join("testdata", tc.name+".xml")
+			if *update {
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			} else if want, err := os.ReadFile(path); err != nil {
+				t.Fatal(err)
+			} else if !bytes.Equal(got, want) {
+				t.Errorf("%s differs:\n--- got\n%s--- want\n%s", path, got, want)
+			}
+
+			// what was built survives parse → marshal unchanged
+			back, err := Parse(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			again, err := back.Marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(again, got) {
+				t.Errorf("parse → marshal changed the XML:\n%s", again)
+			}
+		})
+	}
+}
+
+func TestBuildRecordsAttachedDisks(t *testing.T) {
+	cfg := base()
+	cfg.Disks = append(cfg.Disks, disk("data", model.AttachPrefix+"/srv/images/data.raw", "scsi", 0))
+	cfg.Disks[1].Format = "raw"
+	dom, err := Build(cfg, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := dom.AttachedDisks(); len(got) != 1 || got[0] != "/srv/images/data.raw" {
+		t.Errorf("AttachedDisks = %v", got)
+	}
+	if dom.Variant() != "" {
+		t.Errorf("Variant = %q without a variant", dom.Variant())
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(*model.DomainConfig)
+		wantErr string
+	}{
+		{"name", func(c *model.DomainConfig) { c.Name = " " }, "no name"},
+		{"memory", func(c *model.DomainConfig) { c.MemMiB = 0 }, "memory"},
+		{"vcpus", func(c *model.DomainConfig) { c.VCPU = -1 }, "vCPUs"},
+		{"firmware", func(c *model.DomainConfig) { c.Firmware = "coreboot" }, "unknown firmware"},
+		{"boot", func(c *model.DomainConfig) { c.BootOrder = "hd,usb" }, "unknown boot device"},
+		{"bus", func(c *model.DomainConfig) { c.Disks[0].Bus = "nvme" }, "unknown bus"},
+		{"attached format", func(c *model.DomainConfig) {
+			c.Disks = append(c.Disks, disk("data", model.AttachPrefix+"/srv/images/data.img", "virtio", 0))
+		}, "format of /srv/images/data.img unknown"},
+		{"network", func(c *model.DomainConfig) { c.Network = "default,mode=vepa" }, "network:"},
+		{"filesystem", func(c *model.DomainConfig) { c.FileSystem = "/srv/share" }, "expected /host/dir,/guest/tag"},
+		{"graphics", func(c *model.DomainConfig) { c.Graphics = "rdp" }, "unknown graphics"},
+		{"sound", func(c *model.DomainConfig) { c.Sound = "sb16" }, "unknown sound model"},
+		{"tpm", func(c *model.DomainConfig) { c.TPM = "tpm-1.2" }, "unknown TPM model"},
+	}
+	for _, tt := range tests {
+		cfg := base()
+		tt.change(&cfg)
+		if _, err := Build(cfg, ""); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDiskLetters(t *testing.T) {
+	for i, want := range map[int]string{0: "a", 25: "z", 26: "aa", 27: "ab", 701: "zz", 702: "aaa"} {
+		if got := diskLetters(i); got != want {
+			t.Errorf("diskLetters(%d) = %s, want %s", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,543 @@
+// domxml/domain.go
+// last modified: Oct 16 2026
+package domxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+)
+
+// Namespace of the configurator's own <metadata> block
+const Namespace = "https://github.com/mrtoadie/kvm-configurator/xmlns/1.0"
+
+/*
+Domain is a libvirt domain definition (https://libvirt.org/formatdomain.html).
+The field order is the order libvirt itself uses when it dumps a domain.
+
+Every element keeps what it does not model: unknown attributes end up in
+Attrs, unknown child elements in Extra. Parse → edit → Marshal therefore
+loses nothing, and Marshal writes everything back in the order it was read.
+*/
+type Domain struct {
+	XMLName       xml.Name       `xml:"domain"`
+	Type          string         `xml:"type,attr"`
+	ID            string         `xml:"id,attr,omitempty"` // only while running
+	Attrs         []xml.Attr     `xml:",any,attr"`
+	Name          string         `xml:"name"`
+	UUID          string         `xml:"uuid,omitempty"`
+	Title         string         `xml:"title,omitempty"`
+	Description   string         `xml:"description,omitempty"`
+	Metadata      *Metadata      `xml:"metadata"`
+	MaxMemory     *MaxMemory     `xml:"maxMemory"`
+	Memory        Memory         `xml:"memory"`
+	CurrentMemory *Memory        `xml:"currentMemory"`
+	MemoryBacking *MemoryBacking `xml:"memoryBacking"`
+	VCPU          VCPU           `xml:"vcpu"`
+	OS            OS             `xml:"os"`
+	Features      *Features      `xml:"features"`
+	CPU           *CPU           `xml:"cpu"`
+	Clock         *Clock         `xml:"clock"`
+	OnPoweroff    string         `xml:"on_poweroff,omitempty"`
+	OnReboot      string         `xml:"on_reboot,omitempty"`
+	OnCrash       string         `xml:"on_crash,omitempty"`
+	PM            *PM            `xml:"pm"`
+	Devices       Devices        `xml:"devices"`
+	Extra         []Node         `xml:",any"` // seclabel, resource, cputune, qemu:commandline, …
+
+	layout layout // order of the parsed XML
+}
+
+// Metadata holds the configurator's own block plus whatever other tools stored
+type Metadata struct {
+	Configurator *ConfiguratorMeta `xml:"https://github.com/mrtoadie/kvm-configurator/xmlns/1.0 configurator"`
+	Extra        []Node            `xml:",any"` // libosinfo, …
+}
+
+// ConfiguratorMeta records how the domain was created
+type ConfiguratorMeta struct {
+	Variant  string   `xml:"variant,omitempty"`  // osinfo short id, e.g. archlinux
+	Attached []string `xml:"attached,omitempty"` // existing images and devices (attach:, --import) – not ours to delete
+	Extra    []Node   `xml:",any"`
+}
+
+type Memory struct {
+	Unit  string     `xml:"unit,attr,omitempty"`
+	Attrs []xml.Attr `xml:",any,attr"`
+	Value uint64     `xml:",chardata"`
+}
+
+type MaxMemory struct {
+	Slots string     `xml:"slots,attr,omitempty"`
+	Unit  string     `xml:"unit,attr,omitempty"`
+	Attrs []xml.Attr `xml:",any,attr"`
+	Value uint64     `xml:",chardata"`
+}
+
+type MemoryBacking struct {
+	Source *MemorySource `xml:"source"`
+	Access *MemoryAccess `xml:"access"`
+	Extra  []Node        `xml:",any"`
+}
+
+type MemorySource struct {
+	Type  string     `xml:"type,attr"` // file | anonymous | memfd
+	Attrs []xml.Attr `xml:",any,attr"`
+}
+
+type MemoryAccess struct {
+	Mode  string     `xml:"mode,attr"` // shared | private
+	Attrs []xml.Attr `xml:",any,attr"`
+}
+
+type VCPU struct {
+	Placement string     `xml:"placement,attr,omitempty"`
+	Current   string     `xml:"current,attr,omitempty"`
+	Attrs     []xml.Attr `xml:",any,attr"`
+	Value     int        `xml:",chardata"`
+}
+
+type OS struct {
+	Firmware string     `xml:"firmware,attr,omitempty"` // efi | bios (auto-selection)
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Type     OSType     `xml:"type"`
+	FWInfo   *FWInfo    `xml:"firmware"` // narrows the auto-selection
+	Loader   *Loader    `xml:"loader"`
+	NVRAM    *NVRAM     `xml:"nvram"`
+	Kernel   string     `xml:"kernel,omitempty"`
+	Initrd   string     `xml:"initrd,omitempty"`
+	Cmdline  string     `xml:"cmdline,omitempty"`
+	Boot     []Boot     `xml:"boot"`
+	BootMenu *Enabled   `xml:"bootmenu"`
+	SMBIOS   *SMBIOS    `xml:"smbios"`
+	Extra    []Node     `xml:",any"` // acpi tables, …
+}
+
+// FWInfo lists firmware features for libvirt's auto-selection
+type FWInfo struct {
+	Features []FWFeature `xml:"feature"`
+	Extra    []Node      `xml:",any"`
+}
+
+type FWFeature struct {
+	Enabled string `xml:"enabled,attr"` // yes | no
+	Name    string `xml:"name,attr"`    // secure-boot | enrolled-keys
+}
+
+type OSType struct {
+	Arch    string     `xml:"arch,attr,omitempty"`
+	Machine string     `xml:"machine,attr,omitempty"`
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Value   string     `xml:",chardata"` // hvm
+}
+
+// Loader is the firmware image (OVMF_CODE.fd, …)
+type Loader struct {
+	ReadOnly string     `xml:"readonly,attr,omitempty"`
+	Secure   string     `xml:"secure,attr,omitempty"`
+	Type     string     `xml:"type,attr,omitempty"` // rom | pflash
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Value    string     `xml:",chardata"`
+}
+
+// NVRAM is the per-VM UEFI variable store
+type NVRAM struct {
+	Template string     `xml:"template,attr,omitempty"`
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Value    string     `xml:",chardata"`
+	Extra    []Node     `xml:",any"`
+}
+
+type Boot struct {
+	Dev   string     `xml:"dev,attr"` // hd | cdrom | network | fd
+	Attrs []xml.Attr `xml:",any,attr"`
+}
+
+type SMBIOS struct {
+	Mode  string     `xml:"mode,attr"`
+	Attrs []xml.Attr `xml:",any,attr"`
+}
+
+// Enabled is the common <x enable="yes"/> switch
+type Enabled struct {
+	Enable string     `xml:"enable,attr"`
+	Attrs  []xml.Attr `xml:",any,attr"`
+}
+
+// Empty marks flag elements such as <acpi/>
+type Empty struct {
+	Attrs []xml.Attr `xml:",any,attr"`
+	Extra []Node     `xml:",any"`
+}
+
+type Features struct {
+	ACPI   *Empty `xml:"acpi"`
+	APIC   *Empty `xml:"apic"`
+	PAE    *Empty `xml:"pae"`
+	HAP    *State `xml:"hap"`
+	VMPort *State `xml:"vmport"`
+	SMM    *State `xml:"smm"`
+	Extra  []Node `xml:",any"` // hyperv, kvm, ioapic, …
+}
+
+// State is <x state="on|off"/>
+type State struct {
+	State string     `xml:"state,attr,omitempty"`
+	Attrs []xml.Attr `xml:",any,attr"`
+	Extra []Node     `xml:",any"`
+}
+
+type CPU struct {
+	Mode       string       `xml:"mode,attr,omitempty"`
+	Match      string       `xml:"match,attr,omitempty"`
+	Check      string       `xml:"check,attr,omitempty"`
+	Migratable string       `xml:"migratable,attr,omitempty"`
+	Attrs      []xml.Attr   `xml:",any,attr"`
+	Model      *CPUModel    `xml:"model"`
+	Vendor     string       `xml:"vendor,omitempty"`
+	Topology   *Topology    `xml:"topology"`
+	Features   []CPUFeature `xml:"feature"`
+	Extra      []Node       `xml:",any"` // numa, cache, …
+}
+
+type CPUModel struct {
+	Fallback string     `xml:"fallback,attr,omitempty"`
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Value    string     `xml:",chardata"`
+}
+
+type Topology struct {
+	Sockets string     `xml:"sockets,attr,omitempty"`
+	Dies    string     `xml:"dies,attr,omitempty"`
+	Cores   string     `xml:"cores,attr,omitempty"`
+	Threads string     `xml:"threads,attr,omitempty"`
+	Attrs   []xml.Attr `xml:",any,attr"`
+}
+
+type CPUFeature struct {
+	Policy string     `xml:"policy,attr"`
+	Name   string     `xml:"name,attr"`
+	Attrs  []xml.Attr `xml:",any,attr"`
+}
+
+type Clock struct {
+	Offset string     `xml:"offset,attr"`
+	Attrs  []xml.Attr `xml:",any,attr"`
+	Timers []Timer    `xml:"timer"`
+	Extra  []Node     `xml:",any"`
+}
+
+type Timer struct {
+	Name       string     `xml:"name,attr"`
+	TickPolicy string     `xml:"tickpolicy,attr,omitempty"`
+	Present    string     `xml:"present,attr,omitempty"`
+	Attrs      []xml.Attr `xml:",any,attr"`
+	Extra      []Node     `xml:",any"`
+}
+
+type PM struct {
+	SuspendToMem  *PMState `xml:"suspend-to-mem"`
+	SuspendToDisk *PMState `xml:"suspend-to-disk"`
+	Extra         []Node   `xml:",any"`
+}
+
+type PMState struct {
+	Enabled string     `xml:"enabled,attr"`
+	Attrs   []xml.Attr `xml:",any,attr"`
+}
+
+type Devices struct {
+	Emulator    string       `xml:"emulator,omitempty"`
+	Disks       []Disk       `xml:"disk"`
+	Controllers []Controller `xml:"controller"`
+	Filesystems []Filesystem `xml:"filesystem"`
+	Interfaces  []Interface  `xml:"interface"`
+	Serials     []Char       `xml:"serial"`
+	Consoles    []Char       `xml:"console"`
+	Channels    []Channel    `xml:"channel"`
+	Inputs      []Input      `xml:"input"`
+	TPMs        []TPM        `xml:"tpm"`
+	Graphics    []Graphics   `xml:"graphics"`
+	Sounds      []Sound      `xml:"sound"`
+	Videos      []Video      `xml:"video"`
+	MemBalloon  *MemBalloon  `xml:"memballoon"`
+	RNGs        []RNG        `xml:"rng"`
+	Extra       []Node       `xml:",any"` // hostdev, redirdev, watchdog, audio, …
+}
+
+type Disk struct {
+	Type      string      `xml:"type,attr"`   // file | block | volume | network
+	Device    string      `xml:"device,attr"` // disk | cdrom | floppy | lun
+	Attrs     []xml.Attr  `xml:",any,attr"`
+	Driver    *DiskDriver `xml:"driver"`
+	Source    *DiskSource `xml:"source"`
+	Target    DiskTarget  `xml:"target"`
+	Boot      *BootOrder  `xml:"boot"`
+	ReadOnly  *Empty      `xml:"readonly"`
+	Shareable *Empty      `xml:"shareable"`
+	Serial    string      `xml:"serial,omitempty"`
+	Alias     *Alias      `xml:"alias"`
+	Address   *Address    `xml:"address"`
+	Extra     []Node      `xml:",any"` // backingStore, iotune, …
+}
+
+type DiskDriver struct {
+	Name    string     `xml:"name,attr"`
+	Type    string     `xml:"type,attr,omitempty"`
+	Cache   string     `xml:"cache,attr,omitempty"`
+	IO      string     `xml:"io,attr,omitempty"`
+	Discard string     `xml:"discard,attr,omitempty"`
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Extra   []Node     `xml:",any"`
+}
+
+type DiskSource struct {
+	File     string     `xml:"file,attr,omitempty"`
+	Dev      string     `xml:"dev,attr,omitempty"`
+	Pool     string     `xml:"pool,attr,omitempty"`
+	Volume   string     `xml:"volume,attr,omitempty"`
+	Protocol string     `xml:"protocol,attr,omitempty"`
+	Name     string     `xml:"name,attr,omitempty"`
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Extra    []Node     `xml:",any"`
+}
+
+type DiskTarget struct {
+	Dev   string     `xml:"dev,attr"`
+	Bus   string     `xml:"bus,attr,omitempty"`
+	Attrs []xml.Attr `xml:",any,attr"`
+}
+
+// BootOrder is the per-device <boot order="n"/>
+type BootOrder struct {
+	Order string     `xml:"order,attr"`
+	Attrs []xml.Attr `xml:",any,attr"`
+}
+
+type Alias struct {
+	Name  string     `xml:"name,attr"`
+	Attrs []xml.Attr `xml:",any,attr"`
+}
+
+// Address keeps all attributes (type, domain, bus, slot, …) as they are
+type Address struct {
+	Type  string     `xml:"type,attr"`
+	Attrs []xml.Attr `xml:",any,attr"`
+}
+
+type Controller struct {
+	Type    string     `xml:"type,attr"`
+	Index   string     `xml:"index,attr,omitempty"`
+	Model   string     `xml:"model,attr,omitempty"`
+	Ports   int        `xml:"ports,attr,omitempty"`
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Alias   *Alias     `xml:"alias"`
+	Address *Address   `xml:"address"`
+	Extra   []Node     `xml:",any"`
+}
+
+type Filesystem struct {
+	Type       string     `xml:"type,attr"`
+	AccessMode string     `xml:"accessmode,attr,omitempty"`
+	Attrs      []xml.Attr `xml:",any,attr"`
+	Driver     *FSDriver  `xml:"driver"`
+	Source     FSSource   `xml:"source"`
+	Target     FSTarget   `xml:"target"`
+	Address    *Address   `xml:"address"`
+	Extra      []Node     `xml:",any"`
+}
+
+type FSDriver struct {
+	Type  string     `xml:"type,attr"` // path | virtiofs
+	Attrs []xml.Attr `xml:",any,attr"`
+}
+
+type FSSource struct {
+	Dir   string     `xml:"dir,attr"`
+	Attrs []xml.Attr `xml:",any,attr"`
+}
+
+type FSTarget struct {
+	Dir   string     `xml:"dir,attr"`
+	Attrs []xml.Attr `xml:",any,attr"`
+}
+
+type Interface struct {
+	Type    string       `xml:"type,attr"` // network | bridge | direct | user
+	Attrs   []xml.Attr   `xml:",any,attr"`
+	MAC     *MAC         `xml:"mac"`
+	Source  *IfaceSource `xml:"source"` // none for type user
+	Target  *IfaceTarget `xml:"target"`
+	Model   *Model       `xml:"model"`
+	Boot    *BootOrder   `xml:"boot"`
+	Alias   *Alias       `xml:"alias"`
+	Address *Address     `xml:"address"`
+	Extra   []Node       `xml:",any"`
+}
+
+type MAC struct {
+	Address string     `xml:"address,attr"`
+	Attrs   []xml.Attr `xml:",any,attr"`
+}
+
+type IfaceSource struct {
+	Network string     `xml:"network,attr,omitempty"`
+	Bridge  string     `xml:"bridge,attr,omitempty"`
+	Dev     string     `xml:"dev,attr,omitempty"`
+	Mode    string     `xml:"mode,attr,omitempty"`
+	Attrs   []xml.Attr `xml:",any,attr"`
+}
+
+type IfaceTarget struct {
+	Dev   string     `xml:"dev,attr"`
+	Attrs []xml.Attr `xml:",any,attr"`
+}
+
+type Model struct {
+	Type  string     `xml:"type,attr"`
+	Attrs []xml.Attr `xml:",any,attr"`
+	Extra []Node     `xml:",any"`
+}
+
+// Char is a serial port or console
+type Char struct {
+	Type   string      `xml:"type,attr"` // pty
+	Attrs  []xml.Attr  `xml:",any,attr"`
+	Source *CharSource `xml:"source"`
+	Target *CharTarget `xml:"target"`
+	Alias  *Alias      `xml:"alias"`
+	Extra  []Node      `xml:",any"`
+}
+
+type CharSource struct {
+	Path  string     `xml:"path,attr,omitempty"`
+	Mode  string     `xml:"mode,attr,omitempty"`
+	Attrs []xml.Attr `xml:",any,attr"`
+}
+
+type CharTarget struct {
+	Type  string     `xml:"type,attr,omitempty"`
+	Port  *int       `xml:"port,attr"`
+	Attrs []xml.Attr `xml:",any,attr"`
+	Extra []Node     `xml:",any"`
+}
+
+type Channel struct {
+	Type    string        `xml:"type,attr"` // spicevmc | unix
+	Attrs   []xml.Attr    `xml:",any,attr"`
+	Source  *CharSource   `xml:"source"`
+	Target  ChannelTarget `xml:"target"`
+	Alias   *Alias        `xml:"alias"`
+	Address *Address      `xml:"address"`
+	Extra   []Node        `xml:",any"`
+}
+
+type ChannelTarget struct {
+	Type  string     `xml:"type,attr"`
+	Name  string     `xml:"name,attr,omitempty"`
+	Attrs []xml.Attr `xml:",any,attr"`
+}
+
+type Input struct {
+	Type    string     `xml:"type,attr"`
+	Bus     string     `xml:"bus,attr,omitempty"`
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Alias   *Alias     `xml:"alias"`
+	Address *Address   `xml:"address"`
+	Extra   []Node     `xml:",any"`
+}
+
+// TPM is a (usually emulated) trusted platform module
+type TPM struct {
+	Model   string      `xml:"model,attr,omitempty"` // tpm-crb | tpm-tis
+	Attrs   []xml.Attr  `xml:",any,attr"`
+	Backend *TPMBackend `xml:"backend"`
+	Alias   *Alias      `xml:"alias"`
+	Extra   []Node      `xml:",any"`
+}
+
+type TPMBackend struct {
+	Type    string     `xml:"type,attr"`              // emulator | passthrough
+	Version string     `xml:"version,attr,omitempty"` // 2.0
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Extra   []Node     `xml:",any"`
+}
+
+type Graphics struct {
+	Type     string     `xml:"type,attr"` // spice | vnc
+	Port     string     `xml:"port,attr,omitempty"`
+	AutoPort string     `xml:"autoport,attr,omitempty"`
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Listen   *Listen    `xml:"listen"`
+	Extra    []Node     `xml:",any"` // image, gl, …
+}
+
+type Listen struct {
+	Type    string     `xml:"type,attr"`
+	Address string     `xml:"address,attr,omitempty"`
+	Attrs   []xml.Attr `xml:",any,attr"`
+}
+
+type Sound struct {
+	Model   string     `xml:"model,attr"`
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Alias   *Alias     `xml:"alias"`
+	Address *Address   `xml:"address"`
+	Extra   []Node     `xml:",any"`
+}
+
+type Video struct {
+	Model   VideoModel `xml:"model"`
+	Alias   *Alias     `xml:"alias"`
+	Address *Address   `xml:"address"`
+	Extra   []Node     `xml:",any"`
+}
+
+type VideoModel struct {
+	Type    string     `xml:"type,attr"`
+	Heads   string     `xml:"heads,attr,omitempty"`
+	Primary string     `xml:"primary,attr,omitempty"`
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Extra   []Node     `xml:",any"` // acceleration
+}
+
+type MemBalloon struct {
+	Model   string     `xml:"model,attr"`
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Alias   *Alias     `xml:"alias"`
+	Address *Address   `xml:"address"`
+	Extra   []Node     `xml:",any"`
+}
+
+type RNG struct {
+	Model   string     `xml:"model,attr"`
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Backend RNGBackend `xml:"backend"`
+	Alias   *Alias     `xml:"alias"`
+	Address *Address   `xml:"address"`
+	Extra   []Node     `xml:",any"`
+}
+
+type RNGBackend struct {
+	Model string     `xml:"model,attr"`
+	Attrs []xml.Attr `xml:",any,attr"`
+	Value string     `xml:",chardata"`
+}
+
+// Marshal returns the XML of the domain formatted like virsh dumpxml (with
+// trailing newline); a parsed domain keeps the order it was read in
+func (d *Domain) Marshal() ([]byte, error) {
+	out, err := xml.Marshal(d)
+	if err != nil {
+		return nil, fmt.Errorf("marshal domain %s: %w", d.Name, err)
+	}
+	root, err := readTree(out)
+	if err != nil {
+		return nil, fmt.Errorf("marshal domain %s: %w", d.Name, err)
+	}
+	d.layout.arrange(root, "/domain[0]")
+	var b bytes.Buffer
+	root.write(&b, 0, libvirtQuoting)
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
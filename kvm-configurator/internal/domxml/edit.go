@@ -0,0 +1,113 @@
+// domxml/edit.go
+// last modified: Oct 16 2026
+package domxml
+
+// MiB converts a memory value to MiB (libvirt default unit: KiB)
+func (m Memory) MiB() int64 {
+	v := int64(m.Value)
+	switch m.Unit {
+	case "b", "bytes":
+		return v / (1024 * 1024)
+	case "KB":
+		return v * 1000 / (1024 * 1024)
+	case "M", "MiB":
+		return v
+	case "MB":
+		return v * 1000 * 1000 / (1024 * 1024)
+	case "G", "GiB":
+		return v * 1024
+	case "GB":
+		return v * 1000 * 1000 * 1000 / (1024 * 1024)
+	default: // "", "k", "KiB"
+		return v / 1024
+	}
+}
+
+// Variant returns the os-variant recorded by the configurator (if any)
+func (d *Domain) Variant() string {
+	if d.Metadata == nil || d.Metadata.Configurator == nil {
+		return ""
+	}
+	return d.Metadata.Configurator.Variant
+}
+
+// AttachedDisks returns the disks that were attached as they are instead of
+// created by the configurator
+func (d *Domain) AttachedDisks() []string {
+	if d.Metadata == nil || d.Metadata.Configurator == nil {
+		return nil
+	}
+	return d.Metadata.Configurator.Attached
+}
+
+// DiskPaths returns the image paths of all disks (no CD-ROMs)
+func (d *Domain) DiskPaths() []string {
+	var out []string
+	for _, disk := range d.Devices.Disks {
+		if disk.Device != "" && disk.Device != "disk" {
+			continue
+		}
+		if p := disk.Path(); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Path is the file or block device behind a disk
+func (disk *Disk) Path() string {
+	if disk.Source == nil {
+		return ""
+	}
+	if disk.Source.File != "" {
+		return disk.Source.File
+	}
+	return disk.Source.Dev
+}
+
+// DiskByPath finds the disk that uses the given image
+func (d *Domain) DiskByPath(path string) *Disk {
+	for i := range d.Devices.Disks {
+		if d.Devices.Disks[i].Path() == path {
+			return &d.Devices.Disks[i]
+		}
+	}
+	return nil
+}
+
+/*
+UseLoader pins the UEFI code and variable template instead of letting
+libvirt pick them. libvirt copies the template to the VM's own NVRAM file
+(…/qemu/nvram/<name>_VARS.fd) on the first start; undefine --nvram
+removes it again.
+*/
+func (d *Domain) UseLoader(code, varsTemplate string, secure bool) {
+	d.OS.Firmware = ""
+	d.OS.FWInfo = nil
+	d.OS.Loader = &Loader{ReadOnly: "yes", Type: "pflash", Secure: "no", Value: code}
+	if secure {
+		d.OS.Loader.Secure = "yes"
+	}
+	d.OS.NVRAM = &NVRAM{Template: varsTemplate}
+}
+
+// ReplaceDisk points the disk that uses oldPath to newPath; format sets the
+// driver type (qcow2, raw, …) unless empty. Reports whether a disk matched.
+func (d *Domain) ReplaceDisk(oldPath, newPath, format string) bool {
+	disk := d.DiskByPath(oldPath)
+	if disk == nil {
+		return false
+	}
+	if disk.Source.Dev != "" && disk.Source.File == "" {
+		disk.Source.Dev = newPath
+	} else {
+		disk.Source.File = newPath
+	}
+	if format != "" {
+		if disk.Driver == nil {
+			disk.Driver = &DiskDriver{Name: "qemu"}
+		}
+		disk.Driver.Type = format
+	}
+	return true
+}
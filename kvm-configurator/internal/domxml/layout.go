@@ -0,0 +1,230 @@
+// domxml/layout.go
+// last modified: Oct 16 2026
+package domxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+/*
+layout is the order in which a parsed definition had its attributes and
+child elements. The structs can only write known fields in field order and
+unknown ones (Extra, Attrs) after them; Marshal puts everything back where
+it was read. Elements are addressed by path with the position among equally
+named siblings: /domain[0]/devices[0]/disk[1]/source[0].
+*/
+type layout map[string]*elemOrder
+
+type elemOrder struct {
+	attrs    []string
+	children []string
+}
+
+// qname is a raw (not namespace-resolved) name as written: prefix:local
+func qname(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
+
+// readLayout records the order of attributes and children in data
+func readLayout(data []byte) (layout, error) {
+	type frame struct {
+		path string
+		seen map[string]int
+	}
+	l := layout{}
+	stack := []frame{{seen: map[string]int{}}}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.RawToken()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			parent := &stack[len(stack)-1]
+			name := qname(t.Name)
+			path := fmt.Sprintf("%s/%s[%d]", parent.path, name, parent.seen[name])
+			parent.seen[name]++
+			if o := l[parent.path]; o != nil {
+				o.children = append(o.children, name)
+			}
+			o := &elemOrder{}
+			for _, a := range t.Attr {
+				o.attrs = append(o.attrs, qname(a.Name))
+			}
+			l[path] = o
+			stack = append(stack, frame{path: path, seen: map[string]int{}})
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+			if len(stack) == 1 {
+				return l, nil // end of the root element
+			}
+		}
+	}
+}
+
+// xnode is an element, a text or a comment of the document being written
+type xnode struct {
+	name     string // "" for text and comments
+	attrs    []xml.Attr
+	text     string
+	comment  bool
+	children []*xnode
+}
+
+// readTree parses data into xnodes (names stay as written)
+func readTree(data []byte) (*xnode, error) {
+	root := &xnode{}
+	stack := []*xnode{root}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		top := stack[len(stack)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &xnode{name: qname(t.Name), attrs: t.Attr}
+			top.children = append(top.children, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			top.children = append(top.children, &xnode{text: string(t)})
+		case xml.Comment:
+			top.children = append(top.children, &xnode{text: string(t), comment: true})
+		}
+	}
+	if len(root.children) != 1 || root.children[0].name == "" {
+		return nil, fmt.Errorf("no single root element")
+	}
+	return root.children[0], nil
+}
+
+// arrange sorts attributes and children of n (at path) like the layout says
+func (l layout) arrange(n *xnode, path string) {
+	if o := l[path]; o != nil {
+		n.attrs = byRank(n.attrs, o.attrs, func(a xml.Attr) string { return qname(a.Name) })
+		n.children = byRank(n.children, o.children, func(c *xnode) string { return c.name })
+	}
+	seen := map[string]int{}
+	for _, c := range n.children {
+		if c.name != "" {
+			l.arrange(c, fmt.Sprintf("%s/%s[%d]", path, c.name, seen[c.name]))
+			seen[c.name]++
+		}
+	}
+}
+
+// byRank orders items by the first position of their name in order; what
+// order does not know (new elements, text) stays behind its predecessor
+func byRank[T any](items []T, order []string, name func(T) string) []T {
+	rank := map[string]int{}
+	for i, k := range order {
+		if _, ok := rank[k]; !ok {
+			rank[k] = i
+		}
+	}
+	type ranked struct {
+		item T
+		rank int
+	}
+	list := make([]ranked, len(items))
+	prev := -1
+	for i, it := range items {
+		if r, ok := rank[name(it)]; ok {
+			prev = r
+		}
+		list[i] = ranked{it, prev}
+	}
+	slices.SortStableFunc(list, func(a, b ranked) int { return a.rank - b.rank })
+	for i := range list {
+		items[i] = list[i].item
+	}
+	return items
+}
+
+// quoting is how attributes and text are written
+type quoting struct {
+	quote      string
+	attr, text *strings.Replacer
+}
+
+var (
+	// libvirt's own escaping, attributes in single quotes
+	libvirtEscape  = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "'", "&apos;", `"`, "&quot;")
+	libvirtQuoting = quoting{"'", libvirtEscape, libvirtEscape}
+	// <metadata> content is dumped by libxml2: double quotes
+	libxmlQuoting = quoting{`"`,
+		strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;"),
+		strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")}
+)
+
+/*
+write formats n the way libvirt dumps a domain: two spaces per level,
+attributes in single quotes (double within <metadata>), empty elements
+self-closed. Elements with text between their children (mixed content)
+are written inline.
+*/
+func (n *xnode) write(b *bytes.Buffer, depth int, q quoting) {
+	switch {
+	case n.comment:
+		b.WriteString("<!--" + n.text + "-->")
+		return
+	case n.name == "":
+		b.WriteString(q.text.Replace(n.text))
+		return
+	}
+	b.WriteString("<" + n.name)
+	for _, a := range n.attrs {
+		b.WriteString(" " + qname(a.Name) + "=" + q.quote + q.attr.Replace(a.Value) + q.quote)
+	}
+	if depth == 1 && n.name == "metadata" {
+		q = libxmlQuoting
+	}
+	if len(n.children) == 0 {
+		b.WriteString("/>")
+		return
+	}
+	b.WriteByte('>')
+
+	block := false
+	for _, c := range n.children {
+		if c.name == "" && !c.comment && strings.TrimSpace(c.text) != "" {
+			block = false
+			break
+		}
+		if c.name != "" || c.comment {
+			block = true
+		}
+	}
+	indent := strings.Repeat("  ", depth+1)
+	for _, c := range n.children {
+		if !block {
+			c.write(b, depth+1, q)
+			continue
+		}
+		if c.name == "" && !c.comment {
+			continue // indentation is written anew
+		}
+		b.WriteString("\n" + indent)
+		c.write(b, depth+1, q)
+	}
+	if block {
+		b.WriteString("\n" + indent[2:])
+	}
+	b.WriteString("</" + n.name + ">")
+}
@@ -0,0 +1,145 @@
+// domxml/parse.go
+// last modified: Oct 16 2026
+package domxml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// Node is an element the model does not know. Name and attributes are kept,
+// the content is stored verbatim (prefixes and all) and written back as-is.
+type Node struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Inner   []byte     `xml:",innerxml"`
+}
+
+// prefixes libvirt uses for its own namespaces
+var knownPrefixes = map[string]string{
+	"http://libvirt.org/schemas/domain/qemu/1.0":        "qemu",
+	"http://libvirt.org/schemas/domain/lxc/1.0":         "lxc",
+	"http://libvirt.org/schemas/domain/bhyve/1.0":       "bhyve",
+	"http://libvirt.org/schemas/domain/xen/1.0":         "xen",
+	"http://libosinfo.org/xmlns/libvirt/domain/1.0":     "libosinfo",
+	"http://libvirt.org/schemas/domain/vmware/1.0":      "vmware",
+	"http://libvirt.org/schemas/domain/hyperv/1.0":      "hyperv",
+	"http://libvirt.org/schemas/domain/cloudhypervisor": "ch",
+}
+
+// Parse reads a domain definition (virsh dumpxml, a saved XML file, …)
+func Parse(data []byte) (*Domain, error) {
+	var d Domain
+	if err := xml.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("parse domain XML: %w", err)
+	}
+	// namespace prefixes declared on <domain> (xmlns:qemu=…)
+	prefixes := map[string]string{}
+	for _, a := range d.Attrs {
+		if a.Name.Space == "xmlns" {
+			prefixes[a.Value] = a.Name.Local
+		}
+	}
+	restoreNames(reflect.ValueOf(&d).Elem(), prefixes)
+	l, err := readLayout(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse domain XML: %w", err)
+	}
+	d.layout = l
+	return &d, nil
+}
+
+// ParseFile reads and parses a saved XML definition
+func ParseFile(path string) (*Domain, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	d, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return d, nil
+}
+
+var (
+	attrSliceType = reflect.TypeOf([]xml.Attr(nil))
+	nodeType      = reflect.TypeOf(Node{})
+)
+
+/*
+restoreNames undoes the namespace resolution of encoding/xml. The decoder
+turns qemu:commandline into {Space: URL, Local: commandline} and would write
+it back as <commandline xmlns="URL"> next to a mangled "_xmlns:qemu"
+declaration – the verbatim inner XML then uses an unbound prefix. We put the
+prefix back into the local name, which the encoder writes unchanged.
+*/
+func restoreNames(v reflect.Value, prefixes map[string]string) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			restoreNames(v.Elem(), prefixes)
+		}
+	case reflect.Slice:
+		if v.Type() == attrSliceType {
+			attrs := v.Interface().([]xml.Attr)
+			for i := range attrs {
+				attrs[i].Name = qualify(attrs[i].Name, prefixes, nil)
+			}
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			restoreNames(v.Index(i), prefixes)
+		}
+	case reflect.Struct:
+		if v.Type() == nodeType {
+			n := v.Addr().Interface().(*Node)
+			if n.XMLName.Space != "" {
+				n.XMLName = qualify(n.XMLName, prefixes, n)
+			}
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				restoreNames(v.Field(i), prefixes)
+			}
+		}
+	}
+}
+
+// qualify maps {URL, local} back to {"", prefix:local}; n is the node the
+// name belongs to (its own xmlns:prefix declarations win)
+func qualify(name xml.Name, prefixes map[string]string, n *Node) xml.Name {
+	switch name.Space {
+	case "":
+		return name
+	case "xmlns":
+		return xml.Name{Local: "xmlns:" + name.Local}
+	case "xml", "http://www.w3.org/XML/1998/namespace":
+		return xml.Name{Local: "xml:" + name.Local}
+	}
+	if n != nil {
+		for _, a := range n.Attrs {
+			if (a.Name.Space == "xmlns" || strings.HasPrefix(a.Name.Local, "xmlns:")) && a.Value == name.Space {
+				return xml.Name{Local: strings.TrimPrefix(a.Name.Local, "xmlns:") + ":" + name.Local}
+			}
+		}
+	}
+	if p, ok := prefixes[name.Space]; ok {
+		return xml.Name{Local: p + ":" + name.Local}
+	}
+	if p, ok := knownPrefixes[name.Space]; ok {
+		if n != nil {
+			// declare it on the element itself so the output stays well-formed
+			n.Attrs = append(n.Attrs, xml.Attr{Name: xml.Name{Local: "xmlns:" + p}, Value: name.Space})
+		}
+		return xml.Name{Local: p + ":" + name.Local}
+	}
+	if n != nil {
+		// default namespace of an unprefixed element
+		n.Attrs = append(n.Attrs, xml.Attr{Name: xml.Name{Local: "xmlns"}, Value: name.Space})
+	}
+	return xml.Name{Local: name.Local}
+}
@@ -0,0 +1,93 @@
+// domxml/parse_test.go
+// last modified: Oct 16 2026
+package domxml
+
+import (
+	"bytes"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// names lists the elements of nodes as they will be written
+func names(nodes []Node) []string {
+	out := make([]string, len(nodes))
+	for i, n := range nodes {
+		out[i] = n.XMLName.Local
+	}
+	return out
+}
+
+// dumpXML is the definition of a running VM as virsh dumpxml prints it
+func dumpXML(t *testing.T) []byte {
+	t.Helper()
+	raw, err := os.ReadFile("testdata/dumpxml.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestParseMarshalDumpXML(t *testing.T) {
+	raw := dumpXML(t)
+	dom, err := Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := dom.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, raw) {
+		t.Errorf("round trip differs:\n--- got\n%s\n--- want\n%s", out, raw)
+	}
+
+	// unknown elements are kept in Extra in document order
+	for _, c := range []struct {
+		where string
+		got   []Node
+		want  []string
+	}{
+		{"domain", dom.Extra, []string{"resource", "seclabel", "seclabel", "qemu:commandline"}},
+		{"metadata", dom.Metadata.Extra, []string{"libosinfo:libosinfo"}},
+		{"devices", dom.Devices.Extra, []string{"audio", "redirdev", "watchdog"}},
+		{"disk", dom.Devices.Disks[0].Extra, []string{"backingStore"}},
+		{"pci controller", dom.Devices.Controllers[2].Extra, []string{"model", "target"}},
+		{"graphics", dom.Devices.Graphics[0].Extra, []string{"image"}},
+	} {
+		if got := names(c.got); !slices.Equal(got, c.want) {
+			t.Errorf("%s: Extra = %v, want %v", c.where, got, c.want)
+		}
+	}
+
+	if dom.Variant() != "debian13" || !slices.Equal(dom.AttachedDisks(), []string{"/srv/images/data.raw"}) {
+		t.Errorf("configurator metadata: variant %q, attached %v", dom.Variant(), dom.AttachedDisks())
+	}
+	if src := dom.Devices.Interfaces[1].Source; src != nil {
+		t.Errorf("user interface got a source: %+v", src)
+	}
+}
+
+func TestEditKeepsLayout(t *testing.T) {
+	raw := dumpXML(t)
+	dom, err := Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const oldPath, newPath = "/var/lib/libvirt/images/web-01-system.qcow2", "/srv/vms/web-02-system.qcow2"
+	if !dom.ReplaceDisk(oldPath, newPath, "qcow2") {
+		t.Fatal("system disk not found")
+	}
+	dom.Name = "web-02"
+
+	out, err := dom.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Replace(string(raw), oldPath, newPath, 1)
+	want = strings.Replace(want, "<name>web-01</name>", "<name>web-02</name>", 1)
+	if string(out) != want {
+		t.Errorf("edited domain:\n%s\nwant\n%s", out, want)
+	}
+}
@@ -0,0 +1,65 @@
+// engine/cloudinit.go
+// last modified: Oct 16 2026
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	// internal
+	"configurator/internal/backend"
+	"configurator/internal/model"
+	"configurator/internal/style"
+)
+
+/*
+checkSeed – a cloud-init seed needs a cloud image to boot from (linked
+clone or attached image) and is written by this program, so only next to a
+local hypervisor. Nothing to do without cloud-init.
+*/
+func checkSeed(be backend.Backend, cfg model.DomainConfig) error {
+	ci := cfg.CloudInit
+	if ci == nil {
+		return nil
+	}
+	if !cfg.Imported() {
+		return fmt.Errorf("cloud-init: the system disk must be a cloud image (--cloud-image, --backing or --import)")
+	}
+	if !backend.LocalURI(be.URI()) {
+		return fmt.Errorf("cloud-init: the seed ISO is written locally – not possible for %s", be.URI())
+	}
+	if model.IsBlockDevice(cfg.PrimaryDisk().Path) {
+		return fmt.Errorf("cloud-init: no directory for the seed ISO next to %s", cfg.PrimaryDisk().Path)
+	}
+	if err := ci.Validate(); err != nil {
+		return fmt.Errorf("cloud-init: %w", err)
+	}
+	return nil
+}
+
+// writeSeed writes the NoCloud seed ISO of cfg (replacing an older one)
+func writeSeed(cfg model.DomainConfig) error {
+	seed := cfg.SeedPath()
+	if err := os.MkdirAll(filepath.Dir(seed), 0o755); err != nil {
+		return fmt.Errorf("cloud-init: %w", err)
+	}
+	if err := cfg.CloudInit.WriteSeed(seed, cfg.Name); err != nil {
+		return fmt.Errorf("cloud-init: %w", err)
+	}
+	style.Info("Cloud-init seed written", seed)
+	return nil
+}
+
+// dryRunSeed prints the files that would go onto the seed ISO
+func dryRunSeed(cfg model.DomainConfig) error {
+	files, err := cfg.CloudInit.Files(cfg.Name)
+	if err != nil {
+		return err
+	}
+	fmt.Println(style.Hint("\nCloud-init seed " + cfg.SeedPath() + ":"))
+	for _, f := range files {
+		fmt.Printf("--- %s\n%s", f.Name, f.Data)
+	}
+	return nil
+}
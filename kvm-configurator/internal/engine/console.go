@@ -0,0 +1,80 @@
+// engine/console.go
+// last modified: Oct 16 2026
+package engine
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	// internal
+	"configurator/internal/backend"
+	"configurator/internal/config"
+	"configurator/internal/style"
+)
+
+/*
+StartVM boots a defined VM and opens the requested console: the graphical
+viewer runs detached next to the menu, the serial console takes over the
+terminal until it is left with Ctrl+]. A headless VM (graphics none) gets
+the serial console instead of the viewer.
+*/
+func StartVM(be backend.Backend, name, console, graphics string) error {
+	if err := be.Start(name); err != nil {
+		return fmt.Errorf("start failed: %w", err)
+	}
+	style.Successf("VM %s started.", name)
+
+	console = headless(console, graphics)
+	args := consoleCommand(console, be.URI(), name)
+	if args == nil {
+		return nil
+	}
+	if err := config.RequireCommand(args[0]); err != nil {
+		return fmt.Errorf("cannot open the console: %w", err)
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	if console == config.ConsoleViewer {
+		if err := cmd.Start(); err != nil {
+			return fmt.Errorf("cannot open the viewer: %w", err)
+		}
+		go cmd.Wait() // reap it whenever the window is closed
+		style.Info("Viewer opened", name)
+		return nil
+	}
+	style.Info("Serial console", "leave with Ctrl+]")
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("console: %w", err)
+	}
+	return nil
+}
+
+// headless swaps the viewer for the serial console when there is no display
+func headless(console, graphics string) string {
+	if console == config.ConsoleViewer && strings.EqualFold(strings.TrimSpace(graphics), "none") {
+		return config.ConsoleSerial
+	}
+	return console
+}
+
+// consoleCommand returns the command line of a console (nil for none)
+func consoleCommand(console, uri, name string) []string {
+	var args []string
+	switch console {
+	case config.ConsoleViewer:
+		args = []string{config.CmdVirtViewer}
+	case config.ConsoleSerial:
+		args = []string{config.CmdVirsh}
+	default:
+		return nil
+	}
+	if uri != "" {
+		args = append(args, "--connect", uri)
+	}
+	if console == config.ConsoleSerial {
+		return append(args, "console", name)
+	}
+	return append(args, name)
+}
@@ -1,113 +1,272 @@
 // engine/engine.go
-// last modified: Feb 22 2026
+// last modified: Oct 16 2026
 package engine
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"strconv"
-	"strings"
+	"slices"
+	"sync"
 
 	// internal
+	"configurator/internal/backend"
 	"configurator/internal/config"
+	"configurator/internal/domxml"
 	"configurator/internal/model"
 	"configurator/internal/style"
+	"configurator/internal/utils"
 )
 
+// CreateOptions change how CreateVM behaves
+type CreateOptions struct {
+	// DryRun prints the disk images that would be created and the resulting
+	// domain XML, nothing is written to disk or registered with libvirt
+	DryRun bool
+	// Start boots the VM right after define; Console (config.ConsoleViewer,
+	// config.ConsoleSerial) is opened once it runs
+	Start   bool
+	Console string
+}
+
+// diskJob is one image CreateVM has to provide
+type diskJob struct {
+	Path    string
+	SizeGiB int    // 0 = use an existing image
+	Format  string // of an existing image
+	Pool    string // set: the image is created as volume of this storage pool
+	Volume  string
+	// set: the image is a qcow2 overlay on this base (SizeGiB 0 = size of the base)
+	Backing       string
+	BackingFormat string
+}
+
+// created is what a CreateVM run has made so far
+type created struct {
+	jobs []diskJob
+	seed string // the cloud-init seed ISO
+}
+
+// remove deletes the images and the seed again; pool volumes through the
+// pool, so that works for a remote hypervisor, too
+func (c created) remove(be backend.Backend) {
+	var paths []string
+	for _, j := range c.jobs {
+		if j.Pool != "" && j.Backing == "" {
+			if err := be.DeleteVolume(j.Pool, j.Volume); err != nil {
+				style.Info("Could not remove volume "+j.Volume, err.Error())
+			}
+			continue
+		}
+		paths = append(paths, j.Path)
+	}
+	if c.seed != "" {
+		paths = append(paths, c.seed)
+	}
+	for _, p := range paths {
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			style.Info("Could not remove "+p, err.Error())
+		}
+	}
+}
+
 /*
 CreateVM receives a fully‑filled DomainConfig, the os‑variant string
 and the absolute path to the ISO file
 */
-func CreateVM(cfg model.DomainConfig, variant, isoPath, xmlDir string) error {
-	// build disk arguments (multiple!)
-	diskArgs := model.BuildDiskArgs(cfg.Disks, cfg.Name)
-
-	// CPU arguments
-	cpuBase := "host-passthrough"
-	cpuArg := cpuBase
-	if strings.TrimSpace(cfg.NestedVirt) != "" {
-		cpuArg = fmt.Sprintf("%s,+%s", cpuBase, cfg.NestedVirt)
-	}
-
-	// base arguments
-	args := []string{
-		"--name", cfg.Name,
-		"--memory", strconv.Itoa(cfg.MemMiB),
-		"--vcpus", strconv.Itoa(cfg.VCPU),
-		"--cpu", cpuArg,
-		"--os-variant", variant,
-		"--cdrom", cfg.ISOPath,
-		//"--boot", "hd,cdrom",
-		"--boot", cfg.BootOrder,
-		//"--boot", "network,cdrom,hd",
-		"--graphics", cfg.Graphics,
-		"--sound", cfg.Sound,
-		"--filesystem", cfg.FileSystem,
-		"--print-xml",
-		//"--print-step",
-	}
-
-	// append all disk arguments (each “--disk” + the argument)
-	for _, da := range diskArgs {
-		args = append(args, "--disk", da)
-	}
-
-	// progress-spinner
-	spinner := style.SpinnerProgress("\x1b[34mCreation of the VM " + cfg.Name + " is in progress")
-	defer spinner.Stop()
-
-	cmd := exec.Command(config.CmdVirtInstall, args...)
-	var out, errOut bytes.Buffer
-	cmd.Stdout, cmd.Stderr = &out, &errOut
-	if err := cmd.Run(); err != nil {
-		style.RedError("virt-install failed: ", ">", err)
+func CreateVM(be backend.Backend, cfg model.DomainConfig, variant, isoPath, xmlDir string, opts CreateOptions) error {
+	if isoPath != "" {
+		cfg.ISOPath = isoPath
+	}
+	// pool disks get the path of their volume, attached disks their format
+	// (on a copy, cfg.Disks is shared with the caller)
+	cfg.Disks = slices.Clone(cfg.Disks)
+	if err := ResolvePools(be, &cfg); err != nil {
+		return err
+	}
+	if err := inspectExisting(be, &cfg); err != nil {
+		return err
+	}
+	if err := checkSeed(be, cfg); err != nil {
+		return err
+	}
+	// build the domain definition natively
+	dom, err := domxml.Build(cfg, variant)
+	if err != nil {
+		return fmt.Errorf("build domain XML: %w", err)
+	}
+	if err := pinFirmware(dom, be.URI()); err != nil {
+		return err
+	}
+	if err := checkNetworks(be, dom, opts.Start); err != nil {
+		return err
+	}
+	// swtpm runs next to qemu – only checkable for a local hypervisor
+	if len(dom.Devices.TPMs) > 0 && backend.LocalURI(be.URI()) {
+		if err := config.RequireCommand(config.CmdSwtpm); err != nil {
+			return fmt.Errorf("TPM: %w – install swtpm", err)
+		}
+	}
+	cleanXML, err := dom.Marshal()
+	if err != nil {
+		return err
+	}
+	jobs := diskJobs(cfg)
+	if err := inspectBacking(be, jobs); err != nil {
+		return err
+	}
+	if err := placeDisks(be, jobs); err != nil {
 		return err
 	}
 
-	// Ensure that only one domain block is present
-	raw := out.Bytes()
-	xmlStr := string(raw)
+	if opts.DryRun {
+		opts.Console = headless(opts.Console, cfg.Graphics)
+		return dryRun(jobs, cleanXML, cfg, be.URI(), opts)
+	}
+
+	// progress-spinner (stopped early before a console takes over the terminal)
+	spinner := style.SpinnerProgress("\x1b[34mCreation of the VM " + cfg.Name + " is in progress")
+	stopSpinner := sync.OnceFunc(spinner.Stop)
+	defer stopSpinner()
 
-	// Find the 'first' </domain> tag – discard everything after it
-	firstEndIdx := strings.Index(xmlStr, "</domain>")
-	if firstEndIdx == -1 {
-		return fmt.Errorf(style.Colourise("Failed to locate closing </domain> tag in virt-install output", style.ColRed))
+	// disk images first – define would succeed without them but the VM could not start;
+	// what this run creates is removed again if the VM does not get defined
+	var made created
+	for _, j := range jobs {
+		var ok bool
+		switch {
+		case j.Backing != "":
+			// also for pool disks – the pool lists the overlay after its next refresh
+			ok, err = be.CreateOverlay(j.Path, j.Backing, j.BackingFormat, j.SizeGiB)
+		case j.SizeGiB == 0:
+			continue
+		case j.Pool != "":
+			ok, err = be.CreateVolume(j.Pool, j.Volume, j.SizeGiB, "qcow2")
+		default:
+			ok, err = be.CreateDisk(j.Path, j.SizeGiB, "qcow2")
+		}
+		if err != nil {
+			made.remove(be)
+			return fmt.Errorf("create disk image: %w", err)
+		}
+		if !ok {
+			style.Info("Disk image exists, reusing", j.Path)
+			continue
+		}
+		made.jobs = append(made.jobs, j)
+	}
+	if cfg.CloudInit != nil {
+		made.seed = cfg.SeedPath()
+		if err := writeSeed(cfg); err != nil {
+			made.remove(be)
+			return err
+		}
 	}
-	// +len("</domain>") includes the tag itself
-	cleanXMLStr := xmlStr[:firstEndIdx+len("</domain>")]
-	cleanXML := []byte(cleanXMLStr)
 
 	// xml path from config
 	if xmlDir == "" {
 		// fallback to current dir
 		xmlDir = "."
 	}
-	xmlFileName := cfg.Name + ".xml"
-	xmlFullPath := filepath.Join(xmlDir, xmlFileName)
+	xmlFullPath := filepath.Join(xmlDir, cfg.Name+".xml")
 
 	// save XML
 	if err := os.WriteFile(xmlFullPath, cleanXML, 0644); err != nil {
-		//return fmt.Errorf("\x1b[31mcould not write XML: %w\x1b[0m", err)
-		style.RedError("Could not write XML", xmlFileName, err)
-		//os.Exit(1)
-	} else {
-		abs, _ := filepath.Abs(xmlFullPath)
-		if err != nil {
-			abs = xmlFullPath // fallback
-		}
-		style.Successf("\n\nXML definition saved under: %s", abs)
+		made.remove(be)
+		return fmt.Errorf("save XML: %w", err)
 	}
+	if abs, err := filepath.Abs(xmlFullPath); err == nil {
+		xmlFullPath = abs
+	}
+	style.Successf("\n\nXML definition saved under: %s", xmlFullPath)
 
 	// define the new VM >> libvirt
-	if err := exec.Command("virsh", "define", xmlFullPath).Run(); err != nil {
-		//return fmt.Errorf("\x1b[31mvirsh define failed: %w\x1b[0m", err)
-		style.RedError("virsh define failed: %w", ">", err)
-		return err
+	if err := be.DefineXML(cleanXML); err != nil {
+		made.remove(be)
+		return fmt.Errorf("define %s: %w", cfg.Name, err)
+	}
+	if !opts.Start {
+		style.Successf("VM successfully registered with libvirt/qemu (not yet started).")
+		return nil
+	}
+	stopSpinner()
+	style.Successf("VM successfully registered with libvirt/qemu.")
+	if err := StartVM(be, cfg.Name, opts.Console, cfg.Graphics); err != nil {
+		return fmt.Errorf("VM %s is registered, but: %w", cfg.Name, err)
+	}
+	return nil
+}
+
+// diskJobs lists the image files of all disks
+func diskJobs(cfg model.DomainConfig) []diskJob {
+	jobs := make([]diskJob, 0, len(cfg.Disks))
+	for _, d := range cfg.Disks {
+		j := diskJob{Path: model.DiskImagePath(d, cfg.Name), SizeGiB: d.SizeGiB, Format: d.Format}
+		if d.Existing {
+			j.SizeGiB = 0
+		}
+		j.Backing = d.Backing
+		if d.Pool != "" {
+			j.Pool, j.Volume = d.Pool, d.VolumeName(cfg.Name)
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs
+}
+
+// virshCommand is a virsh command line for the connection uri
+func virshCommand(uri string, args ...string) []string {
+	cmd := []string{config.CmdVirsh}
+	if uri != "" {
+		cmd = append(cmd, "--connect", uri)
+	}
+	return append(cmd, args...)
+}
 
+// dryRun shows the disk images that would be created, the cloud-init seed
+// and the domain XML; virsh commands name the connection uri
+func dryRun(jobs []diskJob, xmlOut []byte, cfg model.DomainConfig, uri string, opts CreateOptions) error {
+	fmt.Println(style.BoxCenter(51, []string{"DRY-RUN"}))
+	fmt.Println(style.Hint("Disk images:"))
+	for _, j := range jobs {
+		if j.Backing != "" {
+			args := []string{config.CmdQemuImg, "create", "-f", "qcow2", "-b", j.Backing, "-F", j.BackingFormat, j.Path}
+			if j.SizeGiB > 0 {
+				args = append(args, fmt.Sprintf("%dG", j.SizeGiB))
+			}
+			fmt.Println(utils.ShellJoin(args))
+			continue
+		}
+		if j.SizeGiB == 0 {
+			if j.Format != "" {
+				fmt.Printf("(existing, %s) %s\n", j.Format, j.Path)
+			} else {
+				fmt.Printf("(existing) %s\n", j.Path)
+			}
+			continue
+		}
+		if j.Pool != "" {
+			fmt.Println(utils.ShellJoin(virshCommand(uri, "vol-create-as", j.Pool, j.Volume, fmt.Sprintf("%dG", j.SizeGiB), "--format", "qcow2")))
+			continue
+		}
+		fmt.Println(utils.ShellJoin([]string{config.CmdQemuImg, "create", "-f", "qcow2", j.Path, fmt.Sprintf("%dG", j.SizeGiB)}))
+	}
+	if cfg.CloudInit != nil {
+		if err := dryRunSeed(cfg); err != nil {
+			return err
+		}
+	}
+	fmt.Println(style.Hint("\nDomain XML:"))
+	fmt.Print(string(xmlOut))
+	if opts.Start {
+		fmt.Println(style.Hint("\nAfterwards:"))
+		fmt.Println(utils.ShellJoin(virshCommand(uri, "start", cfg.Name)))
+		if args := consoleCommand(opts.Console, uri, cfg.Name); args != nil {
+			fmt.Println(utils.ShellJoin(args))
+		}
 	}
-	style.Successf("VM successfully registered with libvirt/qemu (not yet started).")
+	style.Successf("\nDry-run finished – nothing was written or registered.")
 	return nil
 }
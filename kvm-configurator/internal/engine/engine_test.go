@@ -0,0 +1,179 @@
+// engine/engine_test.go
+// last modified: Oct 16 2026
+package engine
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	// internal
+	"configurator/internal/backend"
+	"configurator/internal/config"
+	"configurator/internal/model"
+)
+
+// captureStdout returns what fn prints
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	done := make(chan []byte)
+	go func() {
+		out, _ := io.ReadAll(r)
+		done <- out
+	}()
+	fn()
+	w.Close()
+	return string(<-done)
+}
+
+// failingDefine rejects every definition; its disk images are real files
+type failingDefine struct{ *backend.Fake }
+
+func (f failingDefine) CreateDisk(path string, sizeGiB int, format string) (bool, error) {
+	created, err := f.Fake.CreateDisk(path, sizeGiB, format)
+	if created {
+		err = os.WriteFile(path, nil, 0o644)
+	}
+	return created, err
+}
+
+func (failingDefine) DefineXML([]byte) error { return errors.New("unsupported configuration") }
+
+func TestCreateVMDryRunWritesNothing(t *testing.T) {
+	be := backend.NewFake()
+	diskDir, xmlDir := t.TempDir(), t.TempDir()
+	opts := CreateOptions{DryRun: true, Start: true, Console: config.ConsoleNone}
+	if err := CreateVM(be, testDomain("web", diskDir), "debian13", "", xmlDir, opts); err != nil {
+		t.Fatal(err)
+	}
+	for _, op := range []string{"define", "start", "create-disk", "vol-create-as", "create-overlay"} {
+		if hasCall(be.Calls, op+" ") {
+			t.Errorf("dry-run called %q: %v", op, be.Calls)
+		}
+	}
+	if len(be.Domains) != 0 || len(be.Images) != 0 {
+		t.Errorf("dry-run changed the hypervisor: domains %v, images %v", be.Domains, be.Images)
+	}
+	if entries, _ := os.ReadDir(xmlDir); len(entries) != 0 {
+		t.Errorf("dry-run saved %s", entries[0].Name())
+	}
+}
+
+func TestCreateVMDryRunNamesConnection(t *testing.T) {
+	be := backend.NewFake()
+	be.Host = "kvm01"
+	cfg := testDomain("web", "/var/lib/libvirt/images")
+	opts := CreateOptions{DryRun: true, Start: true, Console: config.ConsoleSerial}
+
+	out := captureStdout(t, func() {
+		if err := CreateVM(be, cfg, "debian13", "", t.TempDir(), opts); err != nil {
+			t.Fatal(err)
+		}
+	})
+	for _, want := range []string{
+		"virsh --connect test://kvm01/fake vol-create-as default web-system.qcow2 10G --format qcow2\n",
+		"virsh --connect test://kvm01/fake start web\n",
+		"virsh --connect test://kvm01/fake console web\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dry-run output lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestCreateVMDefines(t *testing.T) {
+	for _, start := range []bool{false, true} {
+		be := backend.NewFake()
+		diskDir, xmlDir := t.TempDir(), t.TempDir()
+		opts := CreateOptions{Start: start, Console: config.ConsoleNone}
+		if err := CreateVM(be, testDomain("web", diskDir), "debian13", "", xmlDir, opts); err != nil {
+			t.Fatal(err)
+		}
+
+		disk := filepath.Join(diskDir, "web-system.qcow2")
+		if img := be.Images[disk]; img == nil || img.SizeGiB != 10 || img.Format != "qcow2" {
+			t.Errorf("system disk %s = %+v, want 10 GiB qcow2", disk, img)
+		}
+		d := be.Domains["web"]
+		if d == nil {
+			t.Fatalf("web not defined: %v", be.Calls)
+		}
+		if d.VCPU != 2 || d.MemoryMiB != 2048 || !slices.Equal(d.Disks, []string{disk}) {
+			t.Errorf("defined %d vCPU, %d MiB, disks %v", d.VCPU, d.MemoryMiB, d.Disks)
+		}
+		saved, err := os.ReadFile(filepath.Join(xmlDir, "web.xml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(saved, d.XML) {
+			t.Error("saved XML differs from the defined one")
+		}
+
+		want := "shut off"
+		if start {
+			want = "running"
+		}
+		if d.State != want {
+			t.Errorf("Start=%v: state %q, want %q", start, d.State, want)
+		}
+	}
+}
+
+func TestCreateVMInactiveNetwork(t *testing.T) {
+	for _, start := range []bool{false, true} {
+		be := backend.NewFake()
+		be.Networks["default"].Active = false
+		opts := CreateOptions{Start: start, Console: config.ConsoleNone}
+		err := CreateVM(be, testDomain("web", t.TempDir()), "debian13", "", t.TempDir(), opts)
+		if start {
+			if err == nil || hasCall(be.Calls, "define ") || hasCall(be.Calls, "create-disk ") {
+				t.Errorf("Start with inactive network: err %v, calls %v", err, be.Calls)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("define with inactive network: %v", err)
+		}
+	}
+}
+
+func TestCreateVMDefineFailsRemovesImages(t *testing.T) {
+	be := failingDefine{backend.NewFake()}
+	dir := t.TempDir()
+	cfg := testDomain("web", dir)
+	cfg.Disks = append(cfg.Disks,
+		model.DiskSpec{Name: "data", Pool: "default", SizeGiB: 5, Bus: "virtio"},
+		model.DiskSpec{Name: "cache", Pool: "default", SizeGiB: 5, Bus: "virtio"})
+	const reused = "/var/lib/libvirt/images/web-cache.qcow2"
+	be.Images[reused] = &backend.FakeImage{Format: "qcow2", SizeGiB: 5}
+
+	err := CreateVM(be, cfg, "debian13", "", t.TempDir(), CreateOptions{})
+	if err == nil || err.Error() != "define web: unsupported configuration" {
+		t.Fatalf("CreateVM: %v", err)
+	}
+	system := filepath.Join(dir, "web-system.qcow2")
+	if !hasCall(be.Calls, "create-disk "+system) {
+		t.Fatalf("system disk not created: %v", be.Calls)
+	}
+	if _, err := os.Stat(system); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("system disk %s left behind (%v)", system, err)
+	}
+	if !hasCall(be.Calls, "vol-delete default web-data.qcow2") {
+		t.Errorf("pool volume not deleted: %v", be.Calls)
+	}
+	if be.Images[reused] == nil || hasCall(be.Calls, "vol-delete default web-cache.qcow2") {
+		t.Errorf("reused volume deleted: %v", be.Calls)
+	}
+}
@@ -0,0 +1,43 @@
+// engine/firmware.go
+// last modified: Oct 16 2026
+package engine
+
+import (
+	"fmt"
+
+	// internal
+	"configurator/internal/backend"
+	"configurator/internal/domxml"
+	"configurator/internal/firmware"
+	"configurator/internal/style"
+)
+
+// firmwareDirs are the descriptor directories pinFirmware reads
+var firmwareDirs = firmware.DefaultDirs
+
+/*
+pinFirmware replaces libvirt's UEFI auto-selection by the OVMF images of
+the best firmware descriptor, so the saved XML names the exact firmware
+and a missing OVMF package is reported before anything is created. The
+descriptors describe this machine only – on a remote host libvirt keeps
+choosing itself.
+*/
+func pinFirmware(dom *domxml.Domain, uri string) error {
+	if dom.OS.Firmware != "efi" || !backend.LocalURI(uri) {
+		return nil
+	}
+	secure := dom.OS.Loader != nil && dom.OS.Loader.Secure == "yes"
+	d, ok := firmware.FindUEFI(firmware.Load(firmwareDirs()...), dom.OS.Type.Arch, dom.OS.Type.Machine, secure)
+	if !ok {
+		if secure {
+			return fmt.Errorf("no UEFI firmware with Secure Boot and enrolled keys found – install edk2-ovmf (ovmf)")
+		}
+		return fmt.Errorf("no UEFI firmware found – install edk2-ovmf (ovmf)")
+	}
+	dom.UseLoader(d.Mapping.Executable.Filename, d.Mapping.NVRAMTemplate.Filename, secure)
+	if d.Has("requires-smm") {
+		dom.Features.SMM = &domxml.State{State: "on"}
+	}
+	style.Info("UEFI firmware", d.Mapping.Executable.Filename)
+	return nil
+}
@@ -0,0 +1,37 @@
+// engine/firmware_test.go
+// last modified: Oct 16 2026
+package engine
+
+import (
+	"testing"
+
+	// internal
+	"configurator/internal/domxml"
+	"configurator/internal/firmware"
+)
+
+func TestPinFirmwareNoMatch(t *testing.T) {
+	dir := t.TempDir() // no descriptors installed
+	firmwareDirs = func() []string { return []string{dir} }
+	t.Cleanup(func() { firmwareDirs = firmware.DefaultDirs })
+
+	for _, tc := range []struct {
+		uri, loaderSecure, want string
+	}{
+		{"", "", "no UEFI firmware found – install edk2-ovmf (ovmf)"},
+		{"qemu:///system", "yes", "no UEFI firmware with Secure Boot and enrolled keys found – install edk2-ovmf (ovmf)"},
+		{"qemu+ssh://host/system", "", ""}, // libvirt chooses on the remote host
+	} {
+		dom := &domxml.Domain{OS: domxml.OS{Firmware: "efi", Type: domxml.OSType{Arch: "x86_64", Machine: "q35", Value: "hvm"}}}
+		if tc.loaderSecure != "" {
+			dom.OS.Loader = &domxml.Loader{Secure: tc.loaderSecure}
+		}
+		got := ""
+		if err := pinFirmware(dom, tc.uri); err != nil {
+			got = err.Error()
+		}
+		if got != tc.want {
+			t.Errorf("%q, secure %q: %q, want %q", tc.uri, tc.loaderSecure, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// engine/network.go
+// last modified: Oct 16 2026
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	// internal
+	"configurator/internal/backend"
+	"configurator/internal/domxml"
+	"configurator/internal/netspec"
+	"configurator/internal/style"
+	"configurator/internal/ui"
+)
+
+// NetworkSources collects what the NIC picker offers: the libvirt networks
+// of be and – for a local hypervisor – the bridges and devices of this host
+func NetworkSources(be backend.Backend) ui.NetworkSources {
+	var src ui.NetworkSources
+	if nets, err := be.ListNetworks(); err == nil {
+		for _, n := range nets {
+			src.Networks = append(src.Networks, ui.NetworkChoice{Name: n.Name, Active: n.Active})
+		}
+	}
+	if backend.LocalURI(be.URI()) {
+		src.Bridges = netspec.HostBridges()
+		src.Devices = netspec.HostDevices()
+	}
+	return src
+}
+
+/*
+checkNetworks catches NICs that could never come up before anything is
+created: unknown libvirt networks and – locally – missing host bridges.
+An inactive network is only a warning, it can be started later – unless
+the VM is to be started right away (start).
+*/
+func checkNetworks(be backend.Backend, dom *domxml.Domain, start bool) error {
+	var nets []backend.Network
+	listed := false
+	for _, iface := range dom.Devices.Interfaces {
+		if iface.Source == nil {
+			continue
+		}
+		switch iface.Type {
+		case netspec.TypeNetwork:
+			if !listed {
+				var err error
+				if nets, err = be.ListNetworks(); err != nil {
+					style.Info("Cannot list libvirt networks", err.Error())
+					return nil
+				}
+				listed = true
+			}
+			name := iface.Source.Network
+			i := slices.IndexFunc(nets, func(n backend.Network) bool { return n.Name == name })
+			if i < 0 {
+				names := make([]string, len(nets))
+				for j, n := range nets {
+					names[j] = n.Name
+				}
+				return fmt.Errorf("libvirt network %q does not exist (available: %s)", name, strings.Join(names, ", "))
+			}
+			if !nets[i].Active {
+				if start {
+					return fmt.Errorf("libvirt network %q is not active – the VM cannot start (virsh net-start %s)", name, name)
+				}
+				style.Info(fmt.Sprintf("Network %s is not active", name), "start it before the VM: virsh net-start "+name)
+			}
+		case netspec.TypeBridge:
+			if backend.LocalURI(be.URI()) && !slices.Contains(netspec.HostBridges(), iface.Source.Bridge) {
+				return fmt.Errorf("host bridge %q does not exist", iface.Source.Bridge)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+// engine/storage.go
+// last modified: Oct 16 2026
+package engine
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	// internal
+	"configurator/internal/backend"
+	"configurator/internal/model"
+	"configurator/internal/poolxml"
+	"configurator/internal/ui"
+)
+
+// StoragePools collects the pools a disk can be placed in: the file based
+// pools of be (directory, fs, netfs) with their free space and the images
+// no VM uses (base images for linked clones)
+func StoragePools(be backend.Backend) []ui.PoolChoice {
+	pools, err := be.ListPools()
+	if err != nil {
+		return nil
+	}
+	var out []ui.PoolChoice
+	var users map[string][]string
+	var usersErr error
+	for _, p := range pools {
+		def, err := poolDefinition(be, p.Name)
+		if err != nil || !def.FileBased() {
+			continue
+		}
+		choice := ui.PoolChoice{Name: p.Name, Path: def.Path(), Active: p.Active, Available: p.Available}
+		// unused images are only offered if every VM's disks are known
+		if p.Active && usersErr == nil {
+			if users == nil {
+				users, usersErr = backend.DiskUsers(be)
+			}
+			vols, _ := be.ListVolumes(p.Name)
+			for _, v := range vols {
+				if usersErr == nil && len(users[backend.CanonicalPath(v.Path)]) == 0 {
+					choice.Images = append(choice.Images, v.Path)
+				}
+			}
+		}
+		out = append(out, choice)
+	}
+	return out
+}
+
+func poolDefinition(be backend.Backend, name string) (*poolxml.Pool, error) {
+	raw, err := be.PoolXML(name)
+	if err != nil {
+		return nil, err
+	}
+	return poolxml.Parse(raw)
+}
+
+/*
+ResolvePools sets the path of every pool disk to its volume in the pool
+directory (<pool dir>/<vm>-<disk>.qcow2), so the domain XML and all later
+disk tools see a plain image file. The pool has to exist, be active and
+keep its volumes as files.
+*/
+func ResolvePools(be backend.Backend, cfg *model.DomainConfig) error {
+	var pools []backend.Pool
+	for i := range cfg.Disks {
+		d := &cfg.Disks[i]
+		if d.Pool == "" {
+			continue
+		}
+		if pools == nil {
+			var err error
+			if pools, err = be.ListPools(); err != nil {
+				return fmt.Errorf("disk %s: %w", d.Name, err)
+			}
+		}
+		j := slices.IndexFunc(pools, func(p backend.Pool) bool { return p.Name == d.Pool })
+		if j < 0 {
+			names := make([]string, len(pools))
+			for k, p := range pools {
+				names[k] = p.Name
+			}
+			return fmt.Errorf("disk %s: storage pool %q does not exist (available: %s)", d.Name, d.Pool, strings.Join(names, ", "))
+		}
+		if !pools[j].Active {
+			return fmt.Errorf("disk %s: storage pool %s is not active – start it first (virsh pool-start %s)", d.Name, d.Pool, d.Pool)
+		}
+		def, err := poolDefinition(be, d.Pool)
+		if err != nil {
+			return fmt.Errorf("disk %s: %w", d.Name, err)
+		}
+		if !def.FileBased() {
+			return fmt.Errorf("disk %s: storage pool %s is of type %s – only directory pools can hold new disks", d.Name, d.Pool, def.Type)
+		}
+		d.Path = filepath.Join(def.Path(), d.VolumeName(cfg.Name))
+	}
+	return nil
+}
+
+/*
+inspectExisting fills the format of every attached disk from qemu-img info
+(libvirt does not probe formats) and refuses disks another VM already uses.
+*/
+func inspectExisting(be backend.Backend, cfg *model.DomainConfig) error {
+	var users map[string][]string
+	for i := range cfg.Disks {
+		d := &cfg.Disks[i]
+		if !d.Existing {
+			continue
+		}
+		if !backend.LocalURI(be.URI()) {
+			return fmt.Errorf("disk %s: attached disks are inspected with qemu-img on this machine – not possible for %s", d.Name, be.URI())
+		}
+		if !filepath.IsAbs(d.Path) {
+			return fmt.Errorf("disk %s: %q must be an absolute path", d.Name, d.Path)
+		}
+		info, err := be.DiskInfo(d.Path)
+		if err != nil {
+			return fmt.Errorf("disk %s: %w", d.Name, err)
+		}
+		if d.Format == "" {
+			d.Format = info.Format
+		}
+		if users == nil {
+			if users, err = backend.DiskUsers(be); err != nil {
+				return fmt.Errorf("disk %s: cannot tell whether %s is in use: %w", d.Name, d.Path, err)
+			}
+		}
+		if vms := users[backend.CanonicalPath(d.Path)]; len(vms) > 0 {
+			return fmt.Errorf("disk %s: %s is already attached to %s", d.Name, d.Path, strings.Join(vms, ", "))
+		}
+	}
+	return nil
+}
+
+/*
+inspectBacking checks the base image of every linked clone and fills in
+its format. The overlay keeps the size of its base unless a larger one was
+asked for. A base attached to a VM is refused – that VM would change the
+data below all clones.
+*/
+func inspectBacking(be backend.Backend, jobs []diskJob) error {
+	var users map[string][]string
+	for i := range jobs {
+		j := &jobs[i]
+		if j.Backing == "" {
+			continue
+		}
+		if !backend.LocalURI(be.URI()) {
+			return fmt.Errorf("%s: linked clones are created with qemu-img on this machine – not possible for %s", j.Path, be.URI())
+		}
+		if !filepath.IsAbs(j.Backing) {
+			return fmt.Errorf("base image %q must be an absolute path", j.Backing)
+		}
+		if backend.CanonicalPath(j.Backing) == backend.CanonicalPath(j.Path) {
+			return fmt.Errorf("%s cannot be a clone of itself", j.Path)
+		}
+		info, err := be.DiskInfo(j.Backing)
+		if err != nil {
+			return fmt.Errorf("base image: %w", err)
+		}
+		j.BackingFormat = info.Format
+		if uint64(j.SizeGiB)<<30 <= info.VirtualSize {
+			j.SizeGiB = 0
+		}
+		if users == nil {
+			if users, err = backend.DiskUsers(be); err != nil {
+				return fmt.Errorf("base image %s: cannot tell whether it is in use: %w", j.Backing, err)
+			}
+		}
+		if vms := users[backend.CanonicalPath(j.Backing)]; len(vms) > 0 {
+			return fmt.Errorf("base image %s is a disk of %s – a base must not be used by a VM itself", j.Backing, strings.Join(vms, ", "))
+		}
+	}
+	return nil
+}
+
+/*
+placeDisks – a new image in the directory of a storage pool becomes a
+volume of that pool, so libvirt writes it (no write access to
+/var/lib/libvirt/images needed, remote hosts work as well). Other new
+images are created with qemu-img on this machine, which is only possible
+for a local hypervisor.
+*/
+func placeDisks(be backend.Backend, jobs []diskJob) error {
+	var dirs map[string]string
+	for i := range jobs {
+		j := &jobs[i]
+		if j.SizeGiB == 0 || j.Pool != "" || j.Backing != "" {
+			continue
+		}
+		if dirs == nil {
+			dirs = poolDirs(be)
+		}
+		if pool, ok := dirs[filepath.Dir(j.Path)]; ok {
+			j.Pool, j.Volume = pool, filepath.Base(j.Path)
+			continue
+		}
+		if !backend.LocalURI(be.URI()) {
+			return fmt.Errorf("%s is not in a storage pool of %s – disks of a remote hypervisor need pool:<name>", j.Path, be.URI())
+		}
+	}
+	return nil
+}
+
+// poolDirs maps the directory of every active file based pool to its name
+func poolDirs(be backend.Backend) map[string]string {
+	dirs := map[string]string{}
+	pools, err := be.ListPools()
+	if err != nil {
+		return dirs
+	}
+	for _, p := range pools {
+		if !p.Active {
+			continue
+		}
+		if def, err := poolDefinition(be, p.Name); err == nil && def.FileBased() {
+			dirs[filepath.Clean(def.Path())] = p.Name
+		}
+	}
+	return dirs
+}
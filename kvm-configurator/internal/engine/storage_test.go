@@ -0,0 +1,91 @@
+// engine/storage_test.go
+// last modified: Oct 16 2026
+package engine
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	// internal
+	"configurator/internal/backend"
+	"configurator/internal/config"
+	"configurator/internal/model"
+)
+
+// testDomain is a small VM whose system disk lies in diskPath
+func testDomain(name, diskPath string) model.DomainConfig {
+	return model.NewDomainConfig(config.VMConfig{
+		Name: name, ID: "debian13", CPU: 2, RAM: 2048, DiskSize: 10, DiskPath: diskPath,
+		Network: "network=default", Graphics: "none",
+	}, config.Defaults{})
+}
+
+func hasCall(calls []string, prefix string) bool {
+	return slices.ContainsFunc(calls, func(c string) bool { return strings.HasPrefix(c, prefix) })
+}
+
+func TestCreateVMDiskInPoolDirectoryBecomesVolume(t *testing.T) {
+	be := backend.NewFake()
+	cfg := testDomain("web", "/var/lib/libvirt/images")
+	if err := CreateVM(be, cfg, "debian13", "", t.TempDir(), CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if !hasCall(be.Calls, "vol-create-as default web-system.qcow2 10 qcow2") {
+		t.Errorf("disk not created as volume of the default pool: %v", be.Calls)
+	}
+	if hasCall(be.Calls, "create-disk") {
+		t.Errorf("disk created with qemu-img: %v", be.Calls)
+	}
+}
+
+func TestCreateVMDiskOutsidePoolsLocal(t *testing.T) {
+	be := backend.NewFake()
+	dir := t.TempDir()
+	if err := CreateVM(be, testDomain("web", dir), "debian13", "", t.TempDir(), CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if !hasCall(be.Calls, "create-disk "+dir+"/web-system.qcow2") {
+		t.Errorf("disk not created with qemu-img: %v", be.Calls)
+	}
+}
+
+func TestCreateVMRemoteNeedsPools(t *testing.T) {
+	base := "/srv/images/debian-13-genericcloud-amd64.qcow2"
+	tests := []struct {
+		name    string
+		setup   func(*model.DomainConfig)
+		wantErr string // empty: created as volume
+	}{
+		{"pool directory", func(c *model.DomainConfig) {}, ""},
+		{"pool", func(c *model.DomainConfig) { c.Disks[0].SetLocation("pool:default") }, ""},
+		{"plain directory", func(c *model.DomainConfig) { c.Disks[0].SetLocation("/srv/vms") }, "not in a storage pool"},
+		{"linked clone", func(c *model.DomainConfig) { c.Disks[0].Backing = base }, "linked clones"},
+		{"attached", func(c *model.DomainConfig) { c.Disks[0].SetLocation(model.AttachPrefix + base) }, "attached disks"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			be := backend.NewFake()
+			be.Host = "kvm01"
+			be.Images[base] = &backend.FakeImage{Format: "qcow2", SizeGiB: 3}
+			cfg := testDomain("web", "/var/lib/libvirt/images")
+			tt.setup(&cfg)
+			err := CreateVM(be, cfg, "debian13", "", t.TempDir(), CreateOptions{})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !hasCall(be.Calls, "vol-create-as default") {
+					t.Errorf("no volume created: %v", be.Calls)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+			if hasCall(be.Calls, "define") {
+				t.Errorf("VM defined despite the error: %v", be.Calls)
+			}
+		})
+	}
+}
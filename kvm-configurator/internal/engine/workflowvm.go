@@ -1,9 +1,10 @@
 // engine/workflowvm.go
-// last modified: Feb 24 2026
+// last modified: Oct 16 2026
 package engine
 
 import (
 	"bufio"
+	"configurator/internal/backend"
 	"configurator/internal/config"
 	"configurator/internal/model"
 	"configurator/internal/ui"
@@ -16,24 +17,28 @@ import (
 // Workflow "New VM"
 func RunNewVMWorkflow(
 	r *bufio.Reader,
-	osList []config.VMConfig,
-	defs struct {
-		DiskPath string
-		DiskSize int
-	},
-	variantByName map[string]string,
+	be backend.Backend,
+	conf *config.FullConfig, // merged configuration (reloaded after "save as profile")
 	isoWorkDir string, // directory in which the ISOs are located
 	isoPath string, // Path to ISO directory (can be empty → cwd fallback)
 	xmlDir string, // Destination directory for the libvirt XML file
+	reload func() (*config.FullConfig, error), // merges the layers again (with --connect)
 ) error {
 	// choosing distribution
-	distro, err := ui.SelectDistro(r, osList)
+	defs := conf.Defaults
+	distro, err := ui.SelectDistro(r, conf.OSList)
 	if err != nil {
 		return fmt.Errorf("\x1b[31mOS selection failed: %w\x1b[0m", err)
 	}
+	// picked from osinfo-db instead of the OS list: fill in the defaults
+	if distro.Origin == nil {
+		if distro, err = conf.Resolve(distro); err != nil {
+			return err
+		}
+	}
 	// validating
-	variant, ok := variantByName[distro.Name]
-	if !ok {
+	variant := distro.ID
+	if variant == "" {
 		return fmt.Errorf("no varriant found for distro %q", distro.Name)
 	}
 
@@ -44,47 +49,54 @@ func RunNewVMWorkflow(
 	}
 
 	// create basic config from default values
-	cfg := model.DomainConfig{
-		Name:   distro.Name,
-		MemMiB: distro.RAM,
-		VCPU:   distro.CPU,
-		/*
-			create the *system disk*(first element).
-			The path can be a directory (later becomes <vm>-system.qcow2)
-			or a complete file name
-		*/
-		Disks: []model.DiskSpec{
-			{
-				Name:    "system",                              // name of the first disk
-				Path:    defaultDiskPath,                       // can be empty → will be filled later
-				SizeGiB: model.EffectiveDiskSize(distro, defs), // Size (if defined)
-				Bus:     "virtio",                              // Default bus type (can be changed later)
-			},
-		},
-		ISOPath:    distro.ISOPath,
-		Network:    distro.Network,
-		NestedVirt: distro.NestedVirt,
-		Graphics:   distro.Graphics,
-		Sound:      distro.Sound,
-		FileSystem: distro.FileSystem,
-		BootOrder:  distro.BootOrder,
-	}
+	cfg := model.NewDomainConfig(distro, defs)
 
 	// Optional Edit Menu for last edits
-	editor := ui.NewEditor(r, os.Stdout, &cfg, defaultDiskPath, isoWorkDir)
+	editor := ui.NewEditor(r, os.Stdout, &cfg, defaultDiskPath, isoWorkDir, NetworkSources(be), StoragePools(be))
 	editor.Run()
 	// --------------------------------
 
 	// Summary
-	ui.ShowSummary(r, &cfg, cfg.ISOPath)
+	var opts CreateOptions
+	start := ui.StartOptions{Start: conf.Advanced.StartInit, Console: conf.Advanced.StartConsole}
+summary:
+	for {
+		switch ui.ShowSummary(r, &cfg, cfg.ISOPath, &start) {
+		case ui.SummaryCancel:
+			style.Info("VM creation cancelled", cfg.Name)
+			return nil
+		case ui.SummaryDryRun:
+			opts.DryRun = true
+			break summary
+		case ui.SummaryProfile:
+			// the new entry goes to the user file; merge all layers again so it
+			// shows up in the OS list right away
+			if ui.SaveAsProfile(r, &cfg, config.ConfigFilePath(), conf) {
+				if fresh, err := reload(); err != nil {
+					style.RedError("Reload configuration", "", err)
+				} else {
+					*conf = *fresh
+				}
+			}
+		default:
+			break summary
+		}
+	}
+	opts.Start, opts.Console = start.Start, start.Console
+
+	// below the osinfo minimum the installer will most likely fail
+	if !opts.DryRun && !ui.ConfirmResources(r, &cfg) {
+		style.Info("VM creation cancelled", cfg.Name)
+		return nil
+	}
 
 	// Create VM
-	if err := CreateVM(cfg, variant, cfg.ISOPath, xmlDir); err != nil {
+	if err := CreateVM(be, cfg, variant, cfg.ISOPath, xmlDir, opts); err != nil {
 		//return fmt.Errorf("\x1b[31mVM creation failed: %w\x1b[0m", err)
 		style.RedError("VM creation failed", cfg.Name, err)
 		// WIP
 		//return ui.Fatal(ui.ErrVMCreationFail, "%w")
-	} else {
+	} else if !opts.DryRun {
 		style.Success("VM", cfg.Name, "successfully built!")
 	}
 	return nil
@@ -0,0 +1,161 @@
+// firmware/firmware.go
+// last modified: Oct 16 2026
+package firmware
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+/*
+DefaultDirs returns the locations of the QEMU firmware descriptors
+(docs/interop/firmware.json) in the order libvirt reads them; a file in a
+later directory replaces the one with the same name in an earlier one.
+*/
+func DefaultDirs() []string {
+	dirs := []string{"/usr/share/qemu/firmware", "/etc/qemu/firmware"}
+	if cfg, err := os.UserConfigDir(); err == nil {
+		dirs = append(dirs, filepath.Join(cfg, "qemu", "firmware"))
+	}
+	return dirs
+}
+
+// Descriptor is one firmware descriptor (only what we use)
+type Descriptor struct {
+	Path        string   `json:"-"`
+	Description string   `json:"description"`
+	Interfaces  []string `json:"interface-types"` // uefi, bios
+	Mapping     struct {
+		Device        string `json:"device"` // flash | memory | kernel
+		Mode          string `json:"mode"`   // split (default) | combined | stateless
+		Executable    Image  `json:"executable"`
+		NVRAMTemplate Image  `json:"nvram-template"`
+	} `json:"mapping"`
+	Targets []struct {
+		Architecture string   `json:"architecture"`
+		Machines     []string `json:"machines"` // globs, e.g. pc-q35-*
+	} `json:"targets"`
+	Features []string `json:"features"` // secure-boot, enrolled-keys, requires-smm, …
+}
+
+// Image is a firmware file
+type Image struct {
+	Filename string `json:"filename"`
+	Format   string `json:"format"` // raw | qcow2
+}
+
+// Has reports whether the descriptor lists feature
+func (d Descriptor) Has(feature string) bool { return slices.Contains(d.Features, feature) }
+
+/*
+Load reads all *.json descriptors of dirs, sorted by file name – the
+numeric prefix (50-edk2-ovmf-…) is the priority. Unreadable files are
+skipped, no descriptors at all is not an error.
+*/
+func Load(dirs ...string) []Descriptor {
+	byName := map[string]string{}
+	for _, dir := range dirs {
+		files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+		for _, f := range files {
+			byName[filepath.Base(f)] = f
+		}
+	}
+	names := make([]string, 0, len(byName))
+	for n := range byName {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	var out []Descriptor
+	for _, n := range names {
+		data, err := os.ReadFile(byName[n])
+		if err != nil || len(strings.TrimSpace(string(data))) == 0 {
+			continue // an empty file in /etc masks a shipped descriptor
+		}
+		var d Descriptor
+		if json.Unmarshal(data, &d) != nil {
+			continue
+		}
+		d.Path = byName[n]
+		out = append(out, d)
+	}
+	return out
+}
+
+/*
+FindUEFI returns the first descriptor with a UEFI image for arch and
+machine that libvirt can use with a per-VM NVRAM: pflash, split code and
+variables, raw images that exist. secure asks for Secure Boot with the
+Microsoft keys enrolled; otherwise descriptors without Secure Boot are
+preferred.
+*/
+func FindUEFI(descs []Descriptor, arch, machine string, secure bool) (Descriptor, bool) {
+	var fallback *Descriptor
+	for i, d := range descs {
+		if !usable(d, arch, machine) {
+			continue
+		}
+		sb := d.Has("secure-boot")
+		switch {
+		case secure && sb && d.Has("enrolled-keys"):
+			return d, true
+		case !secure && !sb:
+			return d, true
+		case !secure && !d.Has("enrolled-keys") && fallback == nil:
+			fallback = &descs[i] // Secure Boot capable, but not enforced without keys
+		}
+	}
+	if fallback != nil {
+		return *fallback, true
+	}
+	return Descriptor{}, false
+}
+
+func usable(d Descriptor, arch, machine string) bool {
+	m := d.Mapping
+	if !slices.Contains(d.Interfaces, "uefi") || m.Device != "flash" {
+		return false
+	}
+	if m.Mode != "" && m.Mode != "split" {
+		return false
+	}
+	for _, img := range []Image{m.Executable, m.NVRAMTemplate} {
+		if img.Filename == "" || (img.Format != "" && img.Format != "raw") {
+			return false
+		}
+		if _, err := os.Stat(img.Filename); err != nil {
+			return false
+		}
+	}
+	for _, t := range d.Targets {
+		if t.Architecture != arch {
+			continue
+		}
+		for _, pattern := range t.Machines {
+			if machineMatches(pattern, machine) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// machineMatches compares a machine glob with a machine type; the alias
+// q35 (pc for i440fx) stands for every versioned pc-q35-x.y
+func machineMatches(pattern, machine string) bool {
+	if ok, _ := path.Match(pattern, machine); ok {
+		return true
+	}
+	switch machine {
+	case "q35":
+		return strings.HasPrefix(pattern, "pc-q35-")
+	case "pc":
+		return strings.HasPrefix(pattern, "pc-i440fx-")
+	}
+	return false
+}
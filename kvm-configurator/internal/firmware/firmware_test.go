@@ -0,0 +1,126 @@
+// firmware/firmware_test.go
+// last modified: Oct 16 2026
+package firmware
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// names lists the file names of descs in their order
+func names(descs []Descriptor) []string {
+	out := make([]string, len(descs))
+	for i, d := range descs {
+		out[i] = filepath.Base(d.Path)
+	}
+	return out
+}
+
+func TestLoad(t *testing.T) {
+	descs := Load("testdata/system", "testdata/missing", "testdata/etc")
+	want := []string{
+		"10-edk2-x86_64-stateless.json",
+		"20-edk2-x86_64-missing.json",
+		"30-edk2-x86_64-qcow2.json",
+		"40-edk2-x86_64-secure-enrolled.json",
+		"45-edk2-x86_64-secure.json",
+		"50-edk2-x86_64.json",
+		// 60-edk2-aarch64.json is masked by the empty file in etc
+		"70-seabios.json",
+		// 80-broken.json does not parse
+	}
+	if got := names(descs); !slices.Equal(got, want) {
+		t.Fatalf("loaded %v, want %v", got, want)
+	}
+	d := descs[5]
+	if d.Path != filepath.Join("testdata/etc", "50-edk2-x86_64.json") || d.Description != "OVMF without Secure Boot (local copy)" {
+		t.Errorf("50-edk2-x86_64.json from %s: %q", d.Path, d.Description)
+	}
+	if d.Mapping.Executable != (Image{"testdata/ovmf/OVMF_CODE.fd", "raw"}) || !d.Has("amd-sev") || d.Has("secure-boot") {
+		t.Errorf("descriptor decoded wrong: %+v", d)
+	}
+	if len(Load("testdata/missing")) != 0 {
+		t.Error("descriptors from a missing directory")
+	}
+}
+
+func TestUsable(t *testing.T) {
+	usableOn := map[string]bool{}
+	for _, d := range Load("testdata/system") {
+		usableOn[filepath.Base(d.Path)] = usable(d, "x86_64", "pc-q35-9.0")
+	}
+	want := map[string]bool{
+		"10-edk2-x86_64-stateless.json":       false, // no per-VM NVRAM
+		"20-edk2-x86_64-missing.json":         false, // images not installed
+		"30-edk2-x86_64-qcow2.json":           false, // not raw
+		"40-edk2-x86_64-secure-enrolled.json": true,
+		"45-edk2-x86_64-secure.json":          true,
+		"50-edk2-x86_64.json":                 true,
+		"60-edk2-aarch64.json":                false, // other architecture
+		"70-seabios.json":                     false, // no UEFI
+	}
+	for name, ok := range want {
+		if usableOn[name] != ok {
+			t.Errorf("%s: usable %v, want %v", name, usableOn[name], ok)
+		}
+	}
+}
+
+func TestFindUEFI(t *testing.T) {
+	all := Load("testdata/system")
+	noPlain := slices.DeleteFunc(slices.Clone(all), func(d Descriptor) bool {
+		return filepath.Base(d.Path) == "50-edk2-x86_64.json"
+	})
+	for _, tc := range []struct {
+		name          string
+		descs         []Descriptor
+		arch, machine string
+		secure        bool
+		want          string // file name, "" = no matching firmware
+	}{
+		// the earlier Secure Boot descriptors are passed over
+		{"plain before Secure Boot", all, "x86_64", "pc-q35-9.0", false, "50-edk2-x86_64.json"},
+		{"Secure Boot with keys", all, "x86_64", "pc-q35-9.0", true, "40-edk2-x86_64-secure-enrolled.json"},
+		// without a plain one: Secure Boot capable, but no keys to enforce it
+		{"fallback without keys", noPlain, "x86_64", "pc-q35-9.0", false, "45-edk2-x86_64-secure.json"},
+		{"alias q35", all, "x86_64", "q35", true, "40-edk2-x86_64-secure-enrolled.json"},
+		{"alias pc", all, "x86_64", "pc", false, "50-edk2-x86_64.json"},
+		{"i440fx", all, "x86_64", "pc-i440fx-8.2", false, "50-edk2-x86_64.json"},
+		{"aarch64", all, "aarch64", "virt-9.0", false, "60-edk2-aarch64.json"},
+		{"no Secure Boot for i440fx", all, "x86_64", "pc-i440fx-8.2", true, ""},
+		{"no Secure Boot for aarch64", all, "aarch64", "virt-9.0", true, ""},
+		{"unknown machine", all, "x86_64", "microvm", false, ""},
+		{"unknown architecture", all, "riscv64", "virt", false, ""},
+		{"no descriptors", nil, "x86_64", "q35", false, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d, ok := FindUEFI(tc.descs, tc.arch, tc.machine, tc.secure)
+			if got := filepath.Base(d.Path); ok != (tc.want != "") || ok && got != tc.want {
+				t.Errorf("FindUEFI = %s, %v; want %q", got, ok, tc.want)
+			}
+		})
+	}
+}
+
+func TestMachineMatches(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, machine string
+		want             bool
+	}{
+		{"pc-q35-*", "pc-q35-9.0", true},
+		{"pc-q35-*", "q35", true},
+		{"pc-i440fx-*", "pc", true},
+		{"pc-i440fx-*", "pc-i440fx-8.2", true},
+		{"pc-q35-*", "pc", false},
+		{"pc-i440fx-*", "q35", false},
+		{"pc-i440fx-*", "pc-q35-9.0", false},
+		{"virt-*", "virt-9.0", true},
+		{"virt-*", "virt", false}, // no alias outside x86
+		{"pc-q35-8.*", "pc-q35-9.0", false},
+	} {
+		if got := machineMatches(tc.pattern, tc.machine); got != tc.want {
+			t.Errorf("machineMatches(%q, %q) = %v", tc.pattern, tc.machine, got)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// lab/apply.go
+// last modified: Oct 16 2026
+package lab
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	// internal
+	"configurator/internal/backend"
+	"configurator/internal/config"
+	"configurator/internal/engine"
+	"configurator/internal/model"
+	"configurator/internal/style"
+	"configurator/kvmtools"
+)
+
+// Apply executes a plan: missing domains are created via engine.CreateVM,
+// removed ones are undefined. Changes to existing domains are not applied,
+// the returned error names them.
+func Apply(be backend.Backend, plan *Plan, cfg *config.FullConfig) error {
+	st, err := loadState(cfg.XmlDir, plan.Lab)
+	if err != nil {
+		return err
+	}
+
+	var failures, changes []string
+	for _, s := range plan.Steps {
+		switch s.Kind {
+		case KindCreate:
+			if err := checkISO(s.Domain); err != nil {
+				failures = append(failures, fmt.Sprintf("%s (%v)", s.Name, err))
+				continue
+			}
+			if err := engine.CreateVM(be, s.Domain, s.Variant, s.Domain.ISOPath, cfg.XmlDir, engine.CreateOptions{}); err != nil {
+				failures = append(failures, fmt.Sprintf("%s (%v)", s.Name, err))
+				continue
+			}
+			st.add(s.Name)
+		case KindRemove:
+			if err := undefine(be, s.Name, s.Running); err != nil {
+				failures = append(failures, fmt.Sprintf("%s (%v)", s.Name, err))
+				continue
+			}
+			st.remove(s.Name)
+			style.Success("VM", s.Name, "undefined")
+		case KindChange:
+			st.add(s.Name)
+			changes = append(changes, fmt.Sprintf("%s (%s)", s.Name, strings.Join(s.Details, ", ")))
+		default:
+			st.add(s.Name) // adopt existing domains of the spec
+		}
+	}
+
+	if err := st.save(cfg.XmlDir); err != nil {
+		failures = append(failures, err.Error())
+	}
+	if len(changes) > 0 {
+		failures = append(failures, "changes to existing VMs are not applied, destroy and re-apply: "+strings.Join(changes, "; "))
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("apply incomplete: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// Destroy undefines the VMs the lab state records (stopping running ones)
+// and optionally deletes their disk images; VMs of the spec that an apply
+// did not create or adopt are left alone
+func Destroy(be backend.Backend, spec *Spec, cfg *config.FullConfig, deleteDisks bool) error {
+	vms, err := kvmtools.FetchAllVMs(be)
+	if err != nil {
+		return err
+	}
+	running := make(map[string]bool, len(vms))
+	for _, vm := range vms {
+		running[vm.Name] = vm.Stat == "running"
+	}
+	st, err := loadState(cfg.XmlDir, spec.Lab)
+	if err != nil {
+		return err
+	}
+	// the images of a remote hypervisor are not files of this machine
+	if deleteDisks && !backend.LocalURI(be.URI()) {
+		style.Info("Disk files kept", "they lie on the host of "+be.URI())
+		deleteDisks = false
+	}
+
+	var failures []string
+	for _, name := range slices.Clone(st.Domains) {
+		isRunning, ok := running[name]
+		if !ok {
+			st.remove(name)
+			continue
+		}
+		// collect disks before the domain is gone
+		var disks []string
+		if deleteDisks {
+			disks = kvmtools.VMDiskFiles(be, name, xmlDirOrCwd(cfg.XmlDir))
+		}
+		if err := undefine(be, name, isRunning); err != nil {
+			failures = append(failures, fmt.Sprintf("%s (%v)", name, err))
+			continue
+		}
+		st.remove(name)
+		style.Success("VM", name, "undefined")
+
+		// block devices and base images of other VMs' overlays stay
+		if len(disks) > 0 {
+			if disks, err = kvmtools.DeletableDisks(be, disks); err != nil {
+				failures = append(failures, fmt.Sprintf("%s (%v)", name, err))
+				continue
+			}
+		}
+		for _, p := range disks {
+			if err := os.Remove(p); err != nil {
+				failures = append(failures, fmt.Sprintf("%s (%v)", p, err))
+			} else {
+				fmt.Printf("%s deleted.\n", p)
+			}
+		}
+	}
+
+	if err := st.save(cfg.XmlDir); err != nil {
+		failures = append(failures, err.Error())
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("destroy incomplete: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// undefine stops a running domain first – a lab is disposable
+func undefine(be backend.Backend, name string, running bool) error {
+	if running {
+		if err := be.Destroy(name); err != nil {
+			return err
+		}
+	}
+	return be.Undefine(name)
+}
+
+// checkISO fails early instead of letting virt-install fail;
+// an imported VM boots from its disk and needs no ISO
+func checkISO(dom model.DomainConfig) error {
+	path := dom.ISOPath
+	if strings.TrimSpace(path) == "" {
+		if dom.Imported() {
+			return nil
+		}
+		return fmt.Errorf("no ISO given – set iso or import in the spec, or isopath in the profile")
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("ISO %q not usable: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+// lab/lab_test.go
+// last modified: Oct 16 2026
+package lab
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	// internal
+	"configurator/internal/backend"
+	"configurator/internal/config"
+	"configurator/internal/model"
+)
+
+// testLab is a lab of two VMs from one profile; ISO, disks and saved
+// XMLs live in temporary directories
+func testLab(t *testing.T) (*Spec, *config.FullConfig) {
+	t.Helper()
+	iso := filepath.Join(t.TempDir(), "debian-13.iso")
+	if err := os.WriteFile(iso, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.FullConfig{
+		XmlDir: t.TempDir(),
+		OSList: []config.VMConfig{{
+			Name: "Debian 13", ID: "debian13", CPU: 2, RAM: 2048, DiskSize: 10,
+			DiskPath: t.TempDir(), ISOPath: iso, Network: "network=default", Graphics: "none",
+		}},
+	}
+	spec := &Spec{Lab: "demo", VMs: []VMSpec{
+		{Profile: "debian13", Overrides: model.Overrides{Name: "web"}},
+		{Profile: "debian13", Overrides: model.Overrides{Name: "db", MemMiB: 4096},
+			Disks: []DiskSpec{{Name: "system", Size: 20}, {Name: "data", Size: 50}}},
+	}}
+	return spec, cfg
+}
+
+// kinds maps VM name → planned step
+func kinds(p *Plan) map[string]Kind {
+	m := make(map[string]Kind, len(p.Steps))
+	for _, s := range p.Steps {
+		m[s.Name] = s.Kind
+	}
+	return m
+}
+
+func TestComputeApply(t *testing.T) {
+	be := backend.NewFake()
+	spec, cfg := testLab(t)
+
+	plan, err := Compute(be, spec, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := kinds(plan); got["web"] != KindCreate || got["db"] != KindCreate || len(got) != 2 {
+		t.Fatalf("first plan = %v, want two creates", got)
+	}
+	if err := Apply(be, plan, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if d := be.Domains["db"]; d == nil || d.MemoryMiB != 4096 || len(d.Disks) != 2 {
+		t.Fatalf("db defined as %+v", d)
+	}
+	st, err := loadState(cfg.XmlDir, "demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := slices.Sorted(slices.Values(st.Domains)); !slices.Equal(got, []string{"db", "web"}) {
+		t.Errorf("state after apply = %v", got)
+	}
+
+	// applied: nothing left to do
+	plan, err = Compute(be, spec, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Pending() != 0 {
+		t.Errorf("plan after apply = %v, want unchanged", kinds(plan))
+	}
+
+	// web grows, db leaves the spec
+	spec.VMs = spec.VMs[:1]
+	spec.VMs[0].MemMiB = 8192
+	plan, err = Compute(be, spec, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := kinds(plan); got["web"] != KindChange || got["db"] != KindRemove {
+		t.Fatalf("plan after edit = %v, want web change, db remove", got)
+	}
+	// the change is not applied, and apply says so
+	err = Apply(be, plan, cfg)
+	if err == nil || !strings.Contains(err.Error(), "web (ram: 2048 → 8192 MiB)") {
+		t.Errorf("apply with a pending change: %v", err)
+	}
+	if _, ok := be.Domains["db"]; ok {
+		t.Error("db still defined")
+	}
+	if d := be.Domains["web"]; d == nil || d.MemoryMiB != 2048 {
+		t.Errorf("web changed by apply: %+v", d)
+	}
+	if st, _ = loadState(cfg.XmlDir, "demo"); !slices.Equal(st.Domains, []string{"web"}) {
+		t.Errorf("state after removal = %v", st.Domains)
+	}
+}
+
+func TestApplyReportsFailures(t *testing.T) {
+	be := backend.NewFake()
+	spec, cfg := testLab(t)
+	spec.VMs[0].ISOPath = "/nonexistent/missing.iso"
+
+	plan, err := Compute(be, spec, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Apply(be, plan, cfg); err == nil {
+		t.Fatal("apply without ISO succeeded")
+	}
+	if _, ok := be.Domains["web"]; ok {
+		t.Error("web defined without ISO")
+	}
+	// the other VM is created anyway and remembered
+	st, _ := loadState(cfg.XmlDir, "demo")
+	if !slices.Equal(st.Domains, []string{"db"}) {
+		t.Errorf("state = %v, want [db]", st.Domains)
+	}
+}
+
+func TestDestroyKeepsAttachedDisks(t *testing.T) {
+	be := backend.NewFake()
+	spec, cfg := testLab(t)
+	dir := cfg.OSList[0].DiskPath
+	shared := filepath.Join(t.TempDir(), "shared.qcow2")
+	be.Images[shared] = &backend.FakeImage{Format: "qcow2", SizeGiB: 50}
+	spec.VMs = spec.VMs[1:]
+	spec.VMs[0].Disks[1].Path = model.AttachPrefix + shared
+
+	plan, err := Compute(be, spec, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Apply(be, plan, cfg); err != nil {
+		t.Fatal(err)
+	}
+	// the fake creates no files – the images are made here
+	system := filepath.Join(dir, "db-system.qcow2")
+	for _, p := range []string{system, shared} {
+		if err := os.WriteFile(p, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := Destroy(be, spec, cfg, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(system); !os.IsNotExist(err) {
+		t.Errorf("system disk %s not deleted (%v)", system, err)
+	}
+	if _, err := os.Stat(shared); err != nil {
+		t.Errorf("attached disk deleted: %v", err)
+	}
+}
+
+func TestDestroyOnlyStateDomains(t *testing.T) {
+	be := backend.NewFake()
+	spec, cfg := testLab(t)
+	// db is in the spec, but no apply of the lab created it
+	be.Domains["db"] = &backend.FakeDomain{Name: "db", State: "running"}
+	only := &Spec{Lab: spec.Lab, VMs: spec.VMs[:1]}
+	plan, err := Compute(be, only, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Apply(be, plan, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Destroy(be, spec, cfg, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := be.Domains["web"]; ok {
+		t.Error("web still defined")
+	}
+	if d := be.Domains["db"]; d == nil || d.State != "running" {
+		t.Errorf("db not created by the lab, but destroyed: %+v", d)
+	}
+	if st, _ := loadState(cfg.XmlDir, "demo"); len(st.Domains) != 0 {
+		t.Errorf("state after destroy = %v", st.Domains)
+	}
+}
+
+func TestPoolDisksSettle(t *testing.T) {
+	be := backend.NewFake()
+	spec, cfg := testLab(t)
+	spec.VMs = spec.VMs[1:]
+	for i := range spec.VMs[0].Disks {
+		spec.VMs[0].Disks[i].Path = model.PoolPrefix + "default"
+	}
+
+	plan, err := Compute(be, spec, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Apply(be, plan, cfg); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"/var/lib/libvirt/images/db-system.qcow2", "/var/lib/libvirt/images/db-data.qcow2"}
+	if d := be.Domains["db"]; d == nil || !slices.Equal(d.Disks, want) {
+		t.Fatalf("db defined as %+v, want disks %v", d, want)
+	}
+
+	plan, err = Compute(be, spec, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Pending() != 0 {
+		t.Errorf("plan after apply = %+v, want unchanged", plan.Steps)
+	}
+	if s := plan.Steps[0]; len(s.Details) != 0 {
+		t.Errorf("db not compared: %v", s.Details)
+	}
+}
@@ -0,0 +1,204 @@
+// lab/plan.go
+// last modified: Oct 16 2026
+package lab
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"text/tabwriter"
+
+	// internal
+	"configurator/internal/backend"
+	"configurator/internal/config"
+	"configurator/internal/domxml"
+	"configurator/internal/engine"
+	"configurator/internal/model"
+	"configurator/internal/style"
+	"configurator/kvmtools"
+)
+
+// Kind of a planned step
+type Kind string
+
+const (
+	KindCreate    Kind = "create"
+	KindChange    Kind = "change"
+	KindRemove    Kind = "remove"
+	KindUnchanged Kind = "unchanged"
+)
+
+// Step is one planned action for a single domain
+type Step struct {
+	Kind    Kind
+	Name    string
+	Details []string // human readable differences / notes
+	Running bool     // domain is currently running (remove/destroy)
+
+	// only set for KindCreate
+	Domain  model.DomainConfig
+	Variant string
+}
+
+// Plan is the difference between a lab spec and the host
+type Plan struct {
+	Lab   string
+	Steps []Step
+}
+
+// Compute compares the spec with `virsh list --all`, the saved XMLs in
+// cfg.XmlDir and the lab state of the last apply
+func Compute(be backend.Backend, spec *Spec, cfg *config.FullConfig) (*Plan, error) {
+	vms, err := kvmtools.FetchAllVMs(be)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]*kvmtools.VMInfo, len(vms))
+	for _, vm := range vms {
+		existing[vm.Name] = vm
+	}
+	st, err := loadState(cfg.XmlDir, spec.Lab)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{Lab: spec.Lab}
+	wanted := make(map[string]bool, len(spec.VMs))
+	for _, v := range spec.VMs {
+		wanted[v.Name] = true
+		dom, variant, err := v.Domain(cfg)
+		if err != nil {
+			return nil, err
+		}
+		vm, ok := existing[v.Name]
+		if !ok {
+			plan.Steps = append(plan.Steps, Step{
+				Kind: KindCreate, Name: v.Name, Domain: dom, Variant: variant,
+				Details: []string{fmt.Sprintf("profile %s, %d MiB, %d vCPU, %d disk(s)",
+					v.Profile, dom.MemMiB, dom.VCPU, len(dom.Disks))},
+			})
+			continue
+		}
+		diffs, err := diffSaved(be, dom, cfg.XmlDir)
+		step := Step{Kind: KindUnchanged, Name: v.Name, Running: vm.Stat == "running"}
+		switch {
+		case err != nil:
+			step.Details = []string{"cannot compare: " + err.Error()}
+		case len(diffs) > 0:
+			step.Kind = KindChange
+			step.Details = diffs
+		}
+		plan.Steps = append(plan.Steps, step)
+	}
+
+	// domains of an earlier apply that are no longer in the spec
+	for _, name := range st.Domains {
+		if wanted[name] {
+			continue
+		}
+		if vm, ok := existing[name]; ok {
+			plan.Steps = append(plan.Steps, Step{
+				Kind: KindRemove, Name: name, Running: vm.Stat == "running",
+				Details: []string{"no longer in spec (disks are kept)"},
+			})
+		}
+	}
+	return plan, nil
+}
+
+// diffSaved compares the wanted config with the saved XML definition;
+// pool disks are compared by the path of their volume
+func diffSaved(be backend.Backend, dom model.DomainConfig, xmlDir string) ([]string, error) {
+	xmlPath := filepath.Join(xmlDirOrCwd(xmlDir), dom.Name+".xml")
+	if _, err := os.Stat(xmlPath); err != nil {
+		return nil, fmt.Errorf("no saved XML (%w)", err)
+	}
+	saved, err := domxml.ParseFile(xmlPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []string
+	if mem := saved.Memory.MiB(); mem != int64(dom.MemMiB) {
+		diffs = append(diffs, fmt.Sprintf("ram: %d → %d MiB", mem, dom.MemMiB))
+	}
+	if saved.VCPU.Value != dom.VCPU {
+		diffs = append(diffs, fmt.Sprintf("vcpus: %d → %d", saved.VCPU.Value, dom.VCPU))
+	}
+
+	if err := engine.ResolvePools(be, &dom); err != nil {
+		return nil, err
+	}
+	have := saved.DiskPaths()
+	var want []string
+	for _, d := range dom.Disks {
+		want = append(want, model.DiskImagePath(d, dom.Name))
+	}
+	for _, p := range want {
+		if !slices.Contains(have, p) {
+			diffs = append(diffs, "add disk "+p)
+		}
+	}
+	for _, p := range have {
+		if !slices.Contains(want, p) {
+			diffs = append(diffs, "remove disk "+p)
+		}
+	}
+	return diffs, nil
+}
+
+// Pending counts the steps that would modify the host
+func (p *Plan) Pending() int {
+	n := 0
+	for _, s := range p.Steps {
+		if s.Kind != KindUnchanged {
+			n++
+		}
+	}
+	return n
+}
+
+// Print renders the plan as a table
+func (p *Plan) Print(w io.Writer) {
+	steps := append([]Step(nil), p.Steps...)
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].Name < steps[j].Name })
+
+	fmt.Fprintln(w, style.BoxCenter(70, []string{"PLAN FOR LAB " + p.Lab}))
+	lines := style.MustTableToLines(func(tw *tabwriter.Writer) {
+		fmt.Fprintln(tw, "Action\tVM\tDetails")
+		for _, s := range steps {
+			details := ""
+			if len(s.Details) > 0 {
+				details = s.Details[0]
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", kindLabel(s.Kind), s.Name, details)
+			for _, d := range s.Details[min(1, len(s.Details)):] {
+				fmt.Fprintf(tw, "\t\t%s\n", d)
+			}
+		}
+	})
+	fmt.Fprint(w, style.Box(70, lines))
+
+	counts := map[Kind]int{}
+	for _, s := range p.Steps {
+		counts[s.Kind]++
+	}
+	fmt.Fprintf(w, "\nPlan: %d to create, %d to change, %d to remove, %d unchanged.\n",
+		counts[KindCreate], counts[KindChange], counts[KindRemove], counts[KindUnchanged])
+}
+
+// kindLabel prefixes the action like a diff (+ create, ~ change, - remove)
+func kindLabel(k Kind) string {
+	switch k {
+	case KindCreate:
+		return "+ create"
+	case KindChange:
+		return "~ change"
+	case KindRemove:
+		return "- remove"
+	}
+	return "  unchanged"
+}
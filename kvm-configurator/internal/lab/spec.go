@@ -0,0 +1,133 @@
+// lab/spec.go
+// last modified: Oct 16 2026
+package lab
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	// internal
+	"configurator/internal/config"
+	"configurator/internal/model"
+	"configurator/internal/utils"
+	// external
+	"gopkg.in/yaml.v3"
+)
+
+// Spec describes a whole lab: several VMs that are planned, applied
+// and destroyed together
+type Spec struct {
+	Lab string   `yaml:"lab"` // lab name, used for the state file
+	VMs []VMSpec `yaml:"vms"`
+}
+
+// VMSpec is one VM of the lab: a profile from oslist.yaml plus overrides
+type VMSpec struct {
+	Profile         string `yaml:"profile"` // id or name of the OS profile
+	model.Overrides `yaml:",inline"`
+	Disks           []DiskSpec `yaml:"disks"` // replaces the profile system disk if set
+}
+
+// DiskSpec is a disk entry of a lab VM
+type DiskSpec struct {
+	Name    string `yaml:"name"`
+	Path    string `yaml:"path"`    // directory, image file, pool:<name> or attach:<existing image|device>
+	Size    int    `yaml:"size"`    // GiB
+	Bus     string `yaml:"bus"`     // default virtio
+	Backing string `yaml:"backing"` // base image – the disk becomes a linked clone of it
+}
+
+// Load reads and checks a lab spec; unknown keys are rejected
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read lab spec %q: %w", path, err)
+	}
+	var spec Spec
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&spec); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse lab spec %q: %w", path, err)
+	}
+	utils.ExpandEnvInStruct(&spec)
+
+	if err := spec.check(); err != nil {
+		return nil, fmt.Errorf("lab spec %q: %w", path, err)
+	}
+	return &spec, nil
+}
+
+// check makes sure every VM is named, unique and has a profile
+func (s *Spec) check() error {
+	if strings.TrimSpace(s.Lab) == "" {
+		return fmt.Errorf("missing lab name (key 'lab')")
+	}
+	if len(s.VMs) == 0 {
+		return fmt.Errorf("no VMs defined (key 'vms')")
+	}
+	seen := make(map[string]bool, len(s.VMs))
+	for i, vm := range s.VMs {
+		if vm.Name == "" {
+			return fmt.Errorf("vms[%d]: missing name", i)
+		}
+		if vm.Profile == "" {
+			return fmt.Errorf("vm %q: missing profile", vm.Name)
+		}
+		if seen[vm.Name] {
+			return fmt.Errorf("vm %q: defined twice", vm.Name)
+		}
+		seen[vm.Name] = true
+
+		disks := make(map[string]bool, len(vm.Disks))
+		for j, d := range vm.Disks {
+			if d.Name == "" {
+				return fmt.Errorf("vm %q: disks[%d]: missing name", vm.Name, j)
+			}
+			if disks[d.Name] {
+				return fmt.Errorf("vm %q: disk %q defined twice", vm.Name, d.Name)
+			}
+			disks[d.Name] = true
+		}
+	}
+	return nil
+}
+
+// Domain builds the DomainConfig for a lab VM and returns the os-variant
+func (v VMSpec) Domain(cfg *config.FullConfig) (model.DomainConfig, string, error) {
+	distro, err := config.FindProfile(cfg.OSList, v.Profile)
+	if err != nil {
+		return model.DomainConfig{}, "", fmt.Errorf("vm %q: %w", v.Name, err)
+	}
+	dom := model.NewDomainConfig(distro, cfg.Defaults)
+
+	o := v.Overrides
+	o.ISOPath = utils.ResolveInDir(o.ISOPath, cfg.IsoPath)
+	if err := dom.ApplyOverrides(o); err != nil {
+		return dom, "", fmt.Errorf("vm %q: %w", v.Name, err)
+	}
+
+	if len(v.Disks) > 0 {
+		defPath := model.EffectiveDiskPath(distro, cfg.Defaults)
+		dom.Disks = dom.Disks[:0]
+		for _, d := range v.Disks {
+			ds := model.DiskSpec{Name: d.Name, SizeGiB: d.Size, Bus: d.Bus}
+			if d.Path != "" {
+				ds.SetLocation(d.Path)
+			} else {
+				ds.SetLocation(defPath)
+			}
+			if ds.Bus == "" {
+				ds.Bus = "virtio"
+			}
+			if d.Backing != "" && !ds.Existing {
+				ds.Backing = os.ExpandEnv(d.Backing)
+			}
+			dom.Disks = append(dom.Disks, ds)
+		}
+	}
+	return dom, distro.ID, nil
+}
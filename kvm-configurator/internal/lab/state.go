@@ -0,0 +1,81 @@
+// lab/state.go
+// last modified: Oct 16 2026
+package lab
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	// external
+	"gopkg.in/yaml.v3"
+)
+
+// state remembers which domains an apply created for a lab, so a later
+// plan can tell which ones were removed from the spec
+type state struct {
+	Lab     string   `yaml:"lab"`
+	Domains []string `yaml:"domains"`
+}
+
+// xmlDirOrCwd mirrors the fallback of engine.CreateVM
+func xmlDirOrCwd(xmlDir string) string {
+	if xmlDir == "" {
+		return "."
+	}
+	return xmlDir
+}
+
+// statePath – <xmlDir>/<lab>.lab-state.yaml, next to the saved XMLs
+func statePath(xmlDir, lab string) string {
+	return filepath.Join(xmlDirOrCwd(xmlDir), lab+".lab-state.yaml")
+}
+
+// loadState returns an empty state if the lab was never applied
+func loadState(xmlDir, lab string) (*state, error) {
+	st := &state{Lab: lab}
+	data, err := os.ReadFile(statePath(xmlDir, lab))
+	if errors.Is(err, fs.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read lab state: %w", err)
+	}
+	if err := yaml.Unmarshal(data, st); err != nil {
+		return nil, fmt.Errorf("parse lab state: %w", err)
+	}
+	return st, nil
+}
+
+// save writes the state; an empty state removes the file
+func (s *state) save(xmlDir string) error {
+	path := statePath(xmlDir, s.Lab)
+	if len(s.Domains) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("remove lab state: %w", err)
+		}
+		return nil
+	}
+	slices.Sort(s.Domains)
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("write lab state: %w", err)
+	}
+	return nil
+}
+
+func (s *state) add(name string) {
+	if !slices.Contains(s.Domains, name) {
+		s.Domains = append(s.Domains, name)
+	}
+}
+
+func (s *state) remove(name string) {
+	s.Domains = slices.DeleteFunc(s.Domains, func(d string) bool { return d == name })
+}
@@ -0,0 +1,466 @@
+// libvirt/client.go
+// last modified: Oct 16 2026
+package libvirt
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// default socket locations (modular daemon first, then monolithic libvirtd)
+var DefaultSockets = []string{
+	"/var/run/libvirt/virtqemud-sock",
+	"/var/run/libvirt/libvirt-sock",
+}
+
+// DefaultURI is opened when the caller does not name a connection
+const DefaultURI = "qemu:///system"
+
+/*
+Conn is a client for the libvirt remote protocol over a Unix socket.
+Calls are serialised – one request in flight at a time is plenty for a CLI.
+*/
+type Conn struct {
+	mu     sync.Mutex
+	c      net.Conn
+	serial uint32
+}
+
+// SocketsFor returns the local sockets that serve uri; qemu+unix:///system
+// is the explicit spelling of qemu:///system, ?socket= names the socket.
+// Remote URIs (qemu+ssh://, qemu+tcp://, …) cannot be reached over a local socket.
+func SocketsFor(uri string) ([]string, error) {
+	if uri == "" {
+		return DefaultSockets, nil
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid connection URI %q: %w", uri, err)
+	}
+	if _, transport, _ := strings.Cut(u.Scheme, "+"); (transport != "" && transport != "unix") || u.Host != "" {
+		return nil, fmt.Errorf("connection URI %q is remote – use the shell backend", uri)
+	}
+	if socket := u.Query().Get("socket"); socket != "" {
+		return []string{socket}, nil
+	}
+	if u.Path != "/session" {
+		return DefaultSockets, nil
+	}
+	// per-user daemon
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		return nil, fmt.Errorf("XDG_RUNTIME_DIR is not set – cannot locate the session socket")
+	}
+	return []string{
+		filepath.Join(dir, "libvirt", "virtqemud-sock"),
+		filepath.Join(dir, "libvirt", "libvirt-sock"),
+	}, nil
+}
+
+// Dial connects to the first reachable socket of the list
+func Dial(candidates ...string) (*Conn, error) {
+	var lastErr error
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err != nil {
+			lastErr = err
+			continue
+		}
+		c, err := net.DialTimeout("unix", path, 5*time.Second)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return &Conn{c: c}, nil
+	}
+	return nil, fmt.Errorf("no libvirt socket reachable: %w", lastErr)
+}
+
+// NewConn wraps an existing connection (e.g. one end of net.Pipe)
+func NewConn(c net.Conn) *Conn { return &Conn{c: c} }
+
+// Connect dials, authenticates and opens the URI in one go;
+// an empty socket picks the default location for uri
+func Connect(socket, uri string) (*Conn, error) {
+	candidates := []string{socket}
+	if socket == "" {
+		var err error
+		if candidates, err = SocketsFor(uri); err != nil {
+			return nil, err
+		}
+	}
+	c, err := Dial(candidates...)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.auth(); err != nil {
+		c.c.Close()
+		return nil, err
+	}
+	if err := c.ConnectOpen(uri); err != nil {
+		c.c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// call sends one request and waits for the matching reply
+func (c *Conn) call(proc int32, args []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.serial++
+	h := header{
+		Program:   program,
+		Version:   programVersion,
+		Procedure: proc,
+		Type:      typeCall,
+		Serial:    c.serial,
+		Status:    statusOK,
+	}
+	if err := writePacket(c.c, h, args); err != nil {
+		return nil, fmt.Errorf("libvirt: send procedure %d: %w", proc, err)
+	}
+
+	for {
+		rh, payload, err := readPacket(c.c)
+		if err != nil {
+			return nil, fmt.Errorf("libvirt: read reply to procedure %d: %w", proc, err)
+		}
+		// events or stale replies – not ours
+		if rh.Program != program || rh.Type != typeReply || rh.Serial != h.Serial {
+			continue
+		}
+		if rh.Procedure != proc {
+			return nil, fmt.Errorf("libvirt: reply for procedure %d, expected %d", rh.Procedure, proc)
+		}
+		if rh.Status == statusError {
+			return nil, decodeError(NewDecoder(payload))
+		}
+		return payload, nil
+	}
+}
+
+// auth – only "none" and polkit are supported (SASL needs a full library)
+func (c *Conn) auth() error {
+	types, err := c.AuthList()
+	if err != nil {
+		return err
+	}
+	if len(types) == 0 {
+		return nil
+	}
+	for _, t := range types {
+		switch t {
+		case AuthNone:
+			return nil
+		case AuthPolkit:
+			return c.AuthPolkit()
+		}
+	}
+	return fmt.Errorf("libvirt: unsupported authentication %v (use the shell backend)", types)
+}
+
+// AuthList returns the authentication types the server expects
+func (c *Conn) AuthList() ([]int32, error) {
+	out, err := c.call(ProcAuthList, nil)
+	if err != nil {
+		return nil, err
+	}
+	d := NewDecoder(out)
+	n := d.Uint32()
+	if n > 64 {
+		return nil, fmt.Errorf("libvirt: bogus auth list length %d", n)
+	}
+	types := make([]int32, n)
+	for i := range types {
+		types[i] = d.Int32()
+	}
+	return types, d.Err()
+}
+
+// AuthPolkit asks polkit to authorise this client
+func (c *Conn) AuthPolkit() error {
+	_, err := c.call(ProcAuthPolkit, nil)
+	return err
+}
+
+// ConnectOpen opens a hypervisor connection (empty uri = DefaultURI)
+func (c *Conn) ConnectOpen(uri string) error {
+	if uri == "" {
+		uri = DefaultURI
+	}
+	var e Encoder
+	e.OptString(uri)
+	e.Uint32(0) // flags
+	_, err := c.call(ProcConnectOpen, e.Bytes())
+	return err
+}
+
+// Close says goodbye to the daemon and closes the socket
+func (c *Conn) Close() error {
+	_, callErr := c.call(ProcConnectClose, nil)
+	err := c.c.Close()
+	return errors.Join(callErr, err)
+}
+
+// ListAllDomains returns active and inactive domains
+func (c *Conn) ListAllDomains() ([]Domain, error) {
+	var e Encoder
+	e.Int32(1)  // need_results
+	e.Uint32(0) // flags: all
+	out, err := c.call(ProcConnectListAllDomains, e.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	d := NewDecoder(out)
+	n := d.Uint32()
+	if n > 65536 {
+		return nil, fmt.Errorf("libvirt: bogus domain count %d", n)
+	}
+	doms := make([]Domain, 0, n)
+	for range n {
+		doms = append(doms, d.Domain())
+	}
+	d.Uint32() // ret
+	return doms, d.Err()
+}
+
+// ListAllNetworks returns the virtual networks selected by flags
+// (ListNetworksActive, ListNetworksInactive; 0 = all)
+func (c *Conn) ListAllNetworks(flags uint32) ([]Network, error) {
+	var e Encoder
+	e.Int32(1) // need_results
+	e.Uint32(flags)
+	out, err := c.call(ProcConnectListAllNetworks, e.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	d := NewDecoder(out)
+	n := d.Uint32()
+	if n > 65536 {
+		return nil, fmt.Errorf("libvirt: bogus network count %d", n)
+	}
+	nets := make([]Network, 0, n)
+	for range n {
+		nets = append(nets, d.Network())
+	}
+	d.Uint32() // ret
+	return nets, d.Err()
+}
+
+// LookupByName resolves a domain name
+func (c *Conn) LookupByName(name string) (Domain, error) {
+	var e Encoder
+	e.String(name)
+	out, err := c.call(ProcDomainLookupByName, e.Bytes())
+	if err != nil {
+		return Domain{}, err
+	}
+	d := NewDecoder(out)
+	dom := d.Domain()
+	return dom, d.Err()
+}
+
+// GetInfo returns state, memory and vCPUs of a domain
+func (c *Conn) GetInfo(dom Domain) (DomainInfo, error) {
+	var e Encoder
+	e.Domain(dom)
+	out, err := c.call(ProcDomainGetInfo, e.Bytes())
+	if err != nil {
+		return DomainInfo{}, err
+	}
+	d := NewDecoder(out)
+	info := DomainInfo{
+		State:     uint8(d.Uint32()), // XDR has no 8/16 bit types
+		MaxMemKiB: d.Uint64(),
+		MemoryKiB: d.Uint64(),
+		VCPUs:     uint16(d.Uint32()),
+		CPUTime:   d.Uint64(),
+	}
+	return info, d.Err()
+}
+
+// GetXMLDesc returns the domain XML
+func (c *Conn) GetXMLDesc(dom Domain, flags uint32) ([]byte, error) {
+	var e Encoder
+	e.Domain(dom)
+	e.Uint32(flags)
+	out, err := c.call(ProcDomainGetXMLDesc, e.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	d := NewDecoder(out)
+	s := d.String()
+	return []byte(s), d.Err()
+}
+
+// DefineXML defines (or redefines) a persistent domain
+func (c *Conn) DefineXML(xml []byte) (Domain, error) {
+	var e Encoder
+	e.String(string(xml))
+	out, err := c.call(ProcDomainDefineXML, e.Bytes())
+	if err != nil {
+		return Domain{}, err
+	}
+	d := NewDecoder(out)
+	dom := d.Domain()
+	return dom, d.Err()
+}
+
+// domainCall is the common shape "nonnull_domain [+ flags] → void"
+func (c *Conn) domainCall(proc int32, dom Domain, flags ...uint32) error {
+	var e Encoder
+	e.Domain(dom)
+	for _, f := range flags {
+		e.Uint32(f)
+	}
+	_, err := c.call(proc, e.Bytes())
+	return err
+}
+
+func (c *Conn) Create(dom Domain) error   { return c.domainCall(ProcDomainCreate, dom) }
+func (c *Conn) Shutdown(dom Domain) error { return c.domainCall(ProcDomainShutdown, dom) }
+func (c *Conn) Destroy(dom Domain) error  { return c.domainCall(ProcDomainDestroy, dom) }
+func (c *Conn) Reboot(dom Domain) error   { return c.domainCall(ProcDomainReboot, dom, 0) }
+
+// UndefineFlags removes a persistent definition (flags: VIR_DOMAIN_UNDEFINE_*)
+func (c *Conn) UndefineFlags(dom Domain, flags uint32) error {
+	return c.domainCall(ProcDomainUndefineFlags, dom, flags)
+}
+
+// Rename gives an inactive domain a new name
+func (c *Conn) Rename(dom Domain, newName string) error {
+	var e Encoder
+	e.Domain(dom)
+	e.OptString(newName)
+	e.Uint32(0)
+	out, err := c.call(ProcDomainRename, e.Bytes())
+	if err != nil {
+		return err
+	}
+	d := NewDecoder(out)
+	if ret := d.Int32(); d.Err() == nil && ret != 0 {
+		return fmt.Errorf("libvirt: rename %s failed (%d)", dom.Name, ret)
+	}
+	return d.Err()
+}
+
+// GetAutostart reports whether the domain starts with the host
+func (c *Conn) GetAutostart(dom Domain) (bool, error) {
+	var e Encoder
+	e.Domain(dom)
+	out, err := c.call(ProcDomainGetAutostart, e.Bytes())
+	if err != nil {
+		return false, err
+	}
+	d := NewDecoder(out)
+	v := d.Int32()
+	return v != 0, d.Err()
+}
+
+// SetAutostart enables or disables autostart
+func (c *Conn) SetAutostart(dom Domain, on bool) error {
+	var e Encoder
+	e.Domain(dom)
+	e.Bool(on)
+	_, err := c.call(ProcDomainSetAutostart, e.Bytes())
+	return err
+}
+
+// NetworkLookupByName resolves a virtual network
+func (c *Conn) NetworkLookupByName(name string) (Network, error) {
+	var e Encoder
+	e.String(name)
+	out, err := c.call(ProcNetworkLookupByName, e.Bytes())
+	if err != nil {
+		return Network{}, err
+	}
+	d := NewDecoder(out)
+	n := d.Network()
+	return n, d.Err()
+}
+
+// NetworkDefineXML defines (or redefines) a persistent network
+func (c *Conn) NetworkDefineXML(xml []byte) (Network, error) {
+	var e Encoder
+	e.String(string(xml))
+	out, err := c.call(ProcNetworkDefineXML, e.Bytes())
+	if err != nil {
+		return Network{}, err
+	}
+	d := NewDecoder(out)
+	n := d.Network()
+	return n, d.Err()
+}
+
+// NetworkGetXMLDesc returns the network XML
+func (c *Conn) NetworkGetXMLDesc(n Network, flags uint32) ([]byte, error) {
+	var e Encoder
+	e.Network(n)
+	e.Uint32(flags)
+	out, err := c.call(ProcNetworkGetXMLDesc, e.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	d := NewDecoder(out)
+	s := d.String()
+	return []byte(s), d.Err()
+}
+
+// networkCall is the common shape "nonnull_network → void"
+func (c *Conn) networkCall(proc int32, n Network) error {
+	var e Encoder
+	e.Network(n)
+	_, err := c.call(proc, e.Bytes())
+	return err
+}
+
+func (c *Conn) NetworkCreate(n Network) error   { return c.networkCall(ProcNetworkCreate, n) }
+func (c *Conn) NetworkDestroy(n Network) error  { return c.networkCall(ProcNetworkDestroy, n) }
+func (c *Conn) NetworkUndefine(n Network) error { return c.networkCall(ProcNetworkUndefine, n) }
+
+// NetworkSetAutostart enables or disables autostart of a network
+func (c *Conn) NetworkSetAutostart(n Network, on bool) error {
+	var e Encoder
+	e.Network(n)
+	e.Bool(on)
+	_, err := c.call(ProcNetworkSetAutostart, e.Bytes())
+	return err
+}
+
+// IsNotFound reports whether err is libvirt's "no domain (network, pool,
+// volume) with matching name/uuid"
+func IsNotFound(err error) bool {
+	var le *Error
+	if !errors.As(err, &le) {
+		return false
+	}
+	switch le.Code {
+	case ErrNoDomain, ErrNoNetwork, ErrNoStoragePool, ErrNoStorageVol:
+		return true
+	}
+	return false
+}
+
+// IsUnsupportedFlags reports whether err is a daemon rejecting a flag it
+// does not know yet ("unsupported flags (0x20)")
+func IsUnsupportedFlags(err error) bool {
+	var le *Error
+	if !errors.As(err, &le) {
+		return false
+	}
+	switch le.Code {
+	case ErrNoSupport:
+		return true
+	case ErrInvalidArg:
+		return strings.Contains(le.Message, "unsupported flags")
+	}
+	return false
+}
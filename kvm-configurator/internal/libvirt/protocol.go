@@ -0,0 +1,267 @@
+// libvirt/protocol.go
+// last modified: Oct 16 2026
+package libvirt
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// remote protocol constants (libvirt src/remote/remote_protocol.x)
+const (
+	program        = 0x20008086
+	programVersion = 1
+
+	headerSize = 24       // program, version, procedure, type, serial, status
+	maxMessage = 32 << 20 // upper bound for a single packet
+)
+
+// message types
+const (
+	typeCall  = 0
+	typeReply = 1
+)
+
+// reply status
+const (
+	statusOK    = 0
+	statusError = 1
+)
+
+// Procedure numbers used by this package
+const (
+	ProcConnectOpen                = 1
+	ProcConnectClose               = 2
+	ProcDomainCreate               = 9
+	ProcDomainDefineXML            = 11
+	ProcDomainDestroy              = 12
+	ProcDomainGetXMLDesc           = 14
+	ProcDomainGetAutostart         = 15
+	ProcDomainGetInfo              = 16
+	ProcDomainLookupByName         = 23
+	ProcDomainReboot               = 27
+	ProcDomainSetAutostart         = 29
+	ProcDomainShutdown             = 33
+	ProcNetworkCreate              = 39
+	ProcNetworkDefineXML           = 41
+	ProcNetworkDestroy             = 42
+	ProcNetworkGetXMLDesc          = 43
+	ProcNetworkLookupByName        = 46
+	ProcNetworkSetAutostart        = 48
+	ProcNetworkUndefine            = 49
+	ProcAuthList                   = 66
+	ProcAuthPolkit                 = 70
+	ProcStoragePoolDefineXML       = 77
+	ProcStoragePoolCreate          = 78
+	ProcStoragePoolBuild           = 79
+	ProcStoragePoolDestroy         = 80
+	ProcStoragePoolDelete          = 81
+	ProcStoragePoolUndefine        = 82
+	ProcStoragePoolRefresh         = 83
+	ProcStoragePoolLookupByName    = 84
+	ProcStoragePoolGetInfo         = 87
+	ProcStoragePoolGetXMLDesc      = 88
+	ProcStoragePoolGetAutostart    = 89
+	ProcStoragePoolSetAutostart    = 90
+	ProcStorageVolCreateXML        = 93
+	ProcStorageVolDelete           = 94
+	ProcStorageVolLookupByName     = 95
+	ProcStorageVolGetInfo          = 98
+	ProcStorageVolGetPath          = 100
+	ProcDomainUndefineFlags        = 231
+	ProcConnectListAllDomains      = 273
+	ProcConnectListAllStoragePools = 281
+	ProcStoragePoolListAllVolumes  = 282
+	ProcConnectListAllNetworks     = 283
+	ProcDomainRename               = 358
+)
+
+// authentication types of REMOTE_PROC_AUTH_LIST
+const (
+	AuthNone   = 0
+	AuthSASL   = 1
+	AuthPolkit = 2
+)
+
+// domain states (virDomainState)
+const (
+	StateNoState     = 0
+	StateRunning     = 1
+	StateBlocked     = 2
+	StatePaused      = 3
+	StateShutdown    = 4
+	StateShutoff     = 5
+	StateCrashed     = 6
+	StatePMSuspended = 7
+)
+
+// flags of GetXMLDesc (virDomainXMLFlags)
+const (
+	XMLSecure   = 1
+	XMLInactive = 2 // persistent definition instead of the live one
+)
+
+// flags of UndefineFlags (virDomainUndefineFlagsValues)
+const (
+	UndefineManagedSave = 1
+	UndefineSnapshots   = 2  // snapshot metadata
+	UndefineNVRAM       = 4  // also delete the UEFI variable store
+	UndefineTPM         = 32 // also delete the swtpm state (libvirt ≥ 8.9)
+)
+
+// flags of ConnectListAllNetworks (virConnectListAllNetworksFlags)
+const (
+	ListNetworksInactive  = 1
+	ListNetworksActive    = 2
+	ListNetworksAutostart = 16
+)
+
+// error codes (virErrorNumber) the callers care about
+const (
+	ErrNoSupport        = 3
+	ErrInvalidArg       = 8
+	ErrNoDomain         = 42
+	ErrNoNetwork        = 43
+	ErrNoStoragePool    = 49
+	ErrNoStorageVol     = 50
+	ErrOperationInvalid = 55
+)
+
+// header of every packet (after the length word)
+type header struct {
+	Program   uint32
+	Version   uint32
+	Procedure int32
+	Type      int32
+	Serial    uint32
+	Status    int32
+}
+
+// writePacket sends length word + header + payload in one write
+func writePacket(w io.Writer, h header, payload []byte) error {
+	buf := make([]byte, 4+headerSize+len(payload))
+	binary.BigEndian.PutUint32(buf[0:], uint32(len(buf))) // length includes itself
+	binary.BigEndian.PutUint32(buf[4:], h.Program)
+	binary.BigEndian.PutUint32(buf[8:], h.Version)
+	binary.BigEndian.PutUint32(buf[12:], uint32(h.Procedure))
+	binary.BigEndian.PutUint32(buf[16:], uint32(h.Type))
+	binary.BigEndian.PutUint32(buf[20:], h.Serial)
+	binary.BigEndian.PutUint32(buf[24:], uint32(h.Status))
+	copy(buf[28:], payload)
+	_, err := w.Write(buf)
+	return err
+}
+
+// readPacket reads one complete packet
+func readPacket(r io.Reader) (header, []byte, error) {
+	var lenBuf [4]byte
+	if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+		return header{}, nil, err
+	}
+	n := binary.BigEndian.Uint32(lenBuf[:])
+	if n < 4+headerSize || n > maxMessage {
+		return header{}, nil, fmt.Errorf("libvirt: invalid packet length %d", n)
+	}
+	buf := make([]byte, n-4)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return header{}, nil, err
+	}
+	h := header{
+		Program:   binary.BigEndian.Uint32(buf[0:]),
+		Version:   binary.BigEndian.Uint32(buf[4:]),
+		Procedure: int32(binary.BigEndian.Uint32(buf[8:])),
+		Type:      int32(binary.BigEndian.Uint32(buf[12:])),
+		Serial:    binary.BigEndian.Uint32(buf[16:]),
+		Status:    int32(binary.BigEndian.Uint32(buf[20:])),
+	}
+	return h, buf[headerSize:], nil
+}
+
+// Domain is remote_nonnull_domain
+type Domain struct {
+	Name string
+	UUID [16]byte
+	ID   int32 // -1 while not running
+}
+
+func (e *Encoder) Domain(d Domain) {
+	e.String(d.Name)
+	e.Fixed(d.UUID[:])
+	e.Int32(d.ID)
+}
+
+func (d *Decoder) Domain() Domain {
+	var dom Domain
+	dom.Name = d.String()
+	copy(dom.UUID[:], d.Fixed(16))
+	dom.ID = d.Int32()
+	return dom
+}
+
+// Network is remote_nonnull_network
+type Network struct {
+	Name string
+	UUID [16]byte
+}
+
+func (e *Encoder) Network(n Network) {
+	e.String(n.Name)
+	e.Fixed(n.UUID[:])
+}
+
+func (d *Decoder) Network() Network {
+	var n Network
+	n.Name = d.String()
+	copy(n.UUID[:], d.Fixed(16))
+	return n
+}
+
+// DomainInfo is the reply of REMOTE_PROC_DOMAIN_GET_INFO
+type DomainInfo struct {
+	State     uint8
+	MaxMemKiB uint64
+	MemoryKiB uint64
+	VCPUs     uint16
+	CPUTime   uint64
+}
+
+// Error is a libvirt error (remote_error) with its numeric code
+type Error struct {
+	Code    int32
+	Domain  int32 // error domain (virErrorDomain), not a VM
+	Message string
+	Level   int32
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("libvirt error %d: %s", e.Code, e.Message)
+}
+
+// decodeError reads remote_error; trailing details are skipped
+func decodeError(d *Decoder) *Error {
+	e := &Error{}
+	e.Code = d.Int32()
+	e.Domain = d.Int32()
+	e.Message = d.OptString()
+	e.Level = d.Int32()
+	if d.Err() != nil {
+		return &Error{Code: -1, Message: "malformed error reply"}
+	}
+	return e
+}
+
+// encodeError writes a full remote_error (used by the stand-in server)
+func encodeError(e *Encoder, err *Error) {
+	e.Int32(err.Code)
+	e.Int32(err.Domain)
+	e.OptString(err.Message)
+	e.Int32(err.Level)
+	e.Bool(false) // dom
+	e.Bool(false) // str1
+	e.Bool(false) // str2
+	e.Bool(false) // str3
+	e.Int32(0)    // int1
+	e.Int32(0)    // int2
+	e.Bool(false) // net
+}
@@ -0,0 +1,97 @@
+// libvirt/server.go
+// last modified: Oct 16 2026
+package libvirt
+
+import (
+	"errors"
+	"net"
+	"sync"
+)
+
+// Handler answers one procedure; returning an *Error sends it to the client as-is
+type Handler func(args *Decoder) ([]byte, error)
+
+/*
+Server is a minimal stand-in for libvirtd. It speaks the same framing as the
+real daemon, so Conn (and the native backend) can be exercised against a
+local socket without a hypervisor. Unknown procedures get a libvirt error.
+*/
+type Server struct {
+	mu       sync.Mutex
+	handlers map[int32]Handler
+}
+
+// NewServer returns a server that already accepts auth (none) and open/close
+func NewServer() *Server {
+	s := &Server{handlers: map[int32]Handler{}}
+	s.Handle(ProcAuthList, func(*Decoder) ([]byte, error) {
+		var e Encoder
+		e.Uint32(1)
+		e.Int32(AuthNone)
+		return e.Bytes(), nil
+	})
+	s.Handle(ProcConnectOpen, func(*Decoder) ([]byte, error) { return nil, nil })
+	s.Handle(ProcConnectClose, func(*Decoder) ([]byte, error) { return nil, nil })
+	return s
+}
+
+// Handle registers (or replaces) the handler of a procedure
+func (s *Server) Handle(proc int32, h Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[proc] = h
+}
+
+// Serve accepts connections until the listener is closed
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.ServeConn(c)
+	}
+}
+
+// ServeConn handles calls on one connection until it is closed
+func (s *Server) ServeConn(c net.Conn) {
+	defer c.Close()
+	for {
+		h, payload, err := readPacket(c)
+		if err != nil {
+			return
+		}
+		if h.Type != typeCall {
+			continue
+		}
+		s.mu.Lock()
+		handler, ok := s.handlers[h.Procedure]
+		s.mu.Unlock()
+
+		var out []byte
+		if ok {
+			out, err = handler(NewDecoder(payload))
+		} else {
+			err = &Error{Code: 3, Message: "this function is not supported by the stand-in server"} // VIR_ERR_NO_SUPPORT
+		}
+
+		h.Type = typeReply
+		h.Status = statusOK
+		if err != nil {
+			var le *Error
+			if !errors.As(err, &le) {
+				le = &Error{Code: 1, Message: err.Error()} // VIR_ERR_INTERNAL_ERROR
+			}
+			var e Encoder
+			encodeError(&e, le)
+			out = e.Bytes()
+			h.Status = statusError
+		}
+		if err := writePacket(c, h, out); err != nil {
+			return
+		}
+	}
+}
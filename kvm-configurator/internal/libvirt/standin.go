@@ -0,0 +1,391 @@
+// libvirt/standin.go
+// last modified: Oct 16 2026
+package libvirt
+
+import (
+	"crypto/md5"
+	"encoding/xml"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// StandInDomain is the in-memory state of a domain on the stand-in server
+type StandInDomain struct {
+	Domain
+	State     uint8
+	MemoryKiB uint64
+	VCPUs     uint16
+	Autostart bool
+	XML       []byte
+}
+
+// StandInNetwork is a virtual network on the stand-in server
+type StandInNetwork struct {
+	Network
+	Active    bool
+	Autostart bool
+	XML       []byte
+}
+
+// defaultNetworkXML is the NAT network a fresh libvirt install has
+const defaultNetworkXML = `<network>
+  <name>default</name>
+  <forward mode="nat"/>
+  <bridge name="virbr0" stp="on" delay="0"/>
+  <ip address="192.168.122.1" netmask="255.255.255.0">
+    <dhcp>
+      <range start="192.168.122.2" end="192.168.122.254"/>
+    </dhcp>
+  </ip>
+</network>
+`
+
+/*
+StandIn is a Server that keeps a few domains in memory and implements the
+domain procedures the native backend uses. Good enough for trying the
+backend on a machine without libvirt:
+
+	st := libvirt.NewStandIn()
+	l, _ := net.Listen("unix", "/tmp/fake-libvirt.sock")
+	go st.Serve(l)
+*/
+type StandIn struct {
+	*Server
+	mu       sync.Mutex
+	domains  map[string]*StandInDomain
+	networks map[string]*StandInNetwork
+	pools    map[string]*StandInPool
+	nextID   int32
+}
+
+// NewStandIn returns a stand-in server without domains and with the
+// active network and storage pool "default"
+func NewStandIn() *StandIn {
+	st := &StandIn{Server: NewServer(), domains: map[string]*StandInDomain{}, nextID: 1}
+	st.networks = map[string]*StandInNetwork{
+		"default": {
+			Network:   Network{Name: "default", UUID: md5.Sum([]byte("default"))},
+			Active:    true,
+			Autostart: true,
+			XML:       []byte(defaultNetworkXML),
+		},
+	}
+	st.Handle(ProcConnectListAllDomains, st.listAll)
+	st.Handle(ProcConnectListAllNetworks, st.listNetworks)
+	st.Handle(ProcNetworkLookupByName, st.networkLookup)
+	st.Handle(ProcNetworkDefineXML, st.networkDefine)
+	st.Handle(ProcNetworkGetXMLDesc, st.withNetwork(st.networkXML))
+	st.Handle(ProcNetworkCreate, st.withNetwork(st.networkStart))
+	st.Handle(ProcNetworkDestroy, st.withNetwork(st.networkStop))
+	st.Handle(ProcNetworkSetAutostart, st.withNetwork(st.networkAutostart))
+	st.Handle(ProcNetworkUndefine, st.withNetwork(st.networkUndefine))
+	st.handlePools()
+	st.Handle(ProcDomainLookupByName, st.lookupByName)
+	st.Handle(ProcDomainGetInfo, st.withDomain(st.getInfo))
+	st.Handle(ProcDomainGetXMLDesc, st.withDomain(st.getXML))
+	st.Handle(ProcDomainGetAutostart, st.withDomain(st.getAutostart))
+	st.Handle(ProcDomainSetAutostart, st.withDomain(st.setAutostart))
+	st.Handle(ProcDomainDefineXML, st.define)
+	st.Handle(ProcDomainCreate, st.withDomain(st.create))
+	st.Handle(ProcDomainShutdown, st.withDomain(st.stop))
+	st.Handle(ProcDomainDestroy, st.withDomain(st.stop))
+	st.Handle(ProcDomainReboot, st.withDomain(st.reboot))
+	st.Handle(ProcDomainUndefineFlags, st.withDomain(st.undefine))
+	st.Handle(ProcDomainRename, st.withDomain(st.rename))
+	return st
+}
+
+// Domains returns a snapshot of all domains sorted by name
+func (st *StandIn) Domains() []StandInDomain {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	var out []StandInDomain
+	for _, d := range st.domains {
+		out = append(out, *d)
+	}
+	slices.SortFunc(out, func(a, b StandInDomain) int { return strings.Compare(a.Name, b.Name) })
+	return out
+}
+
+func noDomain(name string) *Error {
+	return &Error{Code: ErrNoDomain, Message: "Domain not found: no domain with matching name '" + name + "'"}
+}
+
+func invalidOp(msg string) *Error {
+	return &Error{Code: ErrOperationInvalid, Message: "Requested operation is not valid: " + msg}
+}
+
+// withDomain decodes the leading nonnull_domain and resolves it (mu held in fn)
+func (st *StandIn) withDomain(fn func(d *StandInDomain, args *Decoder) ([]byte, error)) Handler {
+	return func(args *Decoder) ([]byte, error) {
+		ref := args.Domain()
+		if err := args.Err(); err != nil {
+			return nil, err
+		}
+		st.mu.Lock()
+		defer st.mu.Unlock()
+		d, ok := st.domains[ref.Name]
+		if !ok {
+			return nil, noDomain(ref.Name)
+		}
+		return fn(d, args)
+	}
+}
+
+func (st *StandIn) listAll(*Decoder) ([]byte, error) {
+	doms := st.Domains()
+	var e Encoder
+	e.Uint32(uint32(len(doms)))
+	for _, d := range doms {
+		e.Domain(d.Domain)
+	}
+	e.Uint32(uint32(len(doms)))
+	return e.Bytes(), nil
+}
+
+func (st *StandIn) listNetworks(args *Decoder) ([]byte, error) {
+	args.Int32() // need_results
+	flags := args.Uint32()
+	if err := args.Err(); err != nil {
+		return nil, err
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	// no state flag (or both) lists every network
+	state := flags & (ListNetworksActive | ListNetworksInactive)
+	var nets []Network
+	for _, n := range st.networks {
+		if state == ListNetworksActive && !n.Active || state == ListNetworksInactive && n.Active {
+			continue
+		}
+		if flags&ListNetworksAutostart != 0 && !n.Autostart {
+			continue
+		}
+		nets = append(nets, n.Network)
+	}
+	slices.SortFunc(nets, func(a, b Network) int { return strings.Compare(a.Name, b.Name) })
+	var e Encoder
+	e.Uint32(uint32(len(nets)))
+	for _, n := range nets {
+		e.Network(n)
+	}
+	e.Uint32(uint32(len(nets)))
+	return e.Bytes(), nil
+}
+
+func noNetwork(name string) *Error {
+	return &Error{Code: ErrNoNetwork, Message: "Network not found: no network with matching name '" + name + "'"}
+}
+
+// withNetwork decodes the leading nonnull_network and resolves it (mu held in fn)
+func (st *StandIn) withNetwork(fn func(n *StandInNetwork, args *Decoder) ([]byte, error)) Handler {
+	return func(args *Decoder) ([]byte, error) {
+		ref := args.Network()
+		if err := args.Err(); err != nil {
+			return nil, err
+		}
+		st.mu.Lock()
+		defer st.mu.Unlock()
+		n, ok := st.networks[ref.Name]
+		if !ok {
+			return nil, noNetwork(ref.Name)
+		}
+		return fn(n, args)
+	}
+}
+
+func (st *StandIn) networkLookup(args *Decoder) ([]byte, error) {
+	name := args.String()
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	n, ok := st.networks[name]
+	if !ok {
+		return nil, noNetwork(name)
+	}
+	var e Encoder
+	e.Network(n.Network)
+	return e.Bytes(), nil
+}
+
+func (st *StandIn) networkDefine(args *Decoder) ([]byte, error) {
+	raw := args.String()
+	if err := args.Err(); err != nil {
+		return nil, err
+	}
+	var x struct {
+		Name string `xml:"name"`
+	}
+	if err := xml.Unmarshal([]byte(raw), &x); err != nil || x.Name == "" {
+		return nil, &Error{Code: 27, Message: "XML error: invalid network definition"}
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	n, ok := st.networks[x.Name]
+	if !ok {
+		n = &StandInNetwork{Network: Network{Name: x.Name, UUID: md5.Sum([]byte(x.Name))}}
+		st.networks[x.Name] = n
+	}
+	n.XML = []byte(raw)
+	var e Encoder
+	e.Network(n.Network)
+	return e.Bytes(), nil
+}
+
+func (st *StandIn) networkXML(n *StandInNetwork, _ *Decoder) ([]byte, error) {
+	var e Encoder
+	e.String(string(n.XML))
+	return e.Bytes(), nil
+}
+
+func (st *StandIn) networkStart(n *StandInNetwork, _ *Decoder) ([]byte, error) {
+	if n.Active {
+		return nil, invalidOp("network is already active")
+	}
+	n.Active = true
+	return nil, nil
+}
+
+func (st *StandIn) networkStop(n *StandInNetwork, _ *Decoder) ([]byte, error) {
+	if !n.Active {
+		return nil, invalidOp("network is not active")
+	}
+	n.Active = false
+	return nil, nil
+}
+
+func (st *StandIn) networkAutostart(n *StandInNetwork, args *Decoder) ([]byte, error) {
+	n.Autostart = args.Bool()
+	return nil, args.Err()
+}
+
+func (st *StandIn) networkUndefine(n *StandInNetwork, _ *Decoder) ([]byte, error) {
+	delete(st.networks, n.Name)
+	return nil, nil
+}
+
+func (st *StandIn) lookupByName(args *Decoder) ([]byte, error) {
+	name := args.String()
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	d, ok := st.domains[name]
+	if !ok {
+		return nil, noDomain(name)
+	}
+	var e Encoder
+	e.Domain(d.Domain)
+	return e.Bytes(), nil
+}
+
+func (st *StandIn) getInfo(d *StandInDomain, _ *Decoder) ([]byte, error) {
+	var e Encoder
+	e.Uint32(uint32(d.State))
+	e.Uint64(d.MemoryKiB)
+	e.Uint64(d.MemoryKiB)
+	e.Uint32(uint32(d.VCPUs))
+	e.Uint64(0)
+	return e.Bytes(), nil
+}
+
+func (st *StandIn) getXML(d *StandInDomain, _ *Decoder) ([]byte, error) {
+	var e Encoder
+	e.String(string(d.XML))
+	return e.Bytes(), nil
+}
+
+func (st *StandIn) getAutostart(d *StandInDomain, _ *Decoder) ([]byte, error) {
+	var e Encoder
+	e.Bool(d.Autostart)
+	return e.Bytes(), nil
+}
+
+func (st *StandIn) setAutostart(d *StandInDomain, args *Decoder) ([]byte, error) {
+	d.Autostart = args.Bool()
+	return nil, args.Err()
+}
+
+// what define needs from the domain XML
+type standInXML struct {
+	Name   string `xml:"name"`
+	Memory struct {
+		Unit  string `xml:"unit,attr"`
+		Value uint64 `xml:",chardata"`
+	} `xml:"memory"`
+	VCPU uint16 `xml:"vcpu"`
+}
+
+func (st *StandIn) define(args *Decoder) ([]byte, error) {
+	raw := args.String()
+	if err := args.Err(); err != nil {
+		return nil, err
+	}
+	var x standInXML
+	if err := xml.Unmarshal([]byte(raw), &x); err != nil || x.Name == "" {
+		return nil, &Error{Code: 27, Message: "XML error: invalid domain definition"} // VIR_ERR_XML_ERROR
+	}
+	mem := x.Memory.Value
+	switch x.Memory.Unit {
+	case "MiB":
+		mem *= 1024
+	case "GiB":
+		mem *= 1024 * 1024
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	d, ok := st.domains[x.Name]
+	if !ok {
+		d = &StandInDomain{Domain: Domain{Name: x.Name, UUID: md5.Sum([]byte(x.Name)), ID: -1}, State: StateShutoff}
+		st.domains[x.Name] = d
+	}
+	d.MemoryKiB, d.VCPUs, d.XML = mem, x.VCPU, []byte(raw)
+
+	var e Encoder
+	e.Domain(d.Domain)
+	return e.Bytes(), nil
+}
+
+func (st *StandIn) create(d *StandInDomain, _ *Decoder) ([]byte, error) {
+	if d.State == StateRunning {
+		return nil, invalidOp("domain is already running")
+	}
+	d.State, d.ID = StateRunning, st.nextID
+	st.nextID++
+	return nil, nil
+}
+
+func (st *StandIn) stop(d *StandInDomain, _ *Decoder) ([]byte, error) {
+	if d.State != StateRunning {
+		return nil, invalidOp("domain is not running")
+	}
+	d.State, d.ID = StateShutoff, -1
+	return nil, nil
+}
+
+func (st *StandIn) reboot(d *StandInDomain, _ *Decoder) ([]byte, error) {
+	if d.State != StateRunning {
+		return nil, invalidOp("domain is not running")
+	}
+	return nil, nil
+}
+
+func (st *StandIn) undefine(d *StandInDomain, _ *Decoder) ([]byte, error) {
+	delete(st.domains, d.Name)
+	return nil, nil
+}
+
+func (st *StandIn) rename(d *StandInDomain, args *Decoder) ([]byte, error) {
+	newName := args.OptString()
+	if d.State == StateRunning {
+		return nil, invalidOp("cannot rename active domain")
+	}
+	if _, taken := st.domains[newName]; taken || newName == "" {
+		return nil, invalidOp("domain with name '" + newName + "' already exists")
+	}
+	delete(st.domains, d.Name)
+	d.Name = newName
+	st.domains[newName] = d
+	var e Encoder
+	e.Int32(0)
+	return e.Bytes(), nil
+}
@@ -0,0 +1,365 @@
+// libvirt/standinpool.go
+// last modified: Oct 16 2026
+package libvirt
+
+import (
+	"crypto/md5"
+	"encoding/xml"
+	"path"
+	"slices"
+	"strings"
+)
+
+// StandInPool is a storage pool on the stand-in server; the sizes are
+// made up, the volumes only exist in memory
+type StandInPool struct {
+	StoragePool
+	Active    bool
+	Autostart bool
+	Built     bool
+	Path      string
+	Capacity  uint64
+	Volumes   map[string]*StandInVolume
+	XML       []byte
+}
+
+// StandInVolume is a volume of a stand-in pool
+type StandInVolume struct {
+	Name       string
+	Capacity   uint64
+	Allocation uint64
+}
+
+// standInPoolSize is the capacity every stand-in pool reports
+const standInPoolSize = 500 << 30
+
+// defaultPoolXML is the image directory virt-manager sets up
+const defaultPoolXML = `<pool type="dir">
+  <name>default</name>
+  <target>
+    <path>/var/lib/libvirt/images</path>
+  </target>
+</pool>
+`
+
+func newStandInPool(name, dir string, raw []byte) *StandInPool {
+	return &StandInPool{
+		StoragePool: StoragePool{Name: name, UUID: md5.Sum([]byte("pool/" + name))},
+		Path:        dir,
+		Capacity:    standInPoolSize,
+		Volumes:     map[string]*StandInVolume{},
+		XML:         raw,
+	}
+}
+
+// handlePools registers the storage procedures and the active pool "default"
+func (st *StandIn) handlePools() {
+	def := newStandInPool("default", "/var/lib/libvirt/images", []byte(defaultPoolXML))
+	def.Active, def.Autostart, def.Built = true, true, true
+	st.pools = map[string]*StandInPool{"default": def}
+
+	st.Handle(ProcConnectListAllStoragePools, st.listPools)
+	st.Handle(ProcStoragePoolLookupByName, st.poolLookup)
+	st.Handle(ProcStoragePoolDefineXML, st.poolDefine)
+	st.Handle(ProcStoragePoolGetInfo, st.withPool(st.poolInfo))
+	st.Handle(ProcStoragePoolGetXMLDesc, st.withPool(st.poolXML))
+	st.Handle(ProcStoragePoolGetAutostart, st.withPool(st.poolGetAutostart))
+	st.Handle(ProcStoragePoolSetAutostart, st.withPool(st.poolSetAutostart))
+	st.Handle(ProcStoragePoolBuild, st.withPool(st.poolBuild))
+	st.Handle(ProcStoragePoolCreate, st.withPool(st.poolStart))
+	st.Handle(ProcStoragePoolDestroy, st.withPool(st.poolStop))
+	st.Handle(ProcStoragePoolDelete, st.withPool(st.poolDelete))
+	st.Handle(ProcStoragePoolUndefine, st.withPool(st.poolUndefine))
+	st.Handle(ProcStoragePoolRefresh, st.withPool(st.poolRefresh))
+	st.Handle(ProcStoragePoolListAllVolumes, st.withPool(st.listVolumes))
+	st.Handle(ProcStorageVolLookupByName, st.withPool(st.volLookup))
+	st.Handle(ProcStorageVolCreateXML, st.withPool(st.volCreate))
+	st.Handle(ProcStorageVolDelete, st.withVolume(st.volDelete))
+	st.Handle(ProcStorageVolGetInfo, st.withVolume(st.volInfo))
+	st.Handle(ProcStorageVolGetPath, st.withVolume(st.volPath))
+}
+
+func noPool(name string) *Error {
+	return &Error{Code: ErrNoStoragePool, Message: "Storage pool not found: no storage pool with matching name '" + name + "'"}
+}
+
+func noVolume(name string) *Error {
+	return &Error{Code: ErrNoStorageVol, Message: "Storage volume not found: no storage vol with matching name '" + name + "'"}
+}
+
+func (st *StandIn) listPools(args *Decoder) ([]byte, error) {
+	args.Int32() // need_results
+	args.Uint32()
+	if err := args.Err(); err != nil {
+		return nil, err
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	var pools []StoragePool
+	for _, p := range st.pools {
+		pools = append(pools, p.StoragePool)
+	}
+	slices.SortFunc(pools, func(a, b StoragePool) int { return strings.Compare(a.Name, b.Name) })
+	var e Encoder
+	e.Uint32(uint32(len(pools)))
+	for _, p := range pools {
+		e.StoragePool(p)
+	}
+	e.Uint32(uint32(len(pools)))
+	return e.Bytes(), nil
+}
+
+// withPool decodes the leading nonnull_storage_pool and resolves it (mu held in fn)
+func (st *StandIn) withPool(fn func(p *StandInPool, args *Decoder) ([]byte, error)) Handler {
+	return func(args *Decoder) ([]byte, error) {
+		ref := args.StoragePool()
+		if err := args.Err(); err != nil {
+			return nil, err
+		}
+		st.mu.Lock()
+		defer st.mu.Unlock()
+		p, ok := st.pools[ref.Name]
+		if !ok {
+			return nil, noPool(ref.Name)
+		}
+		return fn(p, args)
+	}
+}
+
+// withVolume decodes the leading nonnull_storage_vol and resolves it (mu held in fn)
+func (st *StandIn) withVolume(fn func(p *StandInPool, v *StandInVolume) ([]byte, error)) Handler {
+	return func(args *Decoder) ([]byte, error) {
+		ref := args.StorageVol()
+		if err := args.Err(); err != nil {
+			return nil, err
+		}
+		st.mu.Lock()
+		defer st.mu.Unlock()
+		p, ok := st.pools[ref.Pool]
+		if !ok {
+			return nil, noPool(ref.Pool)
+		}
+		v, ok := p.Volumes[ref.Name]
+		if !ok {
+			return nil, noVolume(ref.Name)
+		}
+		return fn(p, v)
+	}
+}
+
+func (st *StandIn) poolLookup(args *Decoder) ([]byte, error) {
+	name := args.String()
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	p, ok := st.pools[name]
+	if !ok {
+		return nil, noPool(name)
+	}
+	var e Encoder
+	e.StoragePool(p.StoragePool)
+	return e.Bytes(), nil
+}
+
+func (st *StandIn) poolDefine(args *Decoder) ([]byte, error) {
+	raw := args.String()
+	args.Uint32() // flags
+	if err := args.Err(); err != nil {
+		return nil, err
+	}
+	var x struct {
+		Name string `xml:"name"`
+		Path string `xml:"target>path"`
+	}
+	if err := xml.Unmarshal([]byte(raw), &x); err != nil || x.Name == "" {
+		return nil, &Error{Code: 27, Message: "XML error: invalid storage pool definition"}
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	p, ok := st.pools[x.Name]
+	if !ok {
+		p = newStandInPool(x.Name, x.Path, nil)
+		st.pools[x.Name] = p
+	}
+	p.XML, p.Path = []byte(raw), x.Path
+	var e Encoder
+	e.StoragePool(p.StoragePool)
+	return e.Bytes(), nil
+}
+
+func (st *StandIn) poolInfo(p *StandInPool, _ *Decoder) ([]byte, error) {
+	var e Encoder
+	if !p.Active {
+		e.Uint32(PoolInactive)
+		e.Uint64(0)
+		e.Uint64(0)
+		e.Uint64(0)
+		return e.Bytes(), nil
+	}
+	var used uint64
+	for _, v := range p.Volumes {
+		used += v.Allocation
+	}
+	e.Uint32(PoolRunning)
+	e.Uint64(p.Capacity)
+	e.Uint64(used)
+	e.Uint64(p.Capacity - used)
+	return e.Bytes(), nil
+}
+
+func (st *StandIn) poolXML(p *StandInPool, args *Decoder) ([]byte, error) {
+	args.Uint32() // flags
+	var e Encoder
+	e.String(string(p.XML))
+	return e.Bytes(), args.Err()
+}
+
+func (st *StandIn) poolGetAutostart(p *StandInPool, _ *Decoder) ([]byte, error) {
+	var e Encoder
+	e.Bool(p.Autostart)
+	return e.Bytes(), nil
+}
+
+func (st *StandIn) poolSetAutostart(p *StandInPool, args *Decoder) ([]byte, error) {
+	p.Autostart = args.Bool()
+	return nil, args.Err()
+}
+
+func (st *StandIn) poolBuild(p *StandInPool, _ *Decoder) ([]byte, error) {
+	p.Built = true
+	return nil, nil
+}
+
+func (st *StandIn) poolStart(p *StandInPool, _ *Decoder) ([]byte, error) {
+	switch {
+	case p.Active:
+		return nil, invalidOp("storage pool '" + p.Name + "' is already active")
+	case !p.Built:
+		return nil, &Error{Code: 38, Message: "cannot open directory '" + p.Path + "': No such file or directory"}
+	}
+	p.Active = true
+	return nil, nil
+}
+
+func (st *StandIn) poolStop(p *StandInPool, _ *Decoder) ([]byte, error) {
+	if !p.Active {
+		return nil, invalidOp("storage pool '" + p.Name + "' is not active")
+	}
+	p.Active = false
+	return nil, nil
+}
+
+// poolDelete removes the target directory – like rmdir only when empty
+func (st *StandIn) poolDelete(p *StandInPool, _ *Decoder) ([]byte, error) {
+	switch {
+	case p.Active:
+		return nil, invalidOp("storage pool '" + p.Name + "' is still active")
+	case len(p.Volumes) > 0:
+		return nil, &Error{Code: 38, Message: "cannot remove directory '" + p.Path + "': Directory not empty"}
+	}
+	p.Built = false
+	return nil, nil
+}
+
+func (st *StandIn) poolUndefine(p *StandInPool, _ *Decoder) ([]byte, error) {
+	delete(st.pools, p.Name)
+	return nil, nil
+}
+
+func (st *StandIn) poolRefresh(p *StandInPool, _ *Decoder) ([]byte, error) {
+	if !p.Active {
+		return nil, invalidOp("storage pool '" + p.Name + "' is not active")
+	}
+	return nil, nil
+}
+
+func (st *StandIn) storageVol(p *StandInPool, v *StandInVolume) StorageVol {
+	return StorageVol{Pool: p.Name, Name: v.Name, Key: path.Join(p.Path, v.Name)}
+}
+
+func (st *StandIn) listVolumes(p *StandInPool, args *Decoder) ([]byte, error) {
+	args.Int32() // need_results
+	args.Uint32()
+	if !p.Active {
+		return nil, invalidOp("storage pool '" + p.Name + "' is not active")
+	}
+	names := make([]string, 0, len(p.Volumes))
+	for name := range p.Volumes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var e Encoder
+	e.Uint32(uint32(len(names)))
+	for _, name := range names {
+		e.StorageVol(st.storageVol(p, p.Volumes[name]))
+	}
+	e.Uint32(uint32(len(names)))
+	return e.Bytes(), args.Err()
+}
+
+func (st *StandIn) volLookup(p *StandInPool, args *Decoder) ([]byte, error) {
+	name := args.String()
+	if err := args.Err(); err != nil {
+		return nil, err
+	}
+	v, ok := p.Volumes[name]
+	if !ok {
+		return nil, noVolume(name)
+	}
+	var e Encoder
+	e.StorageVol(st.storageVol(p, v))
+	return e.Bytes(), nil
+}
+
+// volCreate takes name and capacity (in G or bytes) of the definition;
+// qcow2 volumes start with a few hundred KiB allocated
+func (st *StandIn) volCreate(p *StandInPool, args *Decoder) ([]byte, error) {
+	raw := args.String()
+	args.Uint32() // flags
+	if err := args.Err(); err != nil {
+		return nil, err
+	}
+	var x struct {
+		Name     string `xml:"name"`
+		Capacity struct {
+			Unit  string `xml:"unit,attr"`
+			Value uint64 `xml:",chardata"`
+		} `xml:"capacity"`
+	}
+	if err := xml.Unmarshal([]byte(raw), &x); err != nil || x.Name == "" {
+		return nil, &Error{Code: 27, Message: "XML error: invalid storage volume definition"}
+	}
+	if !p.Active {
+		return nil, invalidOp("storage pool '" + p.Name + "' is not active")
+	}
+	if _, ok := p.Volumes[x.Name]; ok {
+		return nil, &Error{Code: 90, Message: "storage volume name '" + x.Name + "' already in use."}
+	}
+	size := x.Capacity.Value
+	if x.Capacity.Unit == "G" {
+		size <<= 30
+	}
+	v := &StandInVolume{Name: x.Name, Capacity: size, Allocation: 196 << 10}
+	p.Volumes[x.Name] = v
+	var e Encoder
+	e.StorageVol(st.storageVol(p, v))
+	return e.Bytes(), nil
+}
+
+func (st *StandIn) volDelete(p *StandInPool, v *StandInVolume) ([]byte, error) {
+	delete(p.Volumes, v.Name)
+	return nil, nil
+}
+
+func (st *StandIn) volInfo(_ *StandInPool, v *StandInVolume) ([]byte, error) {
+	var e Encoder
+	e.Int32(0) // file
+	e.Uint64(v.Capacity)
+	e.Uint64(v.Allocation)
+	return e.Bytes(), nil
+}
+
+func (st *StandIn) volPath(p *StandInPool, v *StandInVolume) ([]byte, error) {
+	var e Encoder
+	e.String(path.Join(p.Path, v.Name))
+	return e.Bytes(), nil
+}
@@ -0,0 +1,284 @@
+// libvirt/storage.go
+// last modified: Oct 16 2026
+package libvirt
+
+import "fmt"
+
+// storage pool states (virStoragePoolState)
+const (
+	PoolInactive     = 0
+	PoolBuilding     = 1
+	PoolRunning      = 2
+	PoolDegraded     = 3
+	PoolInaccessible = 4
+)
+
+// StoragePool is remote_nonnull_storage_pool
+type StoragePool struct {
+	Name string
+	UUID [16]byte
+}
+
+func (e *Encoder) StoragePool(p StoragePool) {
+	e.String(p.Name)
+	e.Fixed(p.UUID[:])
+}
+
+func (d *Decoder) StoragePool() StoragePool {
+	var p StoragePool
+	p.Name = d.String()
+	copy(p.UUID[:], d.Fixed(16))
+	return p
+}
+
+// StorageVol is remote_nonnull_storage_vol
+type StorageVol struct {
+	Pool string
+	Name string
+	Key  string // the path for file based pools
+}
+
+func (e *Encoder) StorageVol(v StorageVol) {
+	e.String(v.Pool)
+	e.String(v.Name)
+	e.String(v.Key)
+}
+
+func (d *Decoder) StorageVol() StorageVol {
+	return StorageVol{Pool: d.String(), Name: d.String(), Key: d.String()}
+}
+
+// StoragePoolInfo is the reply of REMOTE_PROC_STORAGE_POOL_GET_INFO (bytes)
+type StoragePoolInfo struct {
+	State      uint8
+	Capacity   uint64
+	Allocation uint64
+	Available  uint64
+}
+
+// StorageVolInfo is the reply of REMOTE_PROC_STORAGE_VOL_GET_INFO (bytes)
+type StorageVolInfo struct {
+	Type       int32 // virStorageVolType: 0 file, 1 block, 2 dir, …
+	Capacity   uint64
+	Allocation uint64
+}
+
+// ListAllStoragePools returns active and inactive storage pools
+func (c *Conn) ListAllStoragePools() ([]StoragePool, error) {
+	var e Encoder
+	e.Int32(1)  // need_results
+	e.Uint32(0) // flags: all
+	out, err := c.call(ProcConnectListAllStoragePools, e.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	d := NewDecoder(out)
+	n := d.Uint32()
+	if n > 65536 {
+		return nil, fmt.Errorf("libvirt: bogus pool count %d", n)
+	}
+	pools := make([]StoragePool, 0, n)
+	for range n {
+		pools = append(pools, d.StoragePool())
+	}
+	d.Uint32() // ret
+	return pools, d.Err()
+}
+
+// StoragePoolLookupByName resolves a storage pool
+func (c *Conn) StoragePoolLookupByName(name string) (StoragePool, error) {
+	var e Encoder
+	e.String(name)
+	out, err := c.call(ProcStoragePoolLookupByName, e.Bytes())
+	if err != nil {
+		return StoragePool{}, err
+	}
+	d := NewDecoder(out)
+	p := d.StoragePool()
+	return p, d.Err()
+}
+
+// StoragePoolDefineXML defines (or redefines) a persistent storage pool
+func (c *Conn) StoragePoolDefineXML(xml []byte) (StoragePool, error) {
+	var e Encoder
+	e.String(string(xml))
+	e.Uint32(0)
+	out, err := c.call(ProcStoragePoolDefineXML, e.Bytes())
+	if err != nil {
+		return StoragePool{}, err
+	}
+	d := NewDecoder(out)
+	p := d.StoragePool()
+	return p, d.Err()
+}
+
+// StoragePoolGetInfo returns state and sizes of a pool
+func (c *Conn) StoragePoolGetInfo(p StoragePool) (StoragePoolInfo, error) {
+	var e Encoder
+	e.StoragePool(p)
+	out, err := c.call(ProcStoragePoolGetInfo, e.Bytes())
+	if err != nil {
+		return StoragePoolInfo{}, err
+	}
+	d := NewDecoder(out)
+	info := StoragePoolInfo{
+		State:      uint8(d.Uint32()),
+		Capacity:   d.Uint64(),
+		Allocation: d.Uint64(),
+		Available:  d.Uint64(),
+	}
+	return info, d.Err()
+}
+
+// StoragePoolGetXMLDesc returns the pool XML
+func (c *Conn) StoragePoolGetXMLDesc(p StoragePool, flags uint32) ([]byte, error) {
+	var e Encoder
+	e.StoragePool(p)
+	e.Uint32(flags)
+	out, err := c.call(ProcStoragePoolGetXMLDesc, e.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	d := NewDecoder(out)
+	s := d.String()
+	return []byte(s), d.Err()
+}
+
+// StoragePoolGetAutostart reports whether the pool starts with the host
+func (c *Conn) StoragePoolGetAutostart(p StoragePool) (bool, error) {
+	var e Encoder
+	e.StoragePool(p)
+	out, err := c.call(ProcStoragePoolGetAutostart, e.Bytes())
+	if err != nil {
+		return false, err
+	}
+	d := NewDecoder(out)
+	v := d.Int32()
+	return v != 0, d.Err()
+}
+
+// StoragePoolSetAutostart enables or disables autostart of a pool
+func (c *Conn) StoragePoolSetAutostart(p StoragePool, on bool) error {
+	var e Encoder
+	e.StoragePool(p)
+	e.Bool(on)
+	_, err := c.call(ProcStoragePoolSetAutostart, e.Bytes())
+	return err
+}
+
+// poolCall is the common shape "nonnull_storage_pool [+ flags] → void"
+func (c *Conn) poolCall(proc int32, p StoragePool, flags ...uint32) error {
+	var e Encoder
+	e.StoragePool(p)
+	for _, f := range flags {
+		e.Uint32(f)
+	}
+	_, err := c.call(proc, e.Bytes())
+	return err
+}
+
+func (c *Conn) StoragePoolBuild(p StoragePool) error   { return c.poolCall(ProcStoragePoolBuild, p, 0) }
+func (c *Conn) StoragePoolCreate(p StoragePool) error  { return c.poolCall(ProcStoragePoolCreate, p, 0) }
+func (c *Conn) StoragePoolDestroy(p StoragePool) error { return c.poolCall(ProcStoragePoolDestroy, p) }
+func (c *Conn) StoragePoolDelete(p StoragePool) error  { return c.poolCall(ProcStoragePoolDelete, p, 0) }
+
+// StoragePoolUndefine removes the definition; an active pool stays until destroyed
+func (c *Conn) StoragePoolUndefine(p StoragePool) error {
+	return c.poolCall(ProcStoragePoolUndefine, p)
+}
+
+// StoragePoolRefresh rescans the pool target (files added or removed outside libvirt)
+func (c *Conn) StoragePoolRefresh(p StoragePool) error {
+	return c.poolCall(ProcStoragePoolRefresh, p, 0)
+}
+
+// StoragePoolListAllVolumes returns the volumes of an active pool
+func (c *Conn) StoragePoolListAllVolumes(p StoragePool) ([]StorageVol, error) {
+	var e Encoder
+	e.StoragePool(p)
+	e.Int32(1)  // need_results
+	e.Uint32(0) // flags
+	out, err := c.call(ProcStoragePoolListAllVolumes, e.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	d := NewDecoder(out)
+	n := d.Uint32()
+	if n > 65536 {
+		return nil, fmt.Errorf("libvirt: bogus volume count %d", n)
+	}
+	vols := make([]StorageVol, 0, n)
+	for range n {
+		vols = append(vols, d.StorageVol())
+	}
+	d.Uint32() // ret
+	return vols, d.Err()
+}
+
+// StorageVolLookupByName resolves a volume of a pool
+func (c *Conn) StorageVolLookupByName(p StoragePool, name string) (StorageVol, error) {
+	var e Encoder
+	e.StoragePool(p)
+	e.String(name)
+	out, err := c.call(ProcStorageVolLookupByName, e.Bytes())
+	if err != nil {
+		return StorageVol{}, err
+	}
+	d := NewDecoder(out)
+	v := d.StorageVol()
+	return v, d.Err()
+}
+
+// StorageVolCreateXML creates a volume in a pool
+func (c *Conn) StorageVolCreateXML(p StoragePool, xml []byte) (StorageVol, error) {
+	var e Encoder
+	e.StoragePool(p)
+	e.String(string(xml))
+	e.Uint32(0)
+	out, err := c.call(ProcStorageVolCreateXML, e.Bytes())
+	if err != nil {
+		return StorageVol{}, err
+	}
+	d := NewDecoder(out)
+	v := d.StorageVol()
+	return v, d.Err()
+}
+
+// StorageVolDelete removes a volume and its data
+func (c *Conn) StorageVolDelete(v StorageVol) error {
+	var e Encoder
+	e.StorageVol(v)
+	e.Uint32(0)
+	_, err := c.call(ProcStorageVolDelete, e.Bytes())
+	return err
+}
+
+// StorageVolGetInfo returns type and sizes of a volume
+func (c *Conn) StorageVolGetInfo(v StorageVol) (StorageVolInfo, error) {
+	var e Encoder
+	e.StorageVol(v)
+	out, err := c.call(ProcStorageVolGetInfo, e.Bytes())
+	if err != nil {
+		return StorageVolInfo{}, err
+	}
+	d := NewDecoder(out)
+	info := StorageVolInfo{
+		Type:       d.Int32(),
+		Capacity:   d.Uint64(),
+		Allocation: d.Uint64(),
+	}
+	return info, d.Err()
+}
+
+// StorageVolGetPath returns the path of a volume
+func (c *Conn) StorageVolGetPath(v StorageVol) (string, error) {
+	var e Encoder
+	e.StorageVol(v)
+	out, err := c.call(ProcStorageVolGetPath, e.Bytes())
+	if err != nil {
+		return "", err
+	}
+	d := NewDecoder(out)
+	s := d.String()
+	return s, d.Err()
+}
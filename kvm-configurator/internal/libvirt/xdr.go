@@ -0,0 +1,130 @@
+// libvirt/xdr.go
+// last modified: Oct 16 2026
+package libvirt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// XDR (RFC 4506) – just the subset the libvirt remote protocol needs.
+// Everything is big endian and padded to multiples of four bytes.
+
+// Encoder appends XDR values to a buffer
+type Encoder struct {
+	buf bytes.Buffer
+}
+
+func (e *Encoder) Bytes() []byte { return e.buf.Bytes() }
+
+func (e *Encoder) Uint32(v uint32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	e.buf.Write(b[:])
+}
+
+func (e *Encoder) Int32(v int32) { e.Uint32(uint32(v)) }
+
+func (e *Encoder) Uint64(v uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	e.buf.Write(b[:])
+}
+
+func (e *Encoder) Bool(v bool) {
+	if v {
+		e.Uint32(1)
+	} else {
+		e.Uint32(0)
+	}
+}
+
+// String – length prefixed, padded
+func (e *Encoder) String(s string) {
+	e.Uint32(uint32(len(s)))
+	e.buf.WriteString(s)
+	e.pad(len(s))
+}
+
+// OptString – remote_string: a pointer to a string (absent if empty)
+func (e *Encoder) OptString(s string) {
+	e.Bool(s != "")
+	if s != "" {
+		e.String(s)
+	}
+}
+
+// Fixed writes fixed-length opaque data (e.g. a UUID)
+func (e *Encoder) Fixed(b []byte) {
+	e.buf.Write(b)
+	e.pad(len(b))
+}
+
+func (e *Encoder) pad(n int) {
+	if r := n % 4; r != 0 {
+		e.buf.Write(make([]byte, 4-r))
+	}
+}
+
+// Decoder reads XDR values; the first error sticks and is returned by Err
+type Decoder struct {
+	r   io.Reader
+	err error
+}
+
+func NewDecoder(b []byte) *Decoder { return &Decoder{r: bytes.NewReader(b)} }
+
+func (d *Decoder) Err() error { return d.err }
+
+func (d *Decoder) read(n int) []byte {
+	if d.err != nil {
+		return make([]byte, n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		d.err = fmt.Errorf("xdr: short message: %w", err)
+	}
+	return b
+}
+
+func (d *Decoder) Uint32() uint32 { return binary.BigEndian.Uint32(d.read(4)) }
+func (d *Decoder) Int32() int32   { return int32(d.Uint32()) }
+func (d *Decoder) Uint64() uint64 { return binary.BigEndian.Uint64(d.read(8)) }
+func (d *Decoder) Bool() bool     { return d.Uint32() != 0 }
+
+// maxString guards against garbage lengths (libvirt: 4 MiB)
+const maxString = 4 << 20
+
+func (d *Decoder) String() string {
+	n := d.Uint32()
+	if n > maxString {
+		if d.err == nil {
+			d.err = fmt.Errorf("xdr: string length %d exceeds limit", n)
+		}
+		return ""
+	}
+	s := string(d.read(int(n)))
+	d.skipPad(int(n))
+	return s
+}
+
+func (d *Decoder) OptString() string {
+	if !d.Bool() {
+		return ""
+	}
+	return d.String()
+}
+
+func (d *Decoder) Fixed(n int) []byte {
+	b := d.read(n)
+	d.skipPad(n)
+	return b
+}
+
+func (d *Decoder) skipPad(n int) {
+	if r := n % 4; r != 0 {
+		d.read(4 - r)
+	}
+}
@@ -1,12 +1,14 @@
 // model/model.go
-// last modified: Feb 22 2026
+// last modified: Oct 16 2026
 package model
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 	// internal
+	"configurator/internal/cloudinit"
 	"configurator/internal/config"
 )
 
@@ -23,74 +25,256 @@ type DomainConfig struct {
 
 	// network & other hardware
 	Network    string // bridge | nat | none
-	NestedVirt string // vmx | smx 
+	NestedVirt string // vmx | svm
 	Graphics   string // spice | vnc | none
 	Sound      string // ac97 | ich6 | ich9
 	FileSystem string // virtiofs | 9p | none
 	BootOrder  string // e.g. "cdrom,hd"
-	Firmware   string // BIOS | EFI (optional, not yet active)
+	Firmware   string // bios | efi | efi-secure (OVMF with Secure Boot)
+	TPM        string // none | tpm-crb | tpm-tis (emulated TPM 2.0)
+
+	// CloudInit configures a cloud image on its first boot; the seed ISO
+	// (SeedPath) is generated and attached as CD-ROM. nil = no seed
+	CloudInit *cloudinit.Config
+
+	// Profile is the resolved OS entry the config started from;
+	// Origin uses it to tell inherited from edited values
+	Profile config.VMConfig
 }
 
 type DiskSpec struct {
 	Name    string
 	Path    string
+	Pool    string // libvirt storage pool – the image becomes a volume of it, Path is filled from the pool
 	SizeGiB int
 	Bus     string
+	// Existing attaches Path as it is (image file, block device, LVM volume),
+	// nothing is created; Format is filled from qemu-img info if empty
+	Existing bool
+	Format   string
+	// Backing makes the new disk a thin qcow2 overlay on this base image
+	// (linked clone); the base itself is never written to
+	Backing string
+}
+
+// PoolPrefix marks a disk location as a storage pool ("pool:default"),
+// AttachPrefix an existing image or device ("attach:/dev/vg0/web")
+const (
+	PoolPrefix   = "pool:"
+	AttachPrefix = "attach:"
+)
+
+// SetLocation stores where the disk goes: "pool:<name>" selects a storage
+// pool, "attach:<path>" an existing disk (its size is not ours to set),
+// anything else is a directory or file
+func (d *DiskSpec) SetLocation(loc string) {
+	loc = strings.TrimSpace(loc)
+	d.Pool, d.Existing, d.Format = "", false, ""
+	if name, ok := strings.CutPrefix(loc, PoolPrefix); ok {
+		d.Pool, d.Path = strings.TrimSpace(name), ""
+		return
+	}
+	if path, ok := strings.CutPrefix(loc, AttachPrefix); ok {
+		d.Path, d.Existing, d.SizeGiB, d.Backing = filepath.Clean(strings.TrimSpace(path)), true, 0, ""
+		return
+	}
+	d.Path = loc
+}
+
+// Location is the inverse of SetLocation
+func (d DiskSpec) Location() string {
+	switch {
+	case d.Pool != "":
+		return PoolPrefix + d.Pool
+	case d.Existing:
+		return AttachPrefix + d.Path
+	}
+	return d.Path
+}
+
+// IsBlockDevice reports whether path names a device rather than an image
+// file – raw disks, partitions and LVM volumes (symlinks are followed)
+func IsBlockDevice(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.Mode()&os.ModeDevice != 0
+}
+
+// VolumeName is the volume a pool disk gets: <vm>-<disk>.qcow2
+func (d DiskSpec) VolumeName(vmName string) string {
+	return fmt.Sprintf("%s-%s.qcow2", vmName, d.Name)
+}
+
+// NewDomainConfig builds the starting DomainConfig for a distro entry,
+// falling back to the global defaults for the system disk
+func NewDomainConfig(distro config.VMConfig, defs config.Defaults) DomainConfig {
+	system := DiskSpec{
+		Name:    "system",                        // name of the first disk
+		SizeGiB: EffectiveDiskSize(distro, defs), // Size (if defined)
+		Bus:     "virtio",                        // Default bus type (can be changed later)
+	}
+	system.SetLocation(EffectiveDiskPath(distro, defs)) // can be empty → will be filled later
+	return DomainConfig{
+		Name:   distro.Name,
+		MemMiB: distro.RAM,
+		VCPU:   distro.CPU,
+		/*
+			create the *system disk*(first element).
+			The path can be a directory (later becomes <vm>-system.qcow2),
+			a complete file name or pool:<name> (a volume of that pool)
+		*/
+		Disks:      []DiskSpec{system},
+		ISOPath:    distro.ISOPath,
+		Network:    distro.Network,
+		NestedVirt: distro.NestedVirt,
+		Graphics:   distro.Graphics,
+		Sound:      distro.Sound,
+		FileSystem: distro.FileSystem,
+		BootOrder:  distro.BootOrder,
+		Firmware:   distro.Firmware,
+		TPM:        distro.TPM,
+		Profile:    distro,
+	}
 }
 
 // [Modul: config] Load Distro‑Defaults
-func EffectiveDiskPath(d config.VMConfig, global struct {
-	DiskPath string
-	DiskSize int
-}) string {
+func EffectiveDiskPath(d config.VMConfig, global config.Defaults) string {
 	if d.DiskPath != "" {
 		return d.DiskPath
 	}
 	return global.DiskPath
 }
 
-func EffectiveDiskSize(d config.VMConfig, global struct {
-	DiskPath string
-	DiskSize int
-}) int {
+func EffectiveDiskSize(d config.VMConfig, global config.Defaults) int {
 	if d.DiskSize != 0 {
 		return d.DiskSize
 	}
 	return global.DiskSize
 }
 
-// buildDiskArg create string for --disk
-func BuildDiskArgs(disks []DiskSpec, vmName string) []string {
-	var args []string
-	for _, d := range disks {
-		// Determine base path
-		base := strings.TrimSpace(d.Path)
-
-		// If only one directory is specified → <dir>/<vmName>-<disk.Name>.qcow2
-		if !strings.Contains(filepath.Base(base), ".") {
-			// no file name > we build a unique name
-			file := fmt.Sprintf("%s-%s.qcow2", vmName, d.Name)
-			base = filepath.Join(base, file)
-		} else if !strings.HasSuffix(base, ".qcow2") {
-			base += ".qcow2"
-		}
+// DiskImagePath returns the image file a DiskSpec resolves to.
+// If only a directory is specified → <dir>/<vmName>-<disk.Name>.qcow2,
+// an existing disk keeps its path
+func DiskImagePath(d DiskSpec, vmName string) string {
+	// an existing disk is used as it is
+	if d.Existing {
+		return d.Path
+	}
+	// Determine base path
+	base := strings.TrimSpace(d.Path)
 
-		// Assemble options
-		opts := []string{
-			fmt.Sprintf("path=%s", base),
-			"format=qcow2",
+	if !strings.Contains(filepath.Base(base), ".") {
+		// no file name > we build a unique name
+		file := fmt.Sprintf("%s-%s.qcow2", vmName, d.Name)
+		base = filepath.Join(base, file)
+	} else if !strings.HasSuffix(base, ".qcow2") {
+		base += ".qcow2"
+	}
+	return base
+}
+
+// ToProfile turns the (edited) config back into an OS entry called name;
+// id and family are those of the profile it started from. Only the
+// system disk fits into a profile, the ISO is left out.
+func (c *DomainConfig) ToProfile(name string) config.VMConfig {
+	p := config.VMConfig{
+		Name:       name,
+		ID:         c.Profile.ID,
+		Family:     c.Profile.Family,
+		CPU:        c.VCPU,
+		RAM:        c.MemMiB,
+		NestedVirt: c.NestedVirt,
+		Network:    c.Network,
+		Graphics:   c.Graphics,
+		Sound:      c.Sound,
+		FileSystem: c.FileSystem,
+		BootOrder:  c.BootOrder,
+		Firmware:   c.Firmware,
+		TPM:        c.TPM,
+	}
+	if d := c.PrimaryDisk(); d != nil {
+		p.DiskPath = d.Location()
+		p.DiskSize = d.SizeGiB
+		// an attached disk belongs to this VM only – keep the profile's location
+		if d.Existing {
+			p.DiskPath, p.DiskSize = c.Profile.DiskPath, c.Profile.DiskSize
 		}
-		if d.SizeGiB > 0 {
-			opts = append([]string{fmt.Sprintf("size=%d", d.SizeGiB)}, opts...)
+	}
+	return p
+}
+
+/*
+Origin tells where the current value of a profile key (ram, graphics, …)
+comes from: "edited" if it differs from the profile, otherwise the origin
+recorded when the profile was resolved ("profile", a family or "defaults").
+Keys nobody set are "built-in".
+*/
+func (c *DomainConfig) Origin(key string) string {
+	p := c.Profile
+	var changed bool
+	switch key {
+	case "ram":
+		changed = c.MemMiB != p.RAM
+	case "cpu":
+		changed = c.VCPU != p.CPU
+	case "diskpath", "disksize":
+		d := c.PrimaryDisk()
+		if d == nil {
+			return "edited"
 		}
-		if d.Bus != "" {
-			opts = append(opts, fmt.Sprintf("bus=%s", d.Bus))
+		if key == "diskpath" {
+			changed = d.Location() != p.DiskPath
+		} else {
+			changed = d.SizeGiB != p.DiskSize
 		}
+	case "isopath":
+		changed = c.ISOPath != p.ISOPath
+	case "network":
+		changed = c.Network != p.Network
+	case "nvirt":
+		changed = c.NestedVirt != p.NestedVirt
+	case "graphics":
+		changed = c.Graphics != p.Graphics
+	case "sound":
+		changed = c.Sound != p.Sound
+	case "filesystem":
+		changed = c.FileSystem != p.FileSystem
+	case "bootorder":
+		changed = c.BootOrder != p.BootOrder
+	case "firmware":
+		changed = c.Firmware != p.Firmware
+	case "tpm":
+		changed = c.TPM != p.TPM
+	}
+	if changed {
+		return "edited"
+	}
+	if o, ok := p.Origin[key]; ok {
+		return o
+	}
+	return "built-in"
+}
 
-		// The finished argument (e.g. "size=20,path=/var/vms/system.qcow2,format=qcow2,bus=virtio")
-		args = append(args, strings.Join(opts, ","))
+// Imported reports whether the VM boots from an existing system disk or a
+// linked clone of a base image instead of being installed from an ISO
+func (c *DomainConfig) Imported() bool {
+	d := c.PrimaryDisk()
+	return d != nil && (d.Existing || d.Backing != "")
+}
+
+// SeedPath is the cloud-init seed ISO of the VM, next to the system disk;
+// empty without cloud-init
+func (c *DomainConfig) SeedPath() string {
+	d := c.PrimaryDisk()
+	if c.CloudInit == nil || d == nil {
+		return ""
 	}
-	return args
+	return SeedFile(DiskImagePath(*d, c.Name), c.Name)
+}
+
+// SeedFile – the seed ISO of vmName next to its system disk image
+// (<dir>/<vm>-cidata.iso)
+func SeedFile(systemDisk, vmName string) string {
+	return filepath.Join(filepath.Dir(systemDisk), vmName+"-"+cloudinit.VolumeID+".iso")
 }
 
 // Helper: return the *first* Disk (System‑Disk) of a VM
@@ -0,0 +1,36 @@
+// model/model_test.go
+// last modified: Oct 16 2026
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsBlockDevice(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "web.qcow2")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "null")
+	if err := os.Symlink("/dev/null", link); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/dev/null", true},
+		{link, true}, // like /dev/vg0/web → ../dm-3
+		{file, false},
+		{dir, false},
+		{"/dev/vg-missing/web", false}, // not there: no device, whatever the prefix
+	}
+	for _, tt := range tests {
+		if got := IsBlockDevice(tt.path); got != tt.want {
+			t.Errorf("IsBlockDevice(%s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// model/overrides.go
+// last modified: Oct 16 2026
+package model
+
+import (
+	"fmt"
+	"os"
+
+	// internal
+	"configurator/internal/cloudinit"
+)
+
+// Overrides are per-VM values that replace the profile defaults.
+// Zero values mean “keep what the profile says”.
+// Used by the create command (flags) and the lab spec (YAML).
+type Overrides struct {
+	Name       string `yaml:"name"`
+	MemMiB     int    `yaml:"ram"`      // RAM in MiB
+	VCPU       int    `yaml:"vcpus"`    // number of vCPUs
+	DiskGiB    int    `yaml:"disksize"` // size of the system disk
+	DiskPath   string `yaml:"diskpath"` // directory, file, pool:<name> or attach:<path> of the system disk
+	Import     string `yaml:"import"`   // existing image or device the VM boots from, no ISO needed
+	Backing    string `yaml:"backing"`  // base image the system disk is a linked clone of
+	ISOPath    string `yaml:"iso"`      // resolved by the caller
+	Network    string `yaml:"network"`
+	Graphics   string `yaml:"graphics"`
+	Sound      string `yaml:"sound"`
+	BootOrder  string `yaml:"bootorder"`
+	NestedVirt string `yaml:"nvirt"`
+	Firmware   string `yaml:"firmware"` // bios | efi | efi-secure
+	TPM        string `yaml:"tpm"`      // none | tpm-crb | tpm-tis
+	// set: the system disk is a cloud image configured by a generated seed
+	CloudInit *CloudInit `yaml:"cloudinit"`
+}
+
+// CloudInit are the first-boot settings of a cloud image; the host name
+// defaults to the VM name, the user to $USER, the keys to ~/.ssh/id_*.pub
+type CloudInit struct {
+	Hostname string   `yaml:"hostname"`
+	User     string   `yaml:"user"`
+	SSHKeys  []string `yaml:"ssh_keys"` // public keys or key files
+	Packages []string `yaml:"packages"`
+	Address  string   `yaml:"address"` // static IPv4 in CIDR notation, empty = DHCP
+	Gateway  string   `yaml:"gateway"`
+	DNS      string   `yaml:"dns"` // space or comma separated
+}
+
+// Config turns the settings into the seed configuration of VM vmName
+func (o CloudInit) Config(vmName string) (*cloudinit.Config, error) {
+	c := &cloudinit.Config{Hostname: o.Hostname, User: o.User, Packages: o.Packages}
+	if c.Hostname == "" {
+		c.Hostname = vmName
+	}
+	if c.User == "" {
+		c.User = cloudinit.DefaultUser()
+	}
+	for _, k := range o.SSHKeys {
+		key, err := cloudinit.LoadKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("cloud-init: %w", err)
+		}
+		c.SSHKeys = append(c.SSHKeys, key)
+	}
+	if len(c.SSHKeys) == 0 {
+		c.SSHKeys = cloudinit.LocalKeys()
+	}
+	st, err := cloudinit.ParseStatic(o.Address, o.Gateway, o.DNS)
+	if err != nil {
+		return nil, fmt.Errorf("cloud-init: %w", err)
+	}
+	c.Network = st
+	return c, nil
+}
+
+// ApplyOverrides copies every non-zero override into the config
+func (c *DomainConfig) ApplyOverrides(o Overrides) error {
+	if o.MemMiB < 0 || o.VCPU < 0 || o.DiskGiB < 0 {
+		return fmt.Errorf("ram, vcpus and disk size must be positive")
+	}
+	if o.Name != "" {
+		c.Name = o.Name
+	}
+	if o.MemMiB > 0 {
+		c.MemMiB = o.MemMiB
+	}
+	if o.VCPU > 0 {
+		c.VCPU = o.VCPU
+	}
+	if primary := c.PrimaryDisk(); primary != nil {
+		if o.DiskGiB > 0 {
+			primary.SizeGiB = o.DiskGiB
+		}
+		if o.DiskPath != "" {
+			primary.SetLocation(os.ExpandEnv(o.DiskPath))
+		}
+		// the imported disk is the system – the profile's ISO is not needed
+		// (an explicit ISO override still attaches one)
+		if o.Import != "" {
+			primary.SetLocation(AttachPrefix + os.ExpandEnv(o.Import))
+			c.ISOPath = ""
+		}
+		// a clone of an installed base image boots without ISO as well
+		if o.Backing != "" && !primary.Existing {
+			primary.Backing = os.ExpandEnv(o.Backing)
+			c.ISOPath = ""
+		}
+	}
+	if o.CloudInit != nil {
+		ci, err := o.CloudInit.Config(c.Name)
+		if err != nil {
+			return err
+		}
+		c.CloudInit = ci
+	}
+	strs := []struct {
+		val   string
+		field *string
+	}{
+		{o.ISOPath, &c.ISOPath},
+		{o.Network, &c.Network},
+		{o.Graphics, &c.Graphics},
+		{o.Sound, &c.Sound},
+		{o.BootOrder, &c.BootOrder},
+		{o.NestedVirt, &c.NestedVirt},
+		{o.Firmware, &c.Firmware},
+		{o.TPM, &c.TPM},
+	}
+	for _, s := range strs {
+		if s.val != "" {
+			*s.field = s.val
+		}
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+// netspec/netspec.go
+// last modified: Oct 16 2026
+package netspec
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// kinds of NIC (the interface type of the domain XML)
+const (
+	TypeNetwork = "network" // libvirt virtual network
+	TypeBridge  = "bridge"  // existing host bridge
+	TypeDirect  = "direct"  // macvtap on a host device
+)
+
+// DefaultNetwork is the NAT network libvirt ships
+const DefaultNetwork = "default"
+
+// DefaultModel is used when a NIC names no model
+const DefaultModel = "virtio"
+
+// Models are the NIC models offered; virtio needs guest drivers (Windows: virtio-win)
+var Models = []string{"virtio", "e1000e", "e1000", "rtl8139"}
+
+// DirectModes are the macvtap modes of libvirt
+var DirectModes = []string{"bridge", "vepa", "private", "passthrough"}
+
+// NIC is one network interface of a VM
+type NIC struct {
+	Type   string // TypeNetwork, TypeBridge or TypeDirect
+	Source string // network name, bridge or host device
+	Mode   string // direct only (default bridge)
+	Model  string // empty = DefaultModel
+	MAC    string // empty = assigned by libvirt
+}
+
+var macRe = regexp.MustCompile(`^[0-9a-fA-F]{2}(:[0-9a-fA-F]{2}){5}$`)
+
+/*
+Parse reads a network setting. NICs are separated by ";", each NIC is its
+source followed by optional ,model=…,mac=…,mode=… settings:
+
+	"" / default / nat            → libvirt network "default"
+	none                          → no NIC at all
+	bridge / bridge=<br>          → host bridge (br0 if none is given)
+	network=<name> or just <name> → that libvirt network
+	direct=<dev> / macvtap=<dev>  → macvtap on a host device (mode=bridge|vepa|private|passthrough)
+
+e.g. "default; bridge=br0,model=e1000e,mac=52:54:00:12:34:56"
+*/
+func Parse(spec string) ([]NIC, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.EqualFold(spec, "none") {
+		return nil, nil
+	}
+	if spec == "" {
+		return []NIC{{Type: TypeNetwork, Source: DefaultNetwork}}, nil
+	}
+	var nics []NIC
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		nic, err := parseNIC(part)
+		if err != nil {
+			return nil, err
+		}
+		nics = append(nics, nic)
+	}
+	if len(nics) == 0 {
+		return []NIC{{Type: TypeNetwork, Source: DefaultNetwork}}, nil
+	}
+	seen := map[string]bool{}
+	for _, n := range nics {
+		if n.MAC == "" {
+			continue
+		}
+		if seen[strings.ToLower(n.MAC)] {
+			return nil, fmt.Errorf("MAC address %s is used twice", n.MAC)
+		}
+		seen[strings.ToLower(n.MAC)] = true
+	}
+	return nics, nil
+}
+
+func parseNIC(part string) (NIC, error) {
+	fields := strings.Split(part, ",")
+	var nic NIC
+	key, val, _ := strings.Cut(strings.TrimSpace(fields[0]), "=")
+	key, val = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(val)
+	switch key {
+	case "", "default", "nat":
+		if val != "" {
+			return NIC{}, fmt.Errorf("unknown network setting %q", part)
+		}
+		nic = NIC{Type: TypeNetwork, Source: DefaultNetwork}
+	case "none":
+		return NIC{}, fmt.Errorf("none cannot be combined with other NICs (%q)", part)
+	case "bridge":
+		nic = NIC{Type: TypeBridge, Source: "br0"}
+		if val != "" {
+			nic.Source = val
+		}
+	case "network":
+		if val == "" {
+			return NIC{}, fmt.Errorf("missing name in %q (network=<name>)", part)
+		}
+		nic = NIC{Type: TypeNetwork, Source: val}
+	case "direct", "macvtap":
+		if val == "" {
+			return NIC{}, fmt.Errorf("missing host device in %q (direct=<dev>)", part)
+		}
+		nic = NIC{Type: TypeDirect, Source: val}
+	default:
+		if val != "" {
+			return NIC{}, fmt.Errorf("unknown network setting %q (default, none, bridge[=br], network=<name>, direct=<dev>)", part)
+		}
+		nic = NIC{Type: TypeNetwork, Source: strings.TrimSpace(fields[0])}
+	}
+
+	for _, f := range fields[1:] {
+		k, v, ok := strings.Cut(strings.TrimSpace(f), "=")
+		k, v = strings.ToLower(strings.TrimSpace(k)), strings.TrimSpace(v)
+		if !ok || v == "" {
+			return NIC{}, fmt.Errorf("%q: expected key=value, got %q", part, f)
+		}
+		switch k {
+		case "model":
+			if !slices.Contains(Models, strings.ToLower(v)) {
+				return NIC{}, fmt.Errorf("unknown NIC model %q (%s)", v, strings.Join(Models, ", "))
+			}
+			nic.Model = strings.ToLower(v)
+		case "mac":
+			if err := CheckMAC(v); err != nil {
+				return NIC{}, err
+			}
+			nic.MAC = strings.ToLower(v)
+		case "mode":
+			if nic.Type != TypeDirect {
+				return NIC{}, fmt.Errorf("%q: mode only applies to direct NICs", part)
+			}
+			if !slices.Contains(DirectModes, strings.ToLower(v)) {
+				return NIC{}, fmt.Errorf("unknown direct mode %q (%s)", v, strings.Join(DirectModes, ", "))
+			}
+			nic.Mode = strings.ToLower(v)
+		default:
+			return NIC{}, fmt.Errorf("%q: unknown setting %q (model, mac, mode)", part, k)
+		}
+	}
+	return nic, nil
+}
+
+// CheckMAC accepts a unicast address such as 52:54:00:12:34:56
+func CheckMAC(mac string) error {
+	if !macRe.MatchString(mac) {
+		return fmt.Errorf("invalid MAC address %q (expected 52:54:00:xx:xx:xx)", mac)
+	}
+	if first, _ := strconv.ParseUint(mac[:2], 16, 8); first&1 == 1 {
+		return fmt.Errorf("MAC address %s is a multicast address", mac)
+	}
+	return nil
+}
+
+// String is the spec of one NIC, the inverse of Parse
+func (n NIC) String() string {
+	var s string
+	switch n.Type {
+	case TypeBridge:
+		s = "bridge=" + n.Source
+	case TypeDirect:
+		s = "direct=" + n.Source
+		if n.Mode != "" {
+			s += ",mode=" + n.Mode
+		}
+	default:
+		if n.Source == DefaultNetwork {
+			s = DefaultNetwork
+		} else {
+			s = "network=" + n.Source
+		}
+	}
+	if n.Model != "" && n.Model != DefaultModel {
+		s += ",model=" + n.Model
+	}
+	if n.MAC != "" {
+		s += ",mac=" + n.MAC
+	}
+	return s
+}
+
+// Format joins NICs to a spec; no NICs is "none"
+func Format(nics []NIC) string {
+	if len(nics) == 0 {
+		return "none"
+	}
+	parts := make([]string, len(nics))
+	for i, n := range nics {
+		parts[i] = n.String()
+	}
+	return strings.Join(parts, "; ")
+}
+
+// sysClassNet is where Linux lists the network devices
+const sysClassNet = "/sys/class/net"
+
+// HostBridges returns the bridges of this machine (br0, virbr0, …), sorted
+func HostBridges() []string {
+	return hostDevices(func(dir string) bool { return exists(filepath.Join(dir, "bridge")) })
+}
+
+// HostDevices returns the physical network devices of this machine – the
+// candidates for direct (macvtap) NICs
+func HostDevices() []string {
+	return hostDevices(func(dir string) bool { return exists(filepath.Join(dir, "device")) })
+}
+
+func hostDevices(match func(dir string) bool) []string {
+	entries, err := os.ReadDir(sysClassNet)
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, e := range entries {
+		if match(filepath.Join(sysClassNet, e.Name())) {
+			out = append(out, e.Name())
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,114 @@
+// netspec/netspec_test.go
+// last modified: Oct 16 2026
+package netspec
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []NIC
+	}{
+		{"", []NIC{{Type: TypeNetwork, Source: DefaultNetwork}}},
+		{"nat", []NIC{{Type: TypeNetwork, Source: DefaultNetwork}}},
+		{" ; ", []NIC{{Type: TypeNetwork, Source: DefaultNetwork}}},
+		{"NONE", nil},
+		{"bridge", []NIC{{Type: TypeBridge, Source: "br0"}}},
+		{"lab", []NIC{{Type: TypeNetwork, Source: "lab"}}},
+		{"default; bridge=br1,model=E1000E,mac=52:54:00:AB:CD:EF; macvtap=enp3s0,mode=vepa", []NIC{
+			{Type: TypeNetwork, Source: DefaultNetwork},
+			{Type: TypeBridge, Source: "br1", Model: "e1000e", MAC: "52:54:00:ab:cd:ef"},
+			{Type: TypeDirect, Source: "enp3s0", Mode: "vepa"},
+		}},
+		{"network=lab,mac=52:54:00:00:00:01;network=lab,mac=52:54:00:00:00:02", []NIC{
+			{Type: TypeNetwork, Source: "lab", MAC: "52:54:00:00:00:01"},
+			{Type: TypeNetwork, Source: "lab", MAC: "52:54:00:00:00:02"},
+		}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.spec)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.spec, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		spec, wantErr string
+	}{
+		{"default,mac=52:54:00:00:00:01; bridge,mac=52:54:00:00:00:01", "used twice"},
+		{"default,mac=52:54:00:AA:BB:01; bridge=br0,mac=52:54:00:aa:bb:01", "used twice"}, // case-insensitive
+		{"default,mac=01:00:5e:00:00:01", "multicast"},
+		{"default,mac=52:54:00:00:01", "invalid MAC"},
+		{"default,mode=vepa", "only applies to direct"},
+		{"bridge=br0,mode=bridge", "only applies to direct"},
+		{"direct=eth0,mode=loop", "unknown direct mode"},
+		{"default,model=ne2k", "unknown NIC model"},
+		{"default,speed=1000", "unknown setting"},
+		{"default,model", "expected key=value"},
+		{"default; none", "cannot be combined"},
+		{"network=", "missing name"},
+		{"direct=", "missing host device"},
+		{"vlan=5", "unknown network setting"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.spec)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Parse(%q) error = %v, want %q", tt.spec, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCheckMAC(t *testing.T) {
+	for mac, ok := range map[string]bool{
+		"52:54:00:12:34:56": true,
+		"02:00:00:00:00:01": true, // locally administered unicast
+		"FE:54:00:12:34:56": true,
+		"ff:ff:ff:ff:ff:ff": false, // broadcast
+		"33:33:00:00:00:01": false, // IPv6 multicast
+		"52-54-00-12-34-56": false,
+		"52:54:00:12:34:5":  false,
+		"52:54:00:12:34:5g": false,
+		"":                  false,
+	} {
+		if err := CheckMAC(mac); (err == nil) != ok {
+			t.Errorf("CheckMAC(%q) = %v, want ok=%v", mac, err, ok)
+		}
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	for _, spec := range []string{
+		"none",
+		"default",
+		"network=lab",
+		"bridge=br0,model=e1000e",
+		"default; bridge=br1,mac=52:54:00:ab:cd:ef; direct=enp3s0,mode=passthrough,model=rtl8139",
+	} {
+		nics, err := Parse(spec)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", spec, err)
+		}
+		if got := Format(nics); got != spec {
+			t.Errorf("Format(Parse(%q)) = %q", spec, got)
+		}
+	}
+	// the default model is not spelled out, everything else survives
+	nics := []NIC{{Type: TypeBridge, Source: "br0", Model: DefaultModel, MAC: "52:54:00:00:00:09"}}
+	back, err := Parse(Format(nics))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []NIC{{Type: TypeBridge, Source: "br0", MAC: "52:54:00:00:00:09"}}; !slices.Equal(back, want) {
+		t.Errorf("Parse(Format(%+v)) = %+v", nics, back)
+	}
+}
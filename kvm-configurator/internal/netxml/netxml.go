@@ -0,0 +1,284 @@
+// netxml/netxml.go
+// last modified: Oct 16 2026
+package netxml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+)
+
+// modes of a new network
+const (
+	ModeNAT      = "nat"      // guests reach the outside through the host (like "default")
+	ModeIsolated = "isolated" // guests only see each other and the host
+)
+
+/*
+Network is a libvirt network definition
+(https://libvirt.org/formatnetwork.html) – only what the network menu
+shows and creates; it is read and built, never edited and written back.
+*/
+type Network struct {
+	XMLName xml.Name `xml:"network"`
+	Name    string   `xml:"name"`
+	UUID    string   `xml:"uuid,omitempty"`
+	Forward *Forward `xml:"forward"`
+	Bridge  *Bridge  `xml:"bridge"`
+	IPs     []IP     `xml:"ip"`
+}
+
+type Forward struct {
+	Mode string `xml:"mode,attr,omitempty"` // nat | route | open | bridge | …; no <forward> = isolated
+	Dev  string `xml:"dev,attr,omitempty"`
+}
+
+type Bridge struct {
+	Name  string `xml:"name,attr,omitempty"`
+	STP   string `xml:"stp,attr,omitempty"`
+	Delay string `xml:"delay,attr,omitempty"`
+}
+
+type IP struct {
+	Family  string `xml:"family,attr,omitempty"` // ipv4 (default) | ipv6
+	Address string `xml:"address,attr,omitempty"`
+	Netmask string `xml:"netmask,attr,omitempty"`
+	Prefix  int    `xml:"prefix,attr,omitempty"`
+	DHCP    *DHCP  `xml:"dhcp"`
+}
+
+type DHCP struct {
+	Ranges []Range `xml:"range"`
+}
+
+type Range struct {
+	Start string `xml:"start,attr"`
+	End   string `xml:"end,attr"`
+}
+
+// Parse reads a network definition (virsh net-dumpxml)
+func Parse(data []byte) (*Network, error) {
+	var n Network
+	if err := xml.Unmarshal(data, &n); err != nil {
+		return nil, fmt.Errorf("parse network XML: %w", err)
+	}
+	return &n, nil
+}
+
+// Marshal renders the definition for net-define
+func (n *Network) Marshal() ([]byte, error) {
+	out, err := xml.MarshalIndent(n, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal network %s: %w", n.Name, err)
+	}
+	return append(out, '\n'), nil
+}
+
+// Mode is the forward mode, "isolated" without one
+func (n *Network) Mode() string {
+	switch {
+	case n.Forward == nil:
+		return ModeIsolated
+	case n.Forward.Mode == "":
+		return ModeNAT // <forward/> defaults to nat
+	}
+	return n.Forward.Mode
+}
+
+// BridgeName is the host bridge of the network ("" if libvirt has not picked one yet)
+func (n *Network) BridgeName() string {
+	if n.Bridge == nil {
+		return ""
+	}
+	return n.Bridge.Name
+}
+
+// Subnets returns the IPv4 subnets of the network (e.g. 192.168.122.0/24)
+func (n *Network) Subnets() []netip.Prefix {
+	var out []netip.Prefix
+	for _, ip := range n.IPs {
+		if p, ok := ip.prefix(); ok {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Subnet is the first IPv4 subnet as text ("" if none)
+func (n *Network) Subnet() string {
+	if s := n.Subnets(); len(s) > 0 {
+		return s[0].String()
+	}
+	return ""
+}
+
+// DHCPRange is the first IPv4 DHCP range, e.g. "192.168.122.2-192.168.122.254"
+func (n *Network) DHCPRange() string {
+	for _, ip := range n.IPs {
+		if _, ok := ip.prefix(); ok && ip.DHCP != nil && len(ip.DHCP.Ranges) > 0 {
+			r := ip.DHCP.Ranges[0]
+			return r.Start + "-" + r.End
+		}
+	}
+	return ""
+}
+
+// prefix is the subnet of an IPv4 <ip> element (address + netmask or prefix)
+func (ip IP) prefix() (netip.Prefix, bool) {
+	if ip.Family != "" && ip.Family != "ipv4" {
+		return netip.Prefix{}, false
+	}
+	addr, err := netip.ParseAddr(ip.Address)
+	if err != nil || !addr.Is4() {
+		return netip.Prefix{}, false
+	}
+	bits := ip.Prefix
+	if ip.Netmask != "" {
+		mask, err := netip.ParseAddr(ip.Netmask)
+		if err != nil || !mask.Is4() {
+			return netip.Prefix{}, false
+		}
+		bits = maskBits(mask)
+	}
+	if bits <= 0 || bits > 32 {
+		return netip.Prefix{}, false
+	}
+	return netip.PrefixFrom(addr, bits).Masked(), true
+}
+
+func maskBits(mask netip.Addr) int {
+	bits := 0
+	for _, b := range mask.As4() {
+		for ; b&0x80 != 0; b <<= 1 {
+			bits++
+		}
+	}
+	return bits
+}
+
+// Spec describes a new NAT or isolated network
+type Spec struct {
+	Name      string
+	Mode      string // ModeNAT or ModeIsolated
+	Subnet    string // IPv4 CIDR, e.g. 192.168.100.0/24; the host gets the first address
+	DHCPStart string // empty start and end: no DHCP
+	DHCPEnd   string
+	Bridge    string // empty: libvirt picks virbrN
+}
+
+var nameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// bridge names are interface names: at most 15 characters
+const maxIfName = 15
+
+/*
+Build checks s and returns the network definition: subnet /8 to /30,
+host address = first address of the subnet, DHCP range inside the subnet
+without the network, host and broadcast address.
+*/
+func Build(s Spec) (*Network, error) {
+	if !nameRe.MatchString(s.Name) {
+		return nil, fmt.Errorf("invalid network name %q (letters, digits, . _ -)", s.Name)
+	}
+	if s.Bridge != "" && (len(s.Bridge) > maxIfName || !nameRe.MatchString(s.Bridge)) {
+		return nil, fmt.Errorf("invalid bridge name %q (at most %d characters)", s.Bridge, maxIfName)
+	}
+	subnet, err := ParseSubnet(s.Subnet)
+	if err != nil {
+		return nil, err
+	}
+	host := subnet.Addr().Next()
+	ip := IP{Address: host.String(), Prefix: subnet.Bits()}
+
+	if s.DHCPStart != "" || s.DHCPEnd != "" {
+		start, end, err := checkRange(subnet, host, s.DHCPStart, s.DHCPEnd)
+		if err != nil {
+			return nil, err
+		}
+		ip.DHCP = &DHCP{Ranges: []Range{{Start: start.String(), End: end.String()}}}
+	}
+
+	n := &Network{Name: s.Name, IPs: []IP{ip}}
+	switch s.Mode {
+	case ModeNAT, "":
+		n.Forward = &Forward{Mode: ModeNAT}
+	case ModeIsolated:
+	default:
+		return nil, fmt.Errorf("unknown network mode %q (%s or %s)", s.Mode, ModeNAT, ModeIsolated)
+	}
+	if s.Bridge != "" {
+		n.Bridge = &Bridge{Name: s.Bridge, STP: "on", Delay: "0"}
+	}
+	return n, nil
+}
+
+// ParseSubnet reads an IPv4 CIDR of /8 to /30 and rejects host bits
+// (192.168.100.1/24 → "did you mean 192.168.100.0/24?")
+func ParseSubnet(cidr string) (netip.Prefix, error) {
+	p, err := netip.ParsePrefix(strings.TrimSpace(cidr))
+	if err != nil || !p.Addr().Is4() {
+		return netip.Prefix{}, fmt.Errorf("invalid subnet %q (IPv4 CIDR such as 192.168.100.0/24)", cidr)
+	}
+	if p.Bits() < 8 || p.Bits() > 30 {
+		return netip.Prefix{}, fmt.Errorf("subnet %s: prefix must be between /8 and /30", p)
+	}
+	if p.Masked() != p {
+		return netip.Prefix{}, fmt.Errorf("subnet %s has host bits set (did you mean %s?)", p, p.Masked())
+	}
+	return p, nil
+}
+
+// DefaultRange is the largest DHCP range of subnet: everything after the
+// host address up to the last address before the broadcast
+func DefaultRange(subnet netip.Prefix) (start, end netip.Addr) {
+	return subnet.Addr().Next().Next(), broadcast(subnet).Prev()
+}
+
+func checkRange(subnet netip.Prefix, host netip.Addr, s, e string) (netip.Addr, netip.Addr, error) {
+	start, err1 := netip.ParseAddr(strings.TrimSpace(s))
+	end, err2 := netip.ParseAddr(strings.TrimSpace(e))
+	if err1 != nil || err2 != nil || !start.Is4() || !end.Is4() {
+		return start, end, fmt.Errorf("invalid DHCP range %q-%q (two IPv4 addresses)", s, e)
+	}
+	if !subnet.Contains(start) || !subnet.Contains(end) {
+		return start, end, fmt.Errorf("DHCP range %s-%s is not inside %s", start, end, subnet)
+	}
+	if end.Less(start) {
+		return start, end, fmt.Errorf("DHCP range %s-%s: start is after end", start, end)
+	}
+	bc := broadcast(subnet)
+	for _, a := range []netip.Addr{subnet.Addr(), host, bc} {
+		if !a.Less(start) && !end.Less(a) {
+			return start, end, fmt.Errorf("DHCP range %s-%s contains %s (network, host or broadcast address)", start, end, a)
+		}
+	}
+	return start, end, nil
+}
+
+func broadcast(p netip.Prefix) netip.Addr {
+	a := p.Masked().Addr().As4()
+	host := uint32(1)<<(32-p.Bits()) - 1
+	v := uint32(a[0])<<24 | uint32(a[1])<<16 | uint32(a[2])<<8 | uint32(a[3]) | host
+	return netip.AddrFrom4([4]byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)})
+}
+
+// FreeSubnet returns the first 192.168.<x>.0/24 (x = 100…254) that
+// overlaps none of used – the suggestion of the "new network" form
+func FreeSubnet(used []netip.Prefix) (netip.Prefix, bool) {
+	for x := 100; x <= 254; x++ {
+		p := netip.PrefixFrom(netip.AddrFrom4([4]byte{192, 168, byte(x), 0}), 24)
+		free := true
+		for _, u := range used {
+			if p.Overlaps(u) {
+				free = false
+				break
+			}
+		}
+		if free {
+			return p, true
+		}
+	}
+	return netip.Prefix{}, false
+}
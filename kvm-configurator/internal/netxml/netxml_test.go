@@ -0,0 +1,203 @@
+// netxml/netxml_test.go
+// last modified: Oct 16 2026
+package netxml
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	n, err := Build(Spec{Name: "lab", Subnet: "10.20.0.0/16", DHCPStart: "10.20.1.0", DHCPEnd: "10.20.1.255", Bridge: "virbr-lab"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := n.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<network>
+  <name>lab</name>
+  <forward mode="nat"></forward>
+  <bridge name="virbr-lab" stp="on" delay="0"></bridge>
+  <ip address="10.20.0.1" prefix="16">
+    <dhcp>
+      <range start="10.20.1.0" end="10.20.1.255"></range>
+    </dhcp>
+  </ip>
+</network>
+`
+	if string(raw) != want {
+		t.Errorf("Build =\n%s\nwant\n%s", raw, want)
+	}
+
+	// read back like net-dumpxml output
+	back, err := Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back.Mode() != ModeNAT || back.Subnet() != "10.20.0.0/16" || back.DHCPRange() != "10.20.1.0-10.20.1.255" {
+		t.Errorf("parsed back: mode %s, subnet %s, range %s", back.Mode(), back.Subnet(), back.DHCPRange())
+	}
+}
+
+func TestBuildIsolatedWithoutDHCP(t *testing.T) {
+	n, err := Build(Spec{Name: "backend.net", Mode: ModeIsolated, Subnet: "192.168.150.0/30"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Forward != nil || n.Bridge != nil || n.Mode() != ModeIsolated {
+		t.Errorf("isolated network has forward %+v, bridge %+v", n.Forward, n.Bridge)
+	}
+	if len(n.IPs) != 1 || n.IPs[0].Address != "192.168.150.1" || n.IPs[0].DHCP != nil {
+		t.Errorf("IPs = %+v, want host 192.168.150.1 without DHCP", n.IPs)
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	ok := Spec{Name: "lab", Subnet: "192.168.100.0/24", DHCPStart: "192.168.100.2", DHCPEnd: "192.168.100.254"}
+	tests := []struct {
+		name    string
+		change  func(*Spec)
+		wantErr string
+	}{
+		{"name", func(s *Spec) { s.Name = "my lab" }, "invalid network name"},
+		{"empty name", func(s *Spec) { s.Name = "" }, "invalid network name"},
+		{"bridge too long", func(s *Spec) { s.Bridge = "virbr-laboratory" }, "invalid bridge name"},
+		{"mode", func(s *Spec) { s.Mode = "route" }, "unknown network mode"},
+		{"IPv6", func(s *Spec) { s.Subnet = "fd00::/64" }, "invalid subnet"},
+		{"host bits", func(s *Spec) { s.Subnet = "192.168.100.1/24" }, "did you mean 192.168.100.0/24"},
+		{"only start", func(s *Spec) { s.DHCPEnd = "" }, "invalid DHCP range"},
+	}
+	for _, tt := range tests {
+		s := ok
+		tt.change(&s)
+		if _, err := Build(s); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+	if _, err := Build(ok); err != nil {
+		t.Errorf("valid spec: %v", err)
+	}
+}
+
+func TestParseSubnetPrefixLimits(t *testing.T) {
+	tests := []struct {
+		cidr string
+		ok   bool
+	}{
+		{"10.0.0.0/7", false},
+		{"10.0.0.0/8", true},
+		{"172.16.0.0/12", true},
+		{"192.168.100.0/24", true},
+		{"192.168.100.4/30", true},
+		{"192.168.100.4/31", false},
+		{"192.168.100.4/32", false},
+		{"192.168.100.4/29", false}, // host bits set: .0/29
+		{" 192.168.100.0/24 ", true},
+		{"192.168.100.0", false},
+	}
+	for _, tt := range tests {
+		if _, err := ParseSubnet(tt.cidr); (err == nil) != tt.ok {
+			t.Errorf("ParseSubnet(%q) = %v, want ok=%v", tt.cidr, err, tt.ok)
+		}
+	}
+}
+
+func TestCheckRange(t *testing.T) {
+	subnet := netip.MustParsePrefix("192.168.100.0/24")
+	host := netip.MustParseAddr("192.168.100.1")
+	tests := []struct {
+		start, end, wantErr string // wantErr empty: valid
+	}{
+		{"192.168.100.2", "192.168.100.254", ""},
+		{"192.168.100.50", "192.168.100.50", ""}, // a single address
+		{"192.168.100.0", "192.168.100.20", "192.168.100.0 (network"},
+		{"192.168.100.1", "192.168.100.20", "contains 192.168.100.1"},
+		{"192.168.100.200", "192.168.100.255", "contains 192.168.100.255"},
+		{"192.168.100.200", "192.168.100.100", "start is after end"},
+		{"192.168.100.2", "192.168.101.10", "not inside"},
+		{"10.0.0.2", "10.0.0.10", "not inside"},
+		{"192.168.100.2", "", "invalid DHCP range"},
+		{"fd00::2", "fd00::10", "invalid DHCP range"},
+	}
+	for _, tt := range tests {
+		_, _, err := checkRange(subnet, host, tt.start, tt.end)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("checkRange(%s-%s): %v", tt.start, tt.end, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("checkRange(%s-%s) error = %v, want %q", tt.start, tt.end, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDefaultRange(t *testing.T) {
+	for cidr, want := range map[string]string{
+		"192.168.122.0/24": "192.168.122.2-192.168.122.254",
+		"10.0.0.0/8":       "10.0.0.2-10.255.255.254",
+		"192.168.100.4/30": "192.168.100.6-192.168.100.6",
+	} {
+		subnet := netip.MustParsePrefix(cidr)
+		start, end := DefaultRange(subnet)
+		if got := start.String() + "-" + end.String(); got != want {
+			t.Errorf("DefaultRange(%s) = %s, want %s", cidr, got, want)
+		}
+		// the default range always passes the check
+		if _, _, err := checkRange(subnet, subnet.Addr().Next(), start.String(), end.String()); err != nil {
+			t.Errorf("DefaultRange(%s): %v", cidr, err)
+		}
+	}
+}
+
+func TestFreeSubnet(t *testing.T) {
+	prefixes := func(cidrs ...string) []netip.Prefix {
+		var out []netip.Prefix
+		for _, c := range cidrs {
+			out = append(out, netip.MustParsePrefix(c))
+		}
+		return out
+	}
+	tests := []struct {
+		used []string
+		want string // empty: none free
+	}{
+		{nil, "192.168.100.0/24"},
+		{[]string{"192.168.122.0/24", "192.168.100.0/24", "192.168.101.0/24"}, "192.168.102.0/24"},
+		{[]string{"192.168.100.128/25"}, "192.168.101.0/24"}, // partly used is used
+		{[]string{"192.168.96.0/20"}, "192.168.112.0/24"},    // 96…111 covered
+		{[]string{"192.168.0.0/16"}, ""},
+		{[]string{"10.0.0.0/8", "172.16.0.0/12"}, "192.168.100.0/24"},
+	}
+	for _, tt := range tests {
+		got, ok := FreeSubnet(prefixes(tt.used...))
+		if tt.want == "" {
+			if ok {
+				t.Errorf("FreeSubnet(%v) = %s, want none", tt.used, got)
+			}
+			continue
+		}
+		if !ok || got.String() != tt.want {
+			t.Errorf("FreeSubnet(%v) = %s, %v, want %s", tt.used, got, ok, tt.want)
+		}
+	}
+}
+
+func TestSubnetsFromNetmask(t *testing.T) {
+	n, err := Parse([]byte(`<network><name>default</name><forward/>
+<ip address="192.168.122.1" netmask="255.255.255.0"><dhcp><range start="192.168.122.2" end="192.168.122.254"/></dhcp></ip>
+<ip family="ipv6" address="fd00::1" prefix="64"/></network>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := n.Subnets(); len(got) != 1 || got[0].String() != "192.168.122.0/24" {
+		t.Errorf("Subnets = %v, want [192.168.122.0/24]", got)
+	}
+	if n.Mode() != ModeNAT {
+		t.Errorf("<forward/> mode = %s, want nat", n.Mode())
+	}
+}
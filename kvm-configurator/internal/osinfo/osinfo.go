@@ -0,0 +1,360 @@
+// osinfo/osinfo.go
+// last modified: Oct 16 2026
+package osinfo
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrNoDatabase is returned when no osinfo-db is installed
+var ErrNoDatabase = errors.New("no osinfo database found (install osinfo-db)")
+
+// Generic is accepted as id without a database entry (virt-install's fallback)
+const Generic = "generic"
+
+/*
+DefaultDirs returns the osinfo-db locations in the order libosinfo reads
+them; later directories override entries of earlier ones:
+
+	/usr/share/osinfo (or $OSINFO_SYSTEM_DIR), /usr/share/libosinfo/db (old layout),
+	/etc/osinfo (or $OSINFO_LOCAL_DIR), ~/.config/osinfo (or $OSINFO_USER_DIR)
+*/
+func DefaultDirs() []string {
+	dir := func(env, def string) string {
+		if v := os.Getenv(env); v != "" {
+			return v
+		}
+		return def
+	}
+	user := ""
+	if cfg, err := os.UserConfigDir(); err == nil {
+		user = filepath.Join(cfg, "osinfo")
+	}
+	return []string{
+		dir("OSINFO_SYSTEM_DIR", "/usr/share/osinfo"),
+		"/usr/share/libosinfo/db",
+		dir("OSINFO_LOCAL_DIR", "/etc/osinfo"),
+		dir("OSINFO_USER_DIR", user),
+	}
+}
+
+// OS is one operating system of the database
+type OS struct {
+	ID       string   // full id, e.g. http://archlinux.org/archlinux/rolling
+	ShortIDs []string // os-variant names; the first is the canonical one
+	Name     string
+	Version  string
+	Vendor   string
+	Family   string // linux, winnt, freebsd, …
+	Distro   string
+	EOL      string // end-of-life date (YYYY-MM-DD), empty if unknown
+
+	Minimum     Resources // what the installer needs at least
+	Recommended Resources // what the vendor recommends
+	derivesFrom string    // id of the OS the resources are inherited from
+}
+
+// Resources are the hardware requirements of an OS; 0 = not recorded
+type Resources struct {
+	CPUs       int
+	RAMMiB     int
+	StorageGiB int
+}
+
+/*
+Shortfalls lists every value below r (the minimum), e.g.
+"RAM 1024 MiB < 4096 MiB"; values ≤ 0 are not checked.
+*/
+func (r Resources) Shortfalls(ramMiB, cpus, diskGiB int) []string {
+	var out []string
+	if r.RAMMiB > 0 && ramMiB > 0 && ramMiB < r.RAMMiB {
+		out = append(out, fmt.Sprintf("RAM %d MiB < %d MiB", ramMiB, r.RAMMiB))
+	}
+	if r.CPUs > 0 && cpus > 0 && cpus < r.CPUs {
+		out = append(out, fmt.Sprintf("vCPU %d < %d", cpus, r.CPUs))
+	}
+	if r.StorageGiB > 0 && diskGiB > 0 && diskGiB < r.StorageGiB {
+		out = append(out, fmt.Sprintf("disk %d GB < %d GB", diskGiB, r.StorageGiB))
+	}
+	return out
+}
+
+// Warnings are the Shortfalls of a VM against the minimum of o, each
+// naming the OS: "RAM 1024 MiB < 4096 MiB (minimum of Microsoft Windows 11)"
+func (o OS) Warnings(ramMiB, cpus, diskGiB int) []string {
+	var out []string
+	for _, s := range o.Minimum.Shortfalls(ramMiB, cpus, diskGiB) {
+		out = append(out, fmt.Sprintf("%s (minimum of %s)", s, o.Name))
+	}
+	return out
+}
+
+// ShortID is the canonical os-variant of o
+func (o OS) ShortID() string {
+	if len(o.ShortIDs) == 0 {
+		return ""
+	}
+	return o.ShortIDs[0]
+}
+
+// DB holds all operating systems found in the osinfo-db directories
+type DB struct {
+	OSes    []OS           // sorted by short id
+	byShort map[string]int // every short id → index in OSes
+}
+
+// xml layout of an osinfo-db file (only what we use)
+type dbFile struct {
+	OSes []struct {
+		ID       string   `xml:"id,attr"`
+		ShortIDs []string `xml:"short-id"`
+		Name     string   `xml:"name"`
+		Version  string   `xml:"version"`
+		Vendor   string   `xml:"vendor"`
+		Family   string   `xml:"family"`
+		Distro   string   `xml:"distro"`
+		EOL      string   `xml:"eol-date"`
+		Derives  struct {
+			ID string `xml:"id,attr"`
+		} `xml:"derives-from"`
+		Resources []struct {
+			Arch        string      `xml:"arch,attr"`
+			Minimum     resourceXML `xml:"minimum"`
+			Recommended resourceXML `xml:"recommended"`
+		} `xml:"resources"`
+	} `xml:"os"`
+}
+
+type resourceXML struct {
+	CPUs    int   `xml:"n-cpus"`
+	RAM     int64 `xml:"ram"`     // bytes
+	Storage int64 `xml:"storage"` // bytes
+}
+
+// resources converts the bytes of the database; ramMiB and storage are
+// rounded up so a minimum is never understated
+func (x resourceXML) resources() Resources {
+	const mib, gib = 1 << 20, 1 << 30
+	r := Resources{CPUs: max(x.CPUs, 0)}
+	if x.RAM > 0 {
+		r.RAMMiB = int((x.RAM + mib - 1) / mib)
+	}
+	if x.Storage > 0 {
+		r.StorageGiB = int((x.Storage + gib - 1) / gib)
+	}
+	return r
+}
+
+// orElse fills the unset values of r from o
+func (r Resources) orElse(o Resources) Resources {
+	if r.CPUs == 0 {
+		r.CPUs = o.CPUs
+	}
+	if r.RAMMiB == 0 {
+		r.RAMMiB = o.RAMMiB
+	}
+	if r.StorageGiB == 0 {
+		r.StorageGiB = o.StorageGiB
+	}
+	return r
+}
+
+var (
+	defaultOnce sync.Once
+	defaultDB   *DB
+	defaultErr  error
+)
+
+// Default loads the database from DefaultDirs once and caches it
+func Default() (*DB, error) {
+	defaultOnce.Do(func() { defaultDB, defaultErr = Load(DefaultDirs()...) })
+	return defaultDB, defaultErr
+}
+
+/*
+Load reads every os/**.xml below dirs. Missing directories are skipped;
+if no OS is found at all, ErrNoDatabase is returned. Files that cannot be
+parsed are skipped as well – one broken local override must not hide the
+whole database.
+*/
+func Load(dirs ...string) (*DB, error) {
+	byID := map[string]OS{}
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		root := filepath.Join(dir, "os")
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || filepath.Ext(path) != ".xml" {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil
+			}
+			var f dbFile
+			if xml.Unmarshal(data, &f) != nil {
+				return nil
+			}
+			for _, o := range f.OSes {
+				if len(o.ShortIDs) == 0 {
+					continue
+				}
+				entry := OS{
+					ID: o.ID, ShortIDs: o.ShortIDs,
+					Name: strings.TrimSpace(o.Name), Version: o.Version,
+					Vendor: o.Vendor, Family: o.Family, Distro: o.Distro, EOL: o.EOL,
+					derivesFrom: o.Derives.ID,
+				}
+				// x86_64 values win over the ones for all architectures
+				for _, arch := range []string{"x86_64", "all"} {
+					for _, res := range o.Resources {
+						if res.Arch == arch {
+							entry.Minimum = entry.Minimum.orElse(res.Minimum.resources())
+							entry.Recommended = entry.Recommended.orElse(res.Recommended.resources())
+						}
+					}
+				}
+				byID[o.ID] = entry
+			}
+			return nil
+		})
+	}
+	if len(byID) == 0 {
+		return nil, ErrNoDatabase
+	}
+
+	db := &DB{byShort: map[string]int{}}
+	for _, o := range byID {
+		// derived OSes (e.g. ubuntu flavours) often record no resources
+		for p, depth := o.derivesFrom, 0; p != "" && depth < 10; depth++ {
+			parent, ok := byID[p]
+			if !ok {
+				break
+			}
+			o.Minimum = o.Minimum.orElse(parent.Minimum)
+			o.Recommended = o.Recommended.orElse(parent.Recommended)
+			p = parent.derivesFrom
+		}
+		db.OSes = append(db.OSes, o)
+	}
+	sort.Slice(db.OSes, func(i, j int) bool { return db.OSes[i].ShortID() < db.OSes[j].ShortID() })
+	for i, o := range db.OSes {
+		for _, s := range o.ShortIDs {
+			if _, dup := db.byShort[s]; !dup {
+				db.byShort[s] = i
+			}
+		}
+	}
+	return db, nil
+}
+
+// Lookup finds an OS by any of its short ids
+func (db *DB) Lookup(shortID string) (OS, bool) {
+	i, ok := db.byShort[strings.TrimSpace(shortID)]
+	if !ok {
+		return OS{}, false
+	}
+	return db.OSes[i], true
+}
+
+// Check returns an error with suggestions if id is not a known os-variant
+func (db *DB) Check(id string) error {
+	id = strings.TrimSpace(id)
+	if id == Generic {
+		return nil
+	}
+	if _, ok := db.Lookup(id); ok {
+		return nil
+	}
+	if s := db.Suggest(id, 3); len(s) > 0 {
+		return fmt.Errorf("unknown os-variant %q (did you mean %s?)", id, strings.Join(s, ", "))
+	}
+	return fmt.Errorf("unknown os-variant %q", id)
+}
+
+/*
+Search returns the OSes whose short id, name or vendor contain every word
+of query (case-insensitive); an empty query returns all of them.
+*/
+func (db *DB) Search(query string) []OS {
+	words := strings.Fields(strings.ToLower(query))
+	var out []OS
+	for _, o := range db.OSes {
+		text := strings.ToLower(strings.Join(append([]string{o.Name, o.Vendor}, o.ShortIDs...), " "))
+		match := true
+		for _, w := range words {
+			if !strings.Contains(text, w) {
+				match = false
+				break
+			}
+		}
+		if match {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// Suggest returns up to n short ids close to a mistyped id, best first
+func (db *DB) Suggest(id string, n int) []string {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if id == "" {
+		return nil
+	}
+	limit := max(2, len(id)/3)
+	type hit struct {
+		id   string
+		dist int
+	}
+	var hits []hit
+	for s := range db.byShort {
+		d := distance(id, s)
+		if strings.HasPrefix(s, id) {
+			d = min(d, 1) // "debian" → debian12, debian13
+		}
+		if d <= limit {
+			hits = append(hits, hit{s, d})
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].dist != hits[j].dist {
+			return hits[i].dist < hits[j].dist
+		}
+		return hits[i].id > hits[j].id // newer versions first (debian13 before debian12)
+	})
+	var out []string
+	for i := 0; i < len(hits) && i < n; i++ {
+		out = append(out, hits[i].id)
+	}
+	return out
+}
+
+// distance is the Levenshtein distance of a and b
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
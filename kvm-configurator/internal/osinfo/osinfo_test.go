@@ -0,0 +1,174 @@
+// osinfo/osinfo_test.go
+// last modified: Oct 16 2026
+package osinfo
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// testDB loads testdata/system with the overrides of testdata/local
+func testDB(t *testing.T) *DB {
+	t.Helper()
+	db, err := Load("testdata/system", "testdata/missing", "", "testdata/local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestLoad(t *testing.T) {
+	db := testDB(t)
+
+	// broken files and entries without short id are skipped
+	var ids []string
+	for _, o := range db.OSes {
+		ids = append(ids, o.ShortID())
+	}
+	want := []string{"debian12", "debian13", "freedos1.3", "kubuntu24.04", "ubuntu24.04", "win11"}
+	if !slices.Equal(ids, want) {
+		t.Errorf("short ids %v, want %v", ids, want)
+	}
+
+	o, ok := db.Lookup(" trixie ")
+	if !ok {
+		t.Fatal("trixie not found")
+	}
+	if o.ID != "http://debian.org/debian/13" || o.Name != "Debian 13 (local)" || o.Family != "linux" || o.Distro != "debian" {
+		t.Errorf("debian13 = %+v, want the local override", o)
+	}
+	if o, _ := db.Lookup("debian12"); o.EOL != "2028-06-30" || o.Vendor != "Debian Project" {
+		t.Errorf("debian12 = %+v", o)
+	}
+
+	if _, err := Load("testdata/missing", "testdata/system/os"); !errors.Is(err, ErrNoDatabase) {
+		t.Errorf("Load without os directory: %v", err)
+	}
+}
+
+func TestResources(t *testing.T) {
+	db := testDB(t)
+	for _, tc := range []struct {
+		id               string
+		min, recommended Resources
+	}{
+		{"debian12", Resources{1, 1024, 10}, Resources{2, 2048, 20}},
+		// RAM of its own (rounded up to MiB), the rest derives from debian12
+		{"debian13", Resources{1, 1431, 10}, Resources{2, 2048, 20}},
+		// x86_64 values first, then those for all architectures; aarch64 is ignored
+		{"ubuntu24.04", Resources{1, 4096, 25}, Resources{CPUs: 2}},
+		// no resources at all: everything from ubuntu24.04
+		{"kubuntu24.04", Resources{1, 4096, 25}, Resources{CPUs: 2}},
+		{"win11", Resources{2, 4096, 64}, Resources{2, 8192, 64}},
+		{"freedos1.3", Resources{}, Resources{}},
+	} {
+		t.Run(tc.id, func(t *testing.T) {
+			o, ok := db.Lookup(tc.id)
+			if !ok {
+				t.Fatal("not found")
+			}
+			if o.Minimum != tc.min {
+				t.Errorf("minimum %+v, want %+v", o.Minimum, tc.min)
+			}
+			if o.Recommended != tc.recommended {
+				t.Errorf("recommended %+v, want %+v", o.Recommended, tc.recommended)
+			}
+		})
+	}
+}
+
+func TestShortfalls(t *testing.T) {
+	minimum := Resources{CPUs: 2, RAMMiB: 4096, StorageGiB: 64}
+	for _, tc := range []struct {
+		name            string
+		ram, cpus, disk int
+		want            []string
+	}{
+		{"enough", 4096, 2, 64, nil},
+		{"all below", 1024, 1, 20, []string{"RAM 1024 MiB < 4096 MiB", "vCPU 1 < 2", "disk 20 GB < 64 GB"}},
+		{"RAM only", 2048, 4, 100, []string{"RAM 2048 MiB < 4096 MiB"}},
+		{"unset values are not checked", 0, 0, 0, nil},
+	} {
+		if got := minimum.Shortfalls(tc.ram, tc.cpus, tc.disk); !slices.Equal(got, tc.want) {
+			t.Errorf("%s: Shortfalls = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+	if got := (Resources{}).Shortfalls(512, 1, 1); got != nil {
+		t.Errorf("no minimum recorded: Shortfalls = %q", got)
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	db := testDB(t)
+	for _, tc := range []struct {
+		id              string
+		ram, cpus, disk int
+		want            []string
+	}{
+		{"win11", 1024, 1, 64, []string{
+			"RAM 1024 MiB < 4096 MiB (minimum of Microsoft Windows 11)",
+			"vCPU 1 < 2 (minimum of Microsoft Windows 11)",
+		}},
+		{"win11", 4096, 2, 64, nil},
+		// derived values count like the OS's own
+		{"kubuntu24.04", 2048, 1, 10, []string{
+			"RAM 2048 MiB < 4096 MiB (minimum of Kubuntu 24.04 LTS)",
+			"disk 10 GB < 25 GB (minimum of Kubuntu 24.04 LTS)",
+		}},
+		// no resource data: nothing to warn about, not "< 0"
+		{"freedos1.3", 1, 1, 1, nil},
+	} {
+		o, ok := db.Lookup(tc.id)
+		if !ok {
+			t.Fatalf("%s not found", tc.id)
+		}
+		if got := o.Warnings(tc.ram, tc.cpus, tc.disk); !slices.Equal(got, tc.want) {
+			t.Errorf("%s with %d MiB, %d vCPU, %d GB: %q, want %q", tc.id, tc.ram, tc.cpus, tc.disk, got, tc.want)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	db := testDB(t)
+	for _, tc := range []struct {
+		id   string
+		want []string
+	}{
+		{"debain13", []string{"debian13"}},           // debian12 is three edits away
+		{"debian", []string{"debian13", "debian12"}}, // prefix, newest first
+		{"ubuntu2404", []string{"ubuntu24.04", "kubuntu24.04"}},
+		{"Win11 ", []string{"win11"}},
+		{"solaris", nil},
+		{"", nil},
+	} {
+		if got := db.Suggest(tc.id, 3); !slices.Equal(got, tc.want) {
+			t.Errorf("Suggest(%q) = %v, want %v", tc.id, got, tc.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	db := testDB(t)
+	for _, tc := range []struct {
+		id, want string
+	}{
+		{"debian13", ""},
+		{"trixie", ""},
+		{Generic, ""},
+		{"debain13", `unknown os-variant "debain13" (did you mean debian13?)`},
+		{"solaris", `unknown os-variant "solaris"`},
+	} {
+		err := db.Check(tc.id)
+		if got := errString(err); got != tc.want {
+			t.Errorf("Check(%q) = %q, want %q", tc.id, got, tc.want)
+		}
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,175 @@
+// poolxml/poolxml.go
+// last modified: Oct 16 2026
+package poolxml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// TypeDir is the pool type the storage menu creates
+const TypeDir = "dir"
+
+// fileTypes are the pool types whose volumes are plain image files –
+// the ones a new VM disk can be placed in
+var fileTypes = []string{TypeDir, "fs", "netfs"}
+
+/*
+Pool is a libvirt storage pool definition
+(https://libvirt.org/formatstorage.html) – type, name and target path;
+everything else a pool may carry (source, permissions, …) is not needed
+by the storage menu.
+*/
+type Pool struct {
+	XMLName xml.Name `xml:"pool"`
+	Type    string   `xml:"type,attr"`
+	Name    string   `xml:"name"`
+	UUID    string   `xml:"uuid,omitempty"`
+	Target  *Target  `xml:"target"`
+}
+
+type Target struct {
+	Path string `xml:"path"`
+}
+
+// Parse reads a pool definition (virsh pool-dumpxml)
+func Parse(data []byte) (*Pool, error) {
+	var p Pool
+	if err := xml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parse pool XML: %w", err)
+	}
+	return &p, nil
+}
+
+// Marshal renders the definition for pool-define
+func (p *Pool) Marshal() ([]byte, error) {
+	out, err := xml.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal pool %s: %w", p.Name, err)
+	}
+	return append(out, '\n'), nil
+}
+
+// Path is the target directory (or device) of the pool
+func (p *Pool) Path() string {
+	if p.Target == nil {
+		return ""
+	}
+	return p.Target.Path
+}
+
+// FileBased reports whether the volumes of the pool are image files
+func (p *Pool) FileBased() bool {
+	return slices.Contains(fileTypes, p.Type) && p.Path() != ""
+}
+
+var nameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// CheckName accepts pool and volume names without slashes or blanks
+func CheckName(name string) error {
+	if !nameRe.MatchString(name) {
+		return fmt.Errorf("invalid name %q (letters, digits, . _ -)", name)
+	}
+	return nil
+}
+
+// DirPool builds a directory pool; path must be absolute
+func DirPool(name, path string) (*Pool, error) {
+	if err := CheckName(name); err != nil {
+		return nil, fmt.Errorf("pool: %w", err)
+	}
+	if !filepath.IsAbs(path) {
+		return nil, fmt.Errorf("pool path %q must be absolute", path)
+	}
+	return &Pool{Type: TypeDir, Name: name, Target: &Target{Path: filepath.Clean(path)}}, nil
+}
+
+// Volume is a storage volume definition as vol-create takes it
+type Volume struct {
+	XMLName  xml.Name      `xml:"volume"`
+	Name     string        `xml:"name"`
+	Capacity Capacity      `xml:"capacity"`
+	Target   *VolumeTarget `xml:"target"`
+}
+
+type Capacity struct {
+	Unit  string `xml:"unit,attr,omitempty"` // bytes if empty
+	Value uint64 `xml:",chardata"`
+}
+
+type VolumeTarget struct {
+	Path   string  `xml:"path,omitempty"`
+	Format *Format `xml:"format"`
+}
+
+type Format struct {
+	Type string `xml:"type,attr"`
+}
+
+// NewVolume is a volume of sizeGiB in the given image format (qcow2, raw)
+func NewVolume(name string, sizeGiB int, format string) *Volume {
+	return &Volume{
+		Name:     name,
+		Capacity: Capacity{Unit: "G", Value: uint64(sizeGiB)},
+		Target:   &VolumeTarget{Format: &Format{Type: format}},
+	}
+}
+
+// ParseVolume reads a volume definition
+func ParseVolume(data []byte) (*Volume, error) {
+	var v Volume
+	if err := xml.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("parse volume XML: %w", err)
+	}
+	return &v, nil
+}
+
+// Marshal renders the definition for vol-create
+func (v *Volume) Marshal() ([]byte, error) {
+	out, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal volume %s: %w", v.Name, err)
+	}
+	return append(out, '\n'), nil
+}
+
+// units of <capacity> (libvirt accepts both the short and the long spelling)
+var units = map[string]uint64{
+	"": 1, "b": 1, "bytes": 1,
+	"k": 1 << 10, "kib": 1 << 10, "kb": 1000,
+	"m": 1 << 20, "mib": 1 << 20, "mb": 1000 * 1000,
+	"g": 1 << 30, "gib": 1 << 30, "gb": 1000 * 1000 * 1000,
+	"t": 1 << 40, "tib": 1 << 40, "tb": 1000 * 1000 * 1000 * 1000,
+}
+
+// Bytes is the capacity in bytes (0 for an unknown unit)
+func (v *Volume) Bytes() uint64 {
+	return v.Capacity.Value * units[strings.ToLower(v.Capacity.Unit)]
+}
+
+// FormatType is the image format of the volume ("" if none is given)
+func (v *Volume) FormatType() string {
+	if v.Target == nil || v.Target.Format == nil {
+		return ""
+	}
+	return v.Target.Format.Type
+}
+
+// Size renders bytes the way virsh does ("20.00 GiB")
+func Size(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return strconv.FormatUint(b, 10) + " B"
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit && exp < 4; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.2f %ciB", float64(b)/float64(div), "KMGTP"[exp])
+}
@@ -236,7 +236,7 @@ func NormalizeStatus(s string) string {
 	switch strings.ToLower(strings.TrimSpace(s)) {
 	case "running", "laufend":
 		return "running"
-	case "shut", "off", "shutoff", "ausgeschaltet":
+	case "shut", "off", "shutoff", "shut off", "ausgeschaltet":
 		return "shut off"
 	default:
 		// unknown – keep as‑is so we never treat it as “running”
@@ -0,0 +1,213 @@
+// ui/cloudinit.go
+// last modified: Oct 16 2026
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	// internal
+	"configurator/internal/cloudinit"
+	"configurator/internal/model"
+	"configurator/internal/style"
+	"configurator/internal/utils"
+)
+
+/*
+editCloudInit – the VM boots a cloud image (Debian genericcloud, Ubuntu
+cloudimg, Fedora Cloud …) and is configured on its first boot by a
+generated NoCloud seed: host name, user with SSH keys, packages and an
+optional static address. The system disk becomes a linked clone of the
+image (or stays the attached image), the ISO is dropped.
+*/
+func (e *Editor) editCloudInit() {
+	fmt.Fprintln(e.out, style.BoxCenter(51, []string{"CLOUD-INIT"}))
+	primary := e.cfg.PrimaryDisk()
+	if primary == nil {
+		e.cfg.Disks = append(e.cfg.Disks, model.DiskSpec{Name: "system", Bus: "virtio"})
+		primary = e.cfg.PrimaryDisk()
+		primary.SetLocation(e.defaultDisk)
+	}
+	if primary.Existing {
+		fmt.Fprintln(e.out, style.Hint("Cloud image: the attached disk "+primary.Path))
+	} else {
+		primary.Backing = askBackingImage(e.in, e.out, e.pools, primary.Backing)
+		if primary.Backing == "" {
+			e.cfg.CloudInit = nil
+			fmt.Fprintln(e.out, style.Ok("Cloud-init off – the system disk is a new empty image"))
+			return
+		}
+	}
+
+	cur := e.cfg.CloudInit
+	if cur == nil {
+		cur = &cloudinit.Config{Hostname: e.cfg.Name, User: cloudinit.DefaultUser(), SSHKeys: cloudinit.LocalKeys()}
+		if cloudinit.CheckHostname(cur.Hostname) != nil {
+			cur.Hostname = ""
+		}
+	}
+	ci := &cloudinit.Config{}
+	var ok bool
+	if ci.Hostname, ok = e.askCloud("Hostname", cur.Hostname, cloudinit.CheckHostname); !ok {
+		return
+	}
+	if ci.User, ok = e.askCloud("User (sudo, SSH key login only)", cur.User, cloudinit.CheckUser); !ok {
+		return
+	}
+	if ci.SSHKeys, ok = e.askKeys(cur.SSHKeys); !ok {
+		return
+	}
+	pkgs, ok := e.askCloud("Packages (space separated, - for none)", dashIfEmpty(strings.Join(cur.Packages, " ")), nil)
+	if !ok {
+		return
+	}
+	if pkgs != "-" {
+		ci.Packages = cloudinit.SplitList(pkgs)
+	}
+	if ci.Network, ok = e.askStatic(cur.Network); !ok {
+		return
+	}
+	if err := ci.Validate(); err != nil {
+		fmt.Fprintln(e.out, style.Err("Cloud-init: "+err.Error()))
+		return
+	}
+	e.cfg.CloudInit = ci
+	fmt.Fprintln(e.out, style.Ok("Cloud-init: "+ci.Summary()))
+	if e.cfg.ISOPath != "" {
+		e.cfg.ISOPath = ""
+		fmt.Fprintln(e.out, style.Hint("The VM boots from the cloud image – ISO removed ([7] adds one again)"))
+	}
+}
+
+// askCloud asks until check accepts the answer; false if the input ended
+func (e *Editor) askCloud(label, def string, check func(string) error) (string, bool) {
+	for {
+		ans, err := utils.Ask(e.in, e.out, style.PromptMsg(label), def)
+		if err != nil {
+			return "", false
+		}
+		if ans == "" {
+			ans = def
+		}
+		if check == nil {
+			return ans, true
+		}
+		if err := check(ans); err != nil {
+			fmt.Fprintln(e.out, style.Err(err.Error()))
+			continue
+		}
+		return ans, true
+	}
+}
+
+// askKeys – ENTER keeps the listed keys, otherwise public keys or key
+// files separated by ';'
+func (e *Editor) askKeys(cur []string) ([]string, bool) {
+	for _, k := range cur {
+		fmt.Fprintln(e.out, style.Hint("  "+keyComment(k)))
+	}
+	def := fmt.Sprintf("%d keys", len(cur))
+	for {
+		ans, err := utils.Ask(e.in, e.out, style.PromptMsg("SSH public keys (files or keys separated by ';')"), def)
+		if err != nil {
+			return nil, false
+		}
+		if ans == "" || ans == def {
+			if len(cur) > 0 {
+				return cur, true
+			}
+			fmt.Fprintln(e.out, style.Err("at least one SSH public key is needed – the password login is locked"))
+			continue
+		}
+		var keys []string
+		for part := range strings.SplitSeq(ans, ";") {
+			if strings.TrimSpace(part) == "" {
+				continue
+			}
+			k, err := cloudinit.LoadKey(part)
+			if err != nil {
+				fmt.Fprintln(e.out, style.Err(err.Error()))
+				keys = nil
+				break
+			}
+			keys = append(keys, k)
+		}
+		if len(keys) > 0 {
+			return keys, true
+		}
+	}
+}
+
+// askStatic – "-" (or ENTER on a DHCP VM) keeps DHCP
+func (e *Editor) askStatic(cur *cloudinit.Static) (*cloudinit.Static, bool) {
+	var addr, gw, dns string
+	if cur != nil {
+		addr = cur.Address.String()
+		if cur.Gateway.IsValid() {
+			gw = cur.Gateway.String()
+		}
+		for _, d := range cur.DNS {
+			dns += d.String() + " "
+		}
+	}
+	for {
+		a, ok := e.askCloud("Static IPv4 address in CIDR notation (- for DHCP)", dashIfEmpty(addr), nil)
+		if !ok {
+			return nil, false
+		}
+		if a == "-" {
+			return nil, true
+		}
+		if gw == "" {
+			// the defaults ParseStatic fills in
+			if p, err := cloudinit.ParseStatic(a, "", ""); err == nil {
+				gw = p.Gateway.String()
+			}
+		}
+		g, ok := e.askCloud("Gateway", gw, nil)
+		if !ok {
+			return nil, false
+		}
+		if dns == "" {
+			dns = g
+		}
+		d, ok := e.askCloud("DNS servers", strings.TrimSpace(dns), nil)
+		if !ok {
+			return nil, false
+		}
+		st, err := cloudinit.ParseStatic(a, g, d)
+		if err == nil {
+			err = st.Validate()
+		}
+		if err != nil {
+			fmt.Fprintln(e.out, style.Err(err.Error()))
+			addr, gw, dns = a, g, d
+			continue
+		}
+		return st, true
+	}
+}
+
+// keyComment shows a key by its type and comment ("ssh-ed25519 alice@host")
+func keyComment(key string) string {
+	f := strings.Fields(key)
+	if len(f) > 2 {
+		return f[0] + " " + strings.Join(f[2:], " ")
+	}
+	return f[0]
+}
+
+// cloudInitLabel is the cloud-init column of the menus
+func cloudInitLabel(cfg *model.DomainConfig) string {
+	if cfg.CloudInit == nil {
+		return "-"
+	}
+	return cfg.CloudInit.Summary()
+}
+
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -16,6 +16,14 @@ import (
 // normalize trims whitespace and forces lower‑case
 func normalize(s string) string { return strings.TrimSpace(strings.ToLower(s)) }
 
+// yesNo renders a flag for the menus
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
 // setChoice prompts for a value that must belong to an allow‑list
 // fieldPtr points to the struct field that shall be updated
 func setChoice(r *bufio.Reader, cfg *model.DomainConfig,
@@ -0,0 +1,252 @@
+// ui/network.go
+// last modified: Oct 16 2026
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	// internal
+	"configurator/internal/netspec"
+	"configurator/internal/style"
+	"configurator/internal/utils"
+)
+
+// NetworkChoice is a libvirt network offered by the NIC picker
+type NetworkChoice struct {
+	Name   string
+	Active bool
+}
+
+// NetworkSources is what the NIC picker offers; anything else can still
+// be typed as a spec (bridge=br1, direct=eth0,mode=vepa, …)
+type NetworkSources struct {
+	Networks []NetworkChoice // libvirt networks
+	Bridges  []string        // bridges of the host
+	Devices  []string        // host devices for direct (macvtap) NICs
+}
+
+// editNetwork is the NIC list of the VM: add, edit, delete or no network
+func (e *Editor) editNetwork() {
+	for {
+		nics, err := netspec.Parse(e.cfg.Network)
+		if err != nil {
+			fmt.Fprintln(e.out, style.Err("Network: "+err.Error()+" – starting over with the default network"))
+			nics = []netspec.NIC{{Type: netspec.TypeNetwork, Source: netspec.DefaultNetwork}}
+		}
+
+		fmt.Fprintln(e.out, style.BoxCenter(51, []string{"NETWORK"}))
+		lines := style.MustTableToLines(func(w *tabwriter.Writer) {
+			fmt.Fprintln(w, "No.\tType\tSource\tModel\tMAC")
+			fmt.Fprintln(w, "---\t----\t------\t-----\t---")
+			for i, n := range nics {
+				fmt.Fprintf(w, "%2d\t%s\t%s\t%s\t%s\n", i+1, n.Type, n.Source, nicModel(n), nicMAC(n))
+			}
+		})
+		if len(nics) == 0 {
+			lines = []string{"no network"}
+		}
+		lines = append(lines, "", "[a] Add NIC   [d] Delete NIC   [n] No network", "[No.] Edit NIC   [ENTER] Back")
+		fmt.Fprint(e.out, style.Box(51, lines))
+
+		ans, err := utils.Prompt(e.in, e.out, style.PromptMsg("Selection: "))
+		if err != nil {
+			return
+		}
+		switch normalize(ans) {
+		case "", "0":
+			return
+		case "a":
+			if n, ok := e.pickNIC(netspec.NIC{}); ok {
+				nics = append(nics, n)
+			}
+		case "d":
+			if i, ok := e.nicIndex(len(nics), "Delete NIC number: "); ok {
+				nics = slices.Delete(nics, i, i+1)
+			}
+		case "n":
+			nics = nil
+		default:
+			i, err := strconv.Atoi(ans)
+			if err != nil || i < 1 || i > len(nics) {
+				fmt.Fprintln(e.out, style.Err("Invalid selection!"))
+				continue
+			}
+			if n, ok := e.pickNIC(nics[i-1]); ok {
+				nics[i-1] = n
+			}
+		}
+		e.setNICs(nics)
+	}
+}
+
+// setNICs stores nics as the network setting, unless two share a MAC
+func (e *Editor) setNICs(nics []netspec.NIC) {
+	spec := netspec.Format(nics)
+	if _, err := netspec.Parse(spec); err != nil {
+		fmt.Fprintln(e.out, style.Err("Network: "+err.Error()))
+		return
+	}
+	e.cfg.Network = spec
+}
+
+func (e *Editor) nicIndex(count int, prompt string) (int, bool) {
+	if count == 0 {
+		return 0, false
+	}
+	ans, _ := utils.Prompt(e.in, e.out, style.PromptMsg(prompt))
+	i, err := strconv.Atoi(ans)
+	if err != nil || i < 1 || i > count {
+		if ans != "" {
+			fmt.Fprintln(e.out, style.Err("Invalid selection!"))
+		}
+		return 0, false
+	}
+	return i - 1, true
+}
+
+/*
+pickNIC asks for source, model and MAC of a NIC; cur holds the values of
+the NIC being edited (zero for a new one). The sources are the libvirt
+networks, host bridges and host devices, or a typed spec.
+*/
+func (e *Editor) pickNIC(cur netspec.NIC) (netspec.NIC, bool) {
+	var choices []netspec.NIC
+	lines := style.MustTableToLines(func(w *tabwriter.Writer) {
+		add := func(n netspec.NIC, note string) {
+			choices = append(choices, n)
+			fmt.Fprintf(w, "%2d\t%s\t%s\t%s\n", len(choices), n.Type, n.Source, note)
+		}
+		for _, n := range e.nets.Networks {
+			note := "active"
+			if !n.Active {
+				note = "inactive"
+			}
+			add(netspec.NIC{Type: netspec.TypeNetwork, Source: n.Name}, note)
+		}
+		for _, b := range e.nets.Bridges {
+			add(netspec.NIC{Type: netspec.TypeBridge, Source: b}, "host bridge")
+		}
+		for _, d := range e.nets.Devices {
+			add(netspec.NIC{Type: netspec.TypeDirect, Source: d}, "macvtap")
+		}
+	})
+	if len(choices) == 0 {
+		lines = []string{"no networks or bridges found"}
+	}
+	lines = append(lines, "", "or type e.g. bridge=br1, network=lab, direct=eth0")
+	fmt.Fprint(e.out, style.Box(51, lines))
+
+	def := ""
+	if cur.Type != "" {
+		def = nicSource(cur)
+	}
+	ans, err := utils.Ask(e.in, e.out, "Source (number or spec)", def)
+	if err != nil {
+		return netspec.NIC{}, false
+	}
+	n := cur
+	switch i, convErr := strconv.Atoi(ans); {
+	case ans == "" && cur.Type != "":
+		// keep the source
+	case ans == "":
+		return netspec.NIC{}, false
+	case convErr == nil:
+		if i < 1 || i > len(choices) {
+			fmt.Fprintln(e.out, style.Err("Invalid selection!"))
+			return netspec.NIC{}, false
+		}
+		n.Type, n.Source, n.Mode = choices[i-1].Type, choices[i-1].Source, ""
+	default:
+		parsed, err := netspec.Parse(ans)
+		if err != nil || len(parsed) != 1 {
+			fmt.Fprintln(e.out, style.Err(fmt.Sprintf("Invalid network source %q", ans)))
+			return netspec.NIC{}, false
+		}
+		p := parsed[0]
+		n.Type, n.Source, n.Mode = p.Type, p.Source, p.Mode
+		if p.Model != "" {
+			n.Model = p.Model
+		}
+		if p.MAC != "" {
+			n.MAC = p.MAC
+		}
+	}
+
+	if n.Type == netspec.TypeDirect {
+		mode, _ := utils.Ask(e.in, e.out, "macvtap mode ("+strings.Join(netspec.DirectModes, ", ")+")", nicMode(n))
+		if mode = normalize(mode); mode != "" {
+			if !slices.Contains(netspec.DirectModes, mode) {
+				fmt.Fprintln(e.out, style.Err("Invalid mode "+mode))
+				return netspec.NIC{}, false
+			}
+			n.Mode = mode
+		}
+	} else {
+		n.Mode = ""
+	}
+
+	model, _ := utils.Ask(e.in, e.out, "Model ("+strings.Join(netspec.Models, ", ")+")", nicModel(n))
+	if model = normalize(model); model != "" {
+		if !slices.Contains(netspec.Models, model) {
+			fmt.Fprintln(e.out, style.Err("Invalid model "+model))
+			return netspec.NIC{}, false
+		}
+		n.Model = model
+	}
+
+	mac, _ := utils.Ask(e.in, e.out, "MAC address (auto = assigned by libvirt)", nicMAC(n))
+	switch mac = normalize(mac); mac {
+	case "":
+	case "auto":
+		n.MAC = ""
+	default:
+		if err := netspec.CheckMAC(mac); err != nil {
+			fmt.Fprintln(e.out, style.Err(err.Error()))
+			return netspec.NIC{}, false
+		}
+		n.MAC = mac
+	}
+	style.Success("NIC", n.String(), "")
+	return n, true
+}
+
+// networkLabel is the network setting for the editor and the summary
+// (the empty default spelled out)
+func networkLabel(spec string) string {
+	nics, err := netspec.Parse(spec)
+	if err != nil {
+		return spec
+	}
+	return netspec.Format(nics)
+}
+
+// nicSource is the source of n as typed at the prompt ("bridge=br0", "default")
+func nicSource(n netspec.NIC) string {
+	src, _, _ := strings.Cut(netspec.NIC{Type: n.Type, Source: n.Source}.String(), ",")
+	return src
+}
+
+func nicModel(n netspec.NIC) string {
+	if n.Model == "" {
+		return netspec.DefaultModel
+	}
+	return n.Model
+}
+
+func nicMode(n netspec.NIC) string {
+	if n.Mode == "" {
+		return "bridge"
+	}
+	return n.Mode
+}
+
+func nicMAC(n netspec.NIC) string {
+	if n.MAC == "" {
+		return "auto"
+	}
+	return n.MAC
+}
@@ -0,0 +1,143 @@
+// ui/osinfo.go
+// last modified: Oct 16 2026
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	// internal
+	"configurator/internal/model"
+	"configurator/internal/osinfo"
+	"configurator/internal/style"
+	"configurator/internal/utils"
+)
+
+// at most this many hits are listed; more words narrow the search
+const maxVariantHits = 25
+
+/*
+PickVariant is a searchable list of all OSes of the local osinfo database.
+query is the first search; typing words searches again, a number picks
+that OS. Reports false if the user cancelled or there is no database.
+*/
+func PickVariant(r *bufio.Reader, query string) (osinfo.OS, bool) {
+	db, err := osinfo.Default()
+	if err != nil {
+		style.RedError("OS search", "", err)
+		return osinfo.OS{}, false
+	}
+	for {
+		hits := db.Search(query)
+		title := "All known OSes"
+		if query != "" {
+			title = fmt.Sprintf("OSes matching %q", query)
+		}
+		fmt.Println(style.BoxCenter(51, []string{title, fmt.Sprintf("%d found", len(hits))}))
+		if len(hits) > 0 {
+			shown := hits[:min(len(hits), maxVariantHits)]
+			lines := style.MustTableToLines(func(w *tabwriter.Writer) {
+				fmt.Fprintln(w, "No.\tid\tName")
+				fmt.Fprintln(w, "---\t--\t----")
+				for i, o := range shown {
+					fmt.Fprintf(w, "%2d\t%s\t%s\n", i+1, o.ShortID(), o.Name)
+				}
+			})
+			if len(hits) > len(shown) {
+				lines = append(lines, fmt.Sprintf("… %d more – type more words", len(hits)-len(shown)))
+			}
+			fmt.Print(style.Box(51, lines))
+		}
+
+		ans, err := utils.Prompt(r, os.Stdout, style.PromptMsg("Number, search words or [0] cancel: "))
+		if err != nil {
+			return osinfo.OS{}, false
+		}
+		switch normalize(ans) {
+		case "0", "q":
+			return osinfo.OS{}, false
+		case "":
+			continue
+		}
+		if i, err := strconv.Atoi(ans); err == nil {
+			if i < 1 || i > min(len(hits), maxVariantHits) {
+				fmt.Println(style.Err("Invalid selection!"))
+				continue
+			}
+			return hits[i-1], true
+		}
+		query = ans
+	}
+}
+
+// checkVariant reports an id that osinfo-db does not know; without a
+// database every id is accepted
+func checkVariant(id string) error {
+	db, err := osinfo.Default()
+	if err != nil {
+		return nil
+	}
+	return db.Check(id)
+}
+
+// requirements returns the OS of the VM's profile and its osinfo resources
+// (ok = false without database or for an unknown id)
+func requirements(cfg *model.DomainConfig) (osinfo.OS, bool) {
+	db, err := osinfo.Default()
+	if err != nil {
+		return osinfo.OS{}, false
+	}
+	return db.Lookup(cfg.Profile.ID)
+}
+
+// ResourceWarnings lists every value of cfg below the minimum osinfo-db
+// records for its OS (RAM, vCPUs, size of the system disk)
+func ResourceWarnings(cfg *model.DomainConfig) []string {
+	o, ok := requirements(cfg)
+	if !ok {
+		return nil
+	}
+	disk := 0
+	if d := cfg.PrimaryDisk(); d != nil {
+		disk = d.SizeGiB
+	}
+	return o.Warnings(cfg.MemMiB, cfg.VCPU, disk)
+}
+
+// resourceHint renders "min 4096 / rec. 8192" for one value ("" if neither is recorded)
+func resourceHint(minimum, recommended int) string {
+	var parts []string
+	if minimum > 0 {
+		parts = append(parts, fmt.Sprintf("min %d", minimum))
+	}
+	if recommended > 0 {
+		parts = append(parts, fmt.Sprintf("rec. %d", recommended))
+	}
+	return strings.Join(parts, " / ")
+}
+
+// warnResources prints the resource warnings of cfg in yellow
+func warnResources(w io.Writer, cfg *model.DomainConfig) {
+	for _, msg := range ResourceWarnings(cfg) {
+		fmt.Fprintln(w, style.Colourise("⚠ "+msg, style.ColYellow))
+	}
+}
+
+/*
+ConfirmResources asks before a VM is created with less than its OS needs
+(installers of e.g. Windows 11 refuse to run on 1 GiB). Without warnings
+it returns true right away.
+*/
+func ConfirmResources(r *bufio.Reader, cfg *model.DomainConfig) bool {
+	if len(ResourceWarnings(cfg)) == 0 {
+		return true
+	}
+	warnResources(os.Stdout, cfg)
+	ans, err := utils.Prompt(r, os.Stdout, style.PromptMsg("Create the VM anyway? [y/N]: "))
+	return err == nil && strings.HasPrefix(normalize(ans), "y")
+}
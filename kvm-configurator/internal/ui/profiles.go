@@ -0,0 +1,313 @@
+// ui/profiles.go
+// last modified: Oct 16 2026
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	// internal packages
+	"configurator/internal/config"
+	"configurator/internal/model"
+	"configurator/internal/style"
+	"configurator/internal/utils"
+)
+
+// labels of the profile keys in the editor (same order as in oslist.yaml)
+var profileLabels = map[string]string{
+	"name":       "Name",
+	"id":         "OS variant (id)",
+	"cpu":        "vCPU",
+	"ram":        "RAM (MiB)",
+	"disksize":   "Disk-Size (GB)",
+	"diskpath":   "Disk-Path",
+	"isopath":    "ISO",
+	"nvirt":      "Nested-Virtualisation",
+	"network":    "Network",
+	"graphics":   "Graphics",
+	"sound":      "Sound",
+	"filesystem": "Filesystem",
+	"bootorder":  "Boot-Order",
+	"firmware":   "Firmware",
+	"tpm":        "TPM",
+	"family":     "Family",
+}
+
+/*
+ManageProfiles is the "Manage profiles" menu: add, clone, edit and delete
+the OS entries of the config file at path (the user's oslist.yaml).
+merged is the effective configuration of all layers – it supplies the
+inherited values and the profiles that can be cloned. Reports whether
+the file was written, so the caller can reload the configuration.
+*/
+func ManageProfiles(r *bufio.Reader, path string, merged *config.FullConfig) bool {
+	changed := false
+	for {
+		file, err := config.OpenProfileFile(path)
+		if err != nil {
+			style.RedError("Profiles", path, err)
+			return changed
+		}
+		profiles, err := file.Profiles()
+		if err != nil {
+			style.RedError("Profiles", path, err)
+			return changed
+		}
+
+		fmt.Println(style.BoxCenter(51, []string{"MANAGE PROFILES", path}))
+		lines := style.MustTableToLines(func(w *tabwriter.Writer) {
+			fmt.Fprintln(w, "No.\tName\tid\tFamily")
+			fmt.Fprintln(w, "---\t----\t--\t------")
+			for i, p := range profiles {
+				fmt.Fprintf(w, "%2d\t%s\t%s\t%s\n", i+1, p.Name, p.ID, p.Family)
+			}
+		})
+		fmt.Print(style.Box(51, lines))
+		fmt.Println(style.Box(51, []string{
+			"[a] Add   [c] Clone   [e] Edit   [d] Delete",
+			"[0] Back",
+		}))
+
+		choice, err := utils.Prompt(r, os.Stdout, style.PromptMsg("Selection: "))
+		if err != nil {
+			return changed
+		}
+		var edited bool
+		switch normalize(choice) {
+		case "0", "q", "":
+			return changed
+		case "a":
+			edited = addProfile(r, file, merged, config.VMConfig{})
+		case "c":
+			src, err := SelectDistro(r, merged.OSList)
+			if err != nil {
+				style.RedError("Clone", "", err)
+				continue
+			}
+			edited = addProfile(r, file, merged, inheritable(src))
+		case "e":
+			if i := pickProfile(r, profiles); i >= 0 {
+				edited = editProfile(r, file, merged, profiles[i])
+			}
+		case "d":
+			if i := pickProfile(r, profiles); i >= 0 {
+				edited = deleteProfile(r, file, profiles[i].Name)
+			}
+		default:
+			fmt.Println(style.Err("Invalid selection!"))
+		}
+		changed = changed || edited
+	}
+}
+
+/*
+SaveAsProfile appends the settings of the VM being configured as a new OS
+entry to the config file at path, so the next VM of that kind starts from
+them. Only values that differ from what the entry would inherit anyway are
+written; the profile editor opens for a last look before saving.
+*/
+func SaveAsProfile(r *bufio.Reader, cfg *model.DomainConfig, path string, merged *config.FullConfig) bool {
+	name, err := utils.Ask(r, os.Stdout, "Profile name", cfg.Profile.Name+" (custom)")
+	if err != nil {
+		return false
+	}
+	if name == "" {
+		name = cfg.Profile.Name + " (custom)"
+	}
+	// an existing name in another layer would only extend that entry
+	for _, p := range merged.OSList {
+		if strings.EqualFold(p.Name, name) {
+			style.RedError("Profile exists", name, nil)
+			return false
+		}
+	}
+	file, err := config.OpenProfileFile(path)
+	if err != nil {
+		style.RedError("Profiles", path, err)
+		return false
+	}
+	if len(cfg.Disks) > 1 {
+		style.Info("Only the system disk is saved", fmt.Sprintf("%d more disk(s) skipped", len(cfg.Disks)-1))
+	}
+	return addProfile(r, file, merged, merged.Minimal(cfg.ToProfile(name)))
+}
+
+// inheritable keeps only the values a profile sets itself, so a clone
+// still inherits the rest from its family and the defaults
+func inheritable(p config.VMConfig) config.VMConfig {
+	var out config.VMConfig
+	for _, key := range config.ProfileKeys() {
+		if o, ok := p.Origin[key]; ok && o != config.OriginProfile {
+			continue
+		}
+		out.Set(key, p.Get(key))
+	}
+	out.Name = p.Name + " (copy)"
+	out.ID = p.ID
+	out.Family = p.Family
+	return out
+}
+
+// pickProfile asks for a number of the profile table (-1 = cancelled)
+func pickProfile(r *bufio.Reader, profiles []config.VMConfig) int {
+	if len(profiles) == 0 {
+		fmt.Println(style.Err("No profiles in this file"))
+		return -1
+	}
+	ans, err := utils.Prompt(r, os.Stdout, style.PromptMsg(fmt.Sprintf("Profile (1-%d): ", len(profiles))))
+	if err != nil || ans == "" {
+		return -1
+	}
+	i, err := strconv.Atoi(ans)
+	if err != nil || i < 1 || i > len(profiles) {
+		style.RedError("Invalid selection", ans, nil)
+		return -1
+	}
+	return i - 1
+}
+
+func addProfile(r *bufio.Reader, file *config.ProfileFile, merged *config.FullConfig, p config.VMConfig) bool {
+	if !editProfileFields(r, &p, merged) {
+		return false
+	}
+	if err := file.Add(p); err != nil {
+		style.RedError("Add profile", p.Name, err)
+		return false
+	}
+	return saveProfiles(file, "Profile added", p.Name)
+}
+
+func editProfile(r *bufio.Reader, file *config.ProfileFile, merged *config.FullConfig, p config.VMConfig) bool {
+	name := p.Name
+	if !editProfileFields(r, &p, merged) {
+		return false
+	}
+	if err := file.Update(name, p); err != nil {
+		style.RedError("Edit profile", name, err)
+		return false
+	}
+	return saveProfiles(file, "Profile saved", p.Name)
+}
+
+func deleteProfile(r *bufio.Reader, file *config.ProfileFile, name string) bool {
+	ans, _ := utils.Prompt(r, os.Stdout, style.PromptMsg(fmt.Sprintf("Delete profile %q? [y/N]: ", name)))
+	if !strings.HasPrefix(normalize(ans), "y") {
+		return false
+	}
+	if err := file.Delete(name); err != nil {
+		style.RedError("Delete profile", name, err)
+		return false
+	}
+	return saveProfiles(file, "Profile deleted", name)
+}
+
+// saveProfiles writes the file and reports the backup
+func saveProfiles(file *config.ProfileFile, msg, name string) bool {
+	backup, err := file.Save()
+	if err != nil {
+		style.RedError("Profile not saved", file.Path, err)
+		return false
+	}
+	style.Success(msg, name, "")
+	if backup != "" {
+		style.Info("Backup of the previous file", backup)
+	}
+	return true
+}
+
+/*
+editProfileFields lets the user change the keys of p one by one.
+Empty keys show the value they would inherit; "-" clears a key again.
+Returns false if the user cancelled.
+*/
+func editProfileFields(r *bufio.Reader, p *config.VMConfig, merged *config.FullConfig) bool {
+	keys := config.ProfileKeys()
+	for {
+		inherited := inheritedValues(*p, merged)
+		fmt.Println(style.BoxCenter(51, []string{"EDIT PROFILE"}))
+		lines := style.MustTableToLines(func(w *tabwriter.Writer) {
+			for i, key := range keys {
+				val := p.Get(key)
+				if val == "" && inherited[key] != "" {
+					val = "← " + inherited[key]
+				}
+				fmt.Fprintf(w, "[%d] %s:\t%s\n", i+1, profileLabels[key], val)
+			}
+		})
+		fmt.Print(style.Box(51, lines))
+		fmt.Println(style.Box(51, []string{"[s] Save   [0] Cancel"}))
+
+		ans, err := utils.Prompt(r, os.Stdout, style.PromptMsg("Selection: "))
+		if err != nil {
+			return false
+		}
+		switch normalize(ans) {
+		case "s":
+			if strings.TrimSpace(p.Name) == "" || strings.TrimSpace(p.ID) == "" {
+				fmt.Println(style.Err("Name and OS variant (id) are required"))
+				continue
+			}
+			if _, ok := merged.Families[p.Family]; p.Family != "" && !ok {
+				fmt.Println(style.Err(fmt.Sprintf("Unknown family %q (%s)", p.Family, strings.Join(merged.FamilyNames(), ", "))))
+				continue
+			}
+			return true
+		case "0", "q":
+			return false
+		}
+		i, err := strconv.Atoi(ans)
+		if err != nil || i < 1 || i > len(keys) {
+			fmt.Println(style.Err("Invalid selection!"))
+			continue
+		}
+		key := keys[i-1]
+		label := profileLabels[key] + " ('-' = inherit)"
+		if key == "id" {
+			label = profileLabels[key] + " ('?' = search osinfo-db)"
+		}
+		val, err := utils.Ask(r, os.Stdout, label, p.Get(key))
+		if err != nil || val == "" {
+			continue
+		}
+		if val == "-" {
+			val = ""
+		}
+		if key == "id" {
+			if query, ok := strings.CutPrefix(val, "?"); ok {
+				o, picked := PickVariant(r, strings.TrimSpace(query))
+				if !picked {
+					continue
+				}
+				val = o.ShortID()
+			} else if err := checkVariant(val); err != nil {
+				style.RedError("Invalid value", profileLabels[key], err)
+				continue
+			}
+		}
+		if err := p.Set(key, val); err != nil {
+			style.RedError("Invalid value", profileLabels[key], err)
+		}
+	}
+}
+
+// inheritedValues shows what an unset key would become: family, then defaults
+func inheritedValues(p config.VMConfig, merged *config.FullConfig) map[string]string {
+	out := map[string]string{}
+	defs := config.VMConfig(merged.Defaults)
+	fam := merged.Families[p.Family]
+	for _, key := range config.ProfileKeys() {
+		switch {
+		case key == "name" || key == "id" || key == "family":
+			// never inherited
+		case fam.Get(key) != "":
+			out[key] = fam.Get(key) + " (" + p.Family + ")"
+		case defs.Get(key) != "":
+			out[key] = defs.Get(key) + " (" + config.OriginDefaults + ")"
+		}
+	}
+	return out
+}
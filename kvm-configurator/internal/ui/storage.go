@@ -0,0 +1,126 @@
+// ui/storage.go
+// last modified: Oct 16 2026
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	// internal
+	"configurator/internal/model"
+	"configurator/internal/poolxml"
+	"configurator/internal/style"
+	"configurator/internal/utils"
+)
+
+// PoolChoice is a storage pool a disk can be placed in
+type PoolChoice struct {
+	Name      string
+	Path      string // target directory
+	Active    bool
+	Available uint64   // free bytes (0 while inactive)
+	Images    []string // volumes no VM uses – the candidates for base images
+}
+
+/*
+askDiskLocation asks where a disk goes: a directory, an image file, a
+storage pool – by its number in the list of pools or as pool:<name> – or
+an existing disk as attach:<path>. ENTER keeps def.
+*/
+func askDiskLocation(r *bufio.Reader, w io.Writer, pools []PoolChoice, def string) string {
+	if len(pools) > 0 {
+		lines := style.MustTableToLines(func(tw *tabwriter.Writer) {
+			fmt.Fprintln(tw, "No.\tPool\tDirectory\tFree")
+			for i, p := range pools {
+				free := poolxml.Size(p.Available)
+				if !p.Active {
+					free = "inactive"
+				}
+				fmt.Fprintf(tw, "%2d\t%s\t%s\t%s\n", i+1, p.Name, p.Path, free)
+			}
+		})
+		lines = append(lines, "", "number = new volume in that pool")
+		fmt.Fprintln(w, style.Box(51, lines))
+	}
+	fmt.Fprintln(w, style.Hint("attach:<path> uses an existing image, block device or LVM volume"))
+	ans, _ := utils.Ask(r, w, style.PromptMsg("Disk path, pool number or pool:<name>"), def)
+	if ans == "" {
+		return def
+	}
+	if path, ok := strings.CutPrefix(ans, model.AttachPrefix); ok {
+		if _, err := os.Stat(os.ExpandEnv(path)); err != nil {
+			fmt.Fprintln(w, style.Hint(path+" not found on this host – it has to exist when the VM is created"))
+		}
+		return ans
+	}
+	if i, err := strconv.Atoi(ans); err == nil && i >= 1 && i <= len(pools) {
+		p := pools[i-1]
+		if !p.Active {
+			fmt.Fprintln(w, style.Hint("Pool "+p.Name+" is not active – start it before the VM is created"))
+		}
+		return model.PoolPrefix + p.Name
+	}
+	return ans
+}
+
+// diskLocationLabel is the location of a disk for the menus
+// ("pool:default (volume arch-system.qcow2)", "/dev/vg0/web (existing)")
+func diskLocationLabel(d model.DiskSpec, vmName string) string {
+	switch {
+	case d.Pool != "":
+		return d.Location() + " (volume " + d.VolumeName(vmName) + ")"
+	case d.Existing:
+		return d.Path + " (existing)"
+	}
+	return d.Path
+}
+
+// diskSizeLabel is the size column of the menus – an existing disk keeps its own
+func diskSizeLabel(d model.DiskSpec) string {
+	if d.Existing {
+		return "as is"
+	}
+	return strconv.Itoa(d.SizeGiB)
+}
+
+/*
+askBackingImage asks for the base image a new disk is cloned from: a number
+of the listed images (the unused volumes of the pools), a path, or "-" for
+a plain disk again. ENTER keeps cur.
+*/
+func askBackingImage(r *bufio.Reader, w io.Writer, pools []PoolChoice, cur string) string {
+	var images []string
+	for _, p := range pools {
+		images = append(images, p.Images...)
+	}
+	if len(images) > 0 {
+		lines := style.MustTableToLines(func(tw *tabwriter.Writer) {
+			fmt.Fprintln(tw, "No.\tImage")
+			for i, img := range images {
+				fmt.Fprintf(tw, "%2d\t%s\n", i+1, img)
+			}
+		})
+		fmt.Fprintln(w, style.Box(51, lines))
+	}
+	ans, _ := utils.Ask(r, w, style.PromptMsg("Base image (number or path, - for none)"), cur)
+	switch {
+	case ans == "":
+		return cur
+	case ans == "-":
+		return ""
+	}
+	if i, err := strconv.Atoi(ans); err == nil && i >= 1 && i <= len(images) {
+		return images[i-1]
+	}
+	path := filepath.Clean(os.ExpandEnv(ans))
+	if _, err := os.Stat(path); err != nil {
+		fmt.Fprintln(w, style.Hint(path+" not found on this host – it has to exist when the VM is created"))
+	}
+	return path
+}
@@ -1,9 +1,10 @@
 // ui/ui.go
-// last modified: Feb 27 2026
+// last modified: Oct 16 2026
 package ui
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -22,19 +23,23 @@ import (
 
 // PUBLIC API (functions used by the rest of the program)
 
-// NewEditor creates the interactive “customise VM” editor
+// NewEditor creates the interactive “customise VM” editor;
+// nets are offered when a NIC is added, pools for the disk location
 func NewEditor(r *bufio.Reader, w io.Writer,
-	cfg *model.DomainConfig, defaultDisk, isoDir string) *Editor {
+	cfg *model.DomainConfig, defaultDisk, isoDir string, nets NetworkSources, pools []PoolChoice) *Editor {
 	return &Editor{
 		in:          r,
 		out:         w,
 		cfg:         cfg,
 		defaultDisk: defaultDisk,
 		isoDir:      isoDir,
+		nets:        nets,
+		pools:       pools,
 	}
 }
 
-// SelectDistro lets the user pick a distribution from the YAML list
+// SelectDistro lets the user pick a distribution from the YAML list or,
+// with [s], any OS of osinfo-db (returned unresolved: Origin == nil)
 func SelectDistro(r *bufio.Reader, list []config.VMConfig) (config.VMConfig, error) {
 	return selectDistroImpl(r, list)
 }
@@ -44,9 +49,27 @@ func SelectISO(r *bufio.Reader, workDir string) (string, error) {
 	return selectISOImpl(r, workDir)
 }
 
+// SummaryChoice is what the user picked on the summary screen
+type SummaryChoice string
+
+const (
+	SummaryCreate  SummaryChoice = "create"  // ENTER
+	SummaryDryRun  SummaryChoice = "dry-run" // show command & XML only
+	SummaryCancel  SummaryChoice = "cancel"  // back to the main menu
+	SummaryProfile SummaryChoice = "profile" // save the settings as a new OS profile
+)
+
+// StartOptions is the per-run choice of the summary screen: start the VM
+// once it is registered and which console to open then
+type StartOptions struct {
+	Start   bool
+	Console string // config.ConsoleNone, ConsoleViewer or ConsoleSerial
+}
+
 // ShowSummary prints a final overview before the VM is created
-func ShowSummary(r *bufio.Reader, cfg *model.DomainConfig, isoPath string) {
-	showSummaryImpl(r, cfg, isoPath)
+// and asks how to continue; start can be changed on the way
+func ShowSummary(r *bufio.Reader, cfg *model.DomainConfig, isoPath string, start *StartOptions) SummaryChoice {
+	return showSummaryImpl(r, cfg, isoPath, start)
 }
 
 // EDITOR (the “CUSTOMIZE VM” loop)
@@ -56,6 +79,8 @@ type Editor struct {
 	cfg         *model.DomainConfig
 	defaultDisk string
 	isoDir      string
+	nets        NetworkSources
+	pools       []PoolChoice
 }
 
 // Run executes the interactive editor
@@ -84,6 +109,10 @@ func (e *Editor) Run() {
 			e.selectISO()
 		case "8":
 			e.editNetwork()
+		case "9":
+			e.editBacking()
+		case "c":
+			e.editCloudInit()
 		case "0":
 			editAdvanced(e.in, e.cfg) // advanced submenu
 		default:
@@ -104,6 +133,7 @@ func (e *Editor) editRAM() {
 	if ans != "" {
 		if i, err := utils.MustInt(ans); err == nil {
 			e.cfg.MemMiB = i
+			warnResources(e.out, e.cfg)
 		}
 	}
 }
@@ -113,30 +143,31 @@ func (e *Editor) editVCPU() {
 	if ans != "" {
 		if i, err := utils.MustInt(ans); err == nil {
 			e.cfg.VCPU = i
+			warnResources(e.out, e.cfg)
 		}
 	}
 }
 
-// Disk‑Path – uses the default path if nothing is entered
+// Disk‑Path – uses the default path if nothing is entered;
+// a storage pool places the disk as volume of that pool
 func (e *Editor) editDiskPath() {
 	def := e.defaultDisk
-	if primary := e.cfg.PrimaryDisk(); primary != nil && primary.Path != "" {
-		def = primary.Path
-	}
-	ans, _ := utils.Ask(e.in, e.out, style.PromptMsg("Disk path"), def)
-	if ans == "" {
-		ans = def
+	if primary := e.cfg.PrimaryDisk(); primary != nil && primary.Location() != "" {
+		def = primary.Location()
 	}
-	if primary := e.cfg.PrimaryDisk(); primary != nil {
-		primary.Path = os.ExpandEnv(ans)
-	} else {
-		e.cfg.Disks = append(e.cfg.Disks, model.DiskSpec{
-			Name: "system",
-			Path: os.ExpandEnv(ans),
-		})
+	ans := askDiskLocation(e.in, e.out, e.pools, def)
+	if e.cfg.PrimaryDisk() == nil {
+		e.cfg.Disks = append(e.cfg.Disks, model.DiskSpec{Name: "system"})
 	}
+	primary := e.cfg.PrimaryDisk()
+	primary.SetLocation(os.ExpandEnv(ans))
 	fmt.Fprintf(e.out, style.Ok("Disk will be stored at: %s"),
-		e.cfg.PrimaryDisk().Path)
+		diskLocationLabel(*primary, e.cfg.Name))
+	// an existing system disk is booted as it is, no installation
+	if primary.Existing && e.cfg.ISOPath != "" {
+		e.cfg.ISOPath = ""
+		fmt.Fprintln(e.out, style.Hint("\nThe VM boots from the attached disk – ISO removed ([7] adds one again)"))
+	}
 }
 
 // Disk‑Size – only for the first (system) disk
@@ -147,6 +178,10 @@ func (e *Editor) editDiskSize() {
 	}
 	if i, err := utils.MustInt(ans); err == nil {
 		if primary := e.cfg.PrimaryDisk(); primary != nil {
+			if primary.Existing {
+				fmt.Fprintln(e.out, style.Hint("The system disk is attached as it is – its size is not changed"))
+				return
+			}
 			primary.SizeGiB = i
 		} else {
 			e.cfg.Disks = append(e.cfg.Disks, model.DiskSpec{
@@ -154,16 +189,42 @@ func (e *Editor) editDiskSize() {
 				SizeGiB: i,
 			})
 		}
+		warnResources(e.out, e.cfg)
 	}
 }
 
 // Add a secondary disk – delegated to PromptAddDisk
 func (e *Editor) addExtraDisk() {
-	if err := PromptAddDisk(e.in, e.cfg, e.defaultDisk); err != nil {
+	if err := PromptAddDisk(e.in, e.cfg, e.defaultDisk, e.pools); err != nil {
 		style.RedError("Add Disk failed", "", err)
 	}
 }
 
+// Base image – the system disk becomes a linked clone (qcow2 overlay) of it
+// and boots what is installed there, so the ISO is dropped
+func (e *Editor) editBacking() {
+	primary := e.cfg.PrimaryDisk()
+	if primary == nil {
+		e.cfg.Disks = append(e.cfg.Disks, model.DiskSpec{Name: "system", Bus: "virtio"})
+		primary = e.cfg.PrimaryDisk()
+		primary.SetLocation(e.defaultDisk)
+	}
+	if primary.Existing {
+		fmt.Fprintln(e.out, style.Hint("The system disk is an attached disk – pick a directory or pool at [4] first"))
+		return
+	}
+	primary.Backing = askBackingImage(e.in, e.out, e.pools, primary.Backing)
+	if primary.Backing == "" {
+		fmt.Fprintln(e.out, style.Ok("System disk is a new empty image"))
+		return
+	}
+	fmt.Fprintln(e.out, style.Ok("System disk is a linked clone of "+primary.Backing))
+	if e.cfg.ISOPath != "" {
+		e.cfg.ISOPath = ""
+		fmt.Fprintln(e.out, style.Hint("The VM boots from the cloned disk – ISO removed ([7] adds one again)"))
+	}
+}
+
 // ISO selection – uses the existing SelectISO helper
 func (e *Editor) selectISO() {
 	isoPath, err := SelectISO(e.in, e.isoDir)
@@ -175,32 +236,43 @@ func (e *Editor) selectISO() {
 	fmt.Fprintf(e.out, "\x1b[32mSelected ISO: %s\x1b[0m\n", isoPath)
 }
 
-// Network
-func (e *Editor) editNetwork() {
-	if v, _ := utils.Prompt(e.in, e.out, style.PromptMsg(">> Network (none or default): ")); true {
-		e.cfg.Network = v
+// backingLabel is the base image of the system disk ("-" for a plain disk)
+func backingLabel(cfg *model.DomainConfig) string {
+	if d := cfg.PrimaryDisk(); d != nil && d.Backing != "" {
+		return filepath.Base(d.Backing)
 	}
+	return "-"
+}
+
+// isoLabel is the ISO column of the menus; an imported VM needs none
+func isoLabel(cfg *model.DomainConfig) string {
+	if cfg.ISOPath == "" && cfg.Imported() {
+		return "none (boots from disk)"
+	}
+	return filepath.Base(cfg.ISOPath)
 }
 
 // UI rendering for the editor
 func (e *Editor) drawMenu() {
-	isoFile := filepath.Base(e.cfg.ISOPath)
-
+	// third column: minimum / recommended values of osinfo-db
+	req, _ := requirements(e.cfg)
 	fmt.Println(style.BoxCenter(51, []string{"CUSTOMIZE VM"}))
 	lines := style.MustTableToLines(func(w *tabwriter.Writer) {
 		fmt.Fprintf(w, "[1] Name:\t%s\n", e.cfg.Name)
-		fmt.Fprintf(w, "[2] RAM (MiB):\t%d\n", e.cfg.MemMiB)
-		fmt.Fprintf(w, "[3] vCPU:\t%d\n", e.cfg.VCPU)
+		fmt.Fprintf(w, "[2] RAM (MiB):\t%d\t%s\n", e.cfg.MemMiB, resourceHint(req.Minimum.RAMMiB, req.Recommended.RAMMiB))
+		fmt.Fprintf(w, "[3] vCPU:\t%d\t%s\n", e.cfg.VCPU, resourceHint(req.Minimum.CPUs, req.Recommended.CPUs))
 		if primary := e.cfg.PrimaryDisk(); primary != nil {
-			fmt.Fprintf(w, "[4] Disk-Path:\t%s\n", primary.Path)
-			fmt.Fprintf(w, "[5] Disk-Size (GB):\t%d\n", primary.SizeGiB)
+			fmt.Fprintf(w, "[4] Disk-Path:\t%s\n", primary.Location())
+			fmt.Fprintf(w, "[5] Disk-Size (GB):\t%s\t%s\n", diskSizeLabel(*primary), resourceHint(req.Minimum.StorageGiB, req.Recommended.StorageGiB))
 		} else {
 			fmt.Fprintf(w, "[4] Disk-Path:\t<none>\n")
 			fmt.Fprintf(w, "[5] Disk-Size (GB):\t<none>\n")
 		}
 		fmt.Fprintln(w, "[6] Add more disks")
-		fmt.Fprintf(w, "[7] ISO:\t%s\n", isoFile)
-		fmt.Fprintf(w, "[8] Network:\t%s\n", e.cfg.Network)
+		fmt.Fprintf(w, "[7] ISO:\t%s\n", isoLabel(e.cfg))
+		fmt.Fprintf(w, "[8] Network:\t%s\n", networkLabel(e.cfg.Network))
+		fmt.Fprintf(w, "[9] Based on image:\t%s\n", backingLabel(e.cfg))
+		fmt.Fprintf(w, "[c] Cloud-init:\t%s\n", cloudInitLabel(e.cfg))
 		fmt.Fprintln(w, "[0] Advanced Parameters")
 	})
 	fmt.Println(style.Box(51, lines))
@@ -214,6 +286,8 @@ func editAdvanced(r *bufio.Reader, cfg *model.DomainConfig) {
 		optGraf   = "c"
 		optSound  = "d"
 		optFS     = "e"
+		optFW     = "f"
+		optTPM    = "g"
 		optBack   = "0"
 	)
 
@@ -223,6 +297,8 @@ func editAdvanced(r *bufio.Reader, cfg *model.DomainConfig) {
 		optGraf:   func() { editGraphics(r, cfg) },
 		optSound:  func() { editSound(r, cfg) },
 		optFS:     func() { editFilesystem(r, cfg) },
+		optFW:     func() { editFirmware(r, cfg) },
+		optTPM:    func() { editTPM(r, cfg) },
 	}
 
 	for {
@@ -253,9 +329,9 @@ func editAdvanced(r *bufio.Reader, cfg *model.DomainConfig) {
 // sub‑functions for each advanced option
 func editNested(r *bufio.Reader, cfg *model.DomainConfig) {
 	setChoice(r, cfg, &cfg.NestedVirt,
-		">> Nested-Virtualisation (vmx for Intel, smx for AMD): ",
+		">> Nested-Virtualisation (vmx for Intel, svm for AMD): ",
 		"Nested-Virtualisation",
-		map[string]bool{"vmx": true, "smx": true})
+		map[string]bool{"vmx": true, "svm": true})
 }
 
 func editBoot(r *bufio.Reader, cfg *model.DomainConfig) {
@@ -287,6 +363,20 @@ func editFilesystem(r *bufio.Reader, cfg *model.DomainConfig) {
 	}
 }
 
+func editFirmware(r *bufio.Reader, cfg *model.DomainConfig) {
+	setChoice(r, cfg, &cfg.Firmware,
+		">> Firmware (bios, efi or efi-secure for Secure Boot): ",
+		"Firmware",
+		map[string]bool{"bios": true, "efi": true, "efi-secure": true})
+}
+
+func editTPM(r *bufio.Reader, cfg *model.DomainConfig) {
+	setChoice(r, cfg, &cfg.TPM,
+		">> TPM 2.0 (none, tpm-crb or tpm-tis for older guests): ",
+		"TPM",
+		map[string]bool{"none": true, "tpm-crb": true, "tpm-tis": true})
+}
+
 // advanced menu screen
 func printAdvancedMenu(cfg *model.DomainConfig) {
 	lines := style.MustTableToLines(func(w *tabwriter.Writer) {
@@ -297,6 +387,8 @@ func printAdvancedMenu(cfg *model.DomainConfig) {
 		fmt.Fprintf(w, "[c]\tGraphics\t%s\n", cfg.Graphics)
 		fmt.Fprintf(w, "[d]\tSound\t%s\n", cfg.Sound)
 		fmt.Fprintf(w, "[e]\tFilesystem\t%s\n", cfg.FileSystem)
+		fmt.Fprintf(w, "[f]\tFirmware\t%s\n", cfg.Firmware)
+		fmt.Fprintf(w, "[g]\tTPM\t%s\n", cfg.TPM)
 		fmt.Fprintln(w, "[0]\tBack to main menu")
 	})
 	fmt.Println(style.Box(51, lines))
@@ -304,8 +396,9 @@ func printAdvancedMenu(cfg *model.DomainConfig) {
 
 // DISK HELPERS (add‑disk)
 
-// PromptAddDisk asks the user for a secondary disk and appends it to cfg
-func PromptAddDisk(r *bufio.Reader, cfg *model.DomainConfig, defaultDiskPath string) error {
+// PromptAddDisk asks the user for a secondary disk and appends it to cfg;
+// pools are offered as location besides a path
+func PromptAddDisk(r *bufio.Reader, cfg *model.DomainConfig, defaultDiskPath string, pools []PoolChoice) error {
 	fmt.Println(style.BoxCenter(55, []string{"=== ADD DISK ==="}))
 
 	// name
@@ -314,22 +407,21 @@ func PromptAddDisk(r *bufio.Reader, cfg *model.DomainConfig, defaultDiskPath str
 		return nil
 	}
 
-	// path – suggest either the primary‑disk path or the global default
+	// path – suggest either the primary‑disk location or the global default
 	var suggestedPath string
-	if primary := cfg.PrimaryDisk(); primary != nil && primary.Path != "" {
-		suggestedPath = primary.Path
+	if primary := cfg.PrimaryDisk(); primary != nil && primary.Location() != "" && !primary.Existing {
+		suggestedPath = primary.Location()
 	} else {
 		suggestedPath = defaultDiskPath
 	}
-	prompt := fmt.Sprintf("Disk path (default: %s): ", suggestedPath)
-	path, _ := utils.Prompt(r, os.Stdout, style.Colourise(prompt, style.ColYellow))
-	if path == "" {
-		path = suggestedPath
-	}
+	path := askDiskLocation(r, os.Stdout, pools, suggestedPath)
 
-	// size
-	sizeStr, _ := utils.Prompt(r, os.Stdout, style.PromptMsg("Size in GiB (0 = default): "))
-	size, _ := strconv.Atoi(sizeStr)
+	// size – an attached disk keeps its own
+	var size int
+	if !strings.HasPrefix(path, model.AttachPrefix) {
+		sizeStr, _ := utils.Prompt(r, os.Stdout, style.PromptMsg("Size in GiB (0 = default): "))
+		size, _ = strconv.Atoi(sizeStr)
+	}
 
 	// optional bus
 	bus, _ := utils.Prompt(r, os.Stdout, style.PromptMsg("Bus (virtio|scsi|sata|usb, default virtio): "))
@@ -338,12 +430,13 @@ func PromptAddDisk(r *bufio.Reader, cfg *model.DomainConfig, defaultDiskPath str
 	}
 
 	// attach
-	cfg.Disks = append(cfg.Disks, model.DiskSpec{
+	disk := model.DiskSpec{
 		Name:    name,
-		Path:    path,
 		SizeGiB: size,
 		Bus:     bus,
-	})
+	}
+	disk.SetLocation(path)
+	cfg.Disks = append(cfg.Disks, disk)
 	style.Success("Disk", name, "added")
 	return nil
 }
@@ -371,17 +464,25 @@ func selectDistroImpl(r *bufio.Reader, list []config.VMConfig) (config.VMConfig,
 
 	// prompt
 	ans, err := utils.Prompt(r, os.Stdout,
-		style.PromptMsg("\nPlease enter a number (or press ENTER for default Arch Linux): "))
+		style.PromptMsg("\nPlease enter a number, [s] to search osinfo-db (or press ENTER for default Arch Linux): "))
 	if err != nil {
 		return config.VMConfig{}, err
 	}
+	// an OS without profile – the caller resolves it against the defaults
+	if normalize(ans) == "s" {
+		o, ok := PickVariant(r, "")
+		if !ok {
+			return config.VMConfig{}, errors.New(style.Err("No OS selected"))
+		}
+		return config.VMConfig{Name: o.Name, ID: o.ShortID()}, nil
+	}
 	// default = first entry
 	idx := 1
 	if ans != "" {
 		if i, e := strconv.Atoi(ans); e == nil && i >= 1 && i <= len(sorted) {
 			idx = i
 		} else {
-			return config.VMConfig{}, fmt.Errorf(style.Err("Invalid selection"))
+			return config.VMConfig{}, errors.New(style.Err("Invalid selection"))
 		}
 	}
 	return sorted[idx-1], nil
@@ -414,33 +515,87 @@ func selectISOImpl(r *bufio.Reader, workDir string) (string, error) {
 }
 
 // SUMMARY DISPLAY
-func showSummaryImpl(r *bufio.Reader, cfg *model.DomainConfig, isoPath string) {
-	isoFile := filepath.Base(cfg.ISOPath)
-
+func showSummaryImpl(r *bufio.Reader, cfg *model.DomainConfig, isoPath string, start *StartOptions) SummaryChoice {
 	fmt.Println(style.BoxCenter(51, []string{"VM-SUMMARY"}))
+	// third column: where the value comes from (profile, family, defaults, edited)
+	from := func(key string) string { return "(" + cfg.Origin(key) + ")" }
 	lines := style.MustTableToLines(func(w *tabwriter.Writer) {
 		fmt.Fprintf(w, "Name:\t%s\n", cfg.Name)
-		fmt.Fprintf(w, "RAM (MiB):\t%d\n", cfg.MemMiB)
-		fmt.Fprintf(w, "vCPU:\t%d\n", cfg.VCPU)
+		fmt.Fprintf(w, "RAM (MiB):\t%d\t%s\n", cfg.MemMiB, from("ram"))
+		fmt.Fprintf(w, "vCPU:\t%d\t%s\n", cfg.VCPU, from("cpu"))
 
 		if primary := cfg.PrimaryDisk(); primary != nil {
-			fmt.Fprintf(w, "Disk-Path:\t%s\n", primary.Path)
-			fmt.Fprintf(w, "Disk-Size (GB):\t%d\n", primary.SizeGiB)
+			fmt.Fprintf(w, "Disk-Path:\t%s\t%s\n", diskLocationLabel(*primary, cfg.Name), from("diskpath"))
+			fmt.Fprintf(w, "Disk-Size (GB):\t%s\t%s\n", diskSizeLabel(*primary), from("disksize"))
 		} else {
 			fmt.Fprintf(w, "Disk-Path:\t<none>\n")
 			fmt.Fprintf(w, "Disk-Size (GB):\t<none>\n")
 		}
 
-		fmt.Fprintf(w, "Network:\t%s\n", cfg.Network)
-		fmt.Fprintf(w, "Nested-Virtualisation:\t%s\n", cfg.NestedVirt)
-		fmt.Fprintf(w, "ISO-File:\t%s\n", isoFile)
-		fmt.Fprintf(w, "Boot-Order:\t%s\n", cfg.BootOrder)
-		fmt.Fprintf(w, "Graphic:\t%s\n", cfg.Graphics)
-		fmt.Fprintf(w, "Sound:\t%s\n", cfg.Sound)
-		fmt.Fprintf(w, "Filesystem:\t%s\n", cfg.FileSystem)
+		fmt.Fprintf(w, "Network:\t%s\t%s\n", networkLabel(cfg.Network), from("network"))
+		fmt.Fprintf(w, "Nested-Virtualisation:\t%s\t%s\n", cfg.NestedVirt, from("nvirt"))
+		fmt.Fprintf(w, "ISO-File:\t%s\t%s\n", isoLabel(cfg), from("isopath"))
+		if d := cfg.PrimaryDisk(); d != nil && d.Backing != "" {
+			fmt.Fprintf(w, "Base image:\t%s\n", d.Backing)
+		}
+		if cfg.CloudInit != nil {
+			fmt.Fprintf(w, "Cloud-init:\t%s\n", cloudInitLabel(cfg))
+		}
+		fmt.Fprintf(w, "Boot-Order:\t%s\t%s\n", cfg.BootOrder, from("bootorder"))
+		fmt.Fprintf(w, "Graphic:\t%s\t%s\n", cfg.Graphics, from("graphics"))
+		fmt.Fprintf(w, "Sound:\t%s\t%s\n", cfg.Sound, from("sound"))
+		fmt.Fprintf(w, "Filesystem:\t%s\t%s\n", cfg.FileSystem, from("filesystem"))
+		fmt.Fprintf(w, "Firmware:\t%s\t%s\n", cfg.Firmware, from("firmware"))
+		fmt.Fprintf(w, "TPM:\t%s\t%s\n", cfg.TPM, from("tpm"))
 	})
 	fmt.Print(style.Box(51, lines))
+	warnResources(os.Stdout, cfg)
 
-	_, _ = utils.Prompt(r, os.Stdout,
-		style.PromptMsg("\nPress ENTER to create VM … "))
+	for {
+		create, console := "[ENTER] Create VM", start.Console
+		if start.Start {
+			create += " and start it"
+		}
+		if console == "" {
+			console = config.ConsoleNone
+		}
+		fmt.Println(style.Box(51, []string{
+			create,
+			fmt.Sprintf("[s] Start after creation: %s", yesNo(start.Start)),
+			fmt.Sprintf("[c] Console after start: %s", console),
+			"[d] Dry-run (show command & XML only)",
+			"[p] Save settings as new OS profile",
+			"[0] Cancel",
+		}))
+		ans, err := utils.Prompt(r, os.Stdout, style.PromptMsg("Selection: "))
+		if err != nil {
+			return SummaryCancel
+		}
+		switch normalize(ans) {
+		case "":
+			return SummaryCreate
+		case "d":
+			return SummaryDryRun
+		case "p":
+			return SummaryProfile
+		case "s":
+			start.Start = !start.Start
+			continue
+		case "c":
+			// none → viewer → serial → none; choosing a console implies starting
+			switch start.Console {
+			case config.ConsoleViewer:
+				start.Console = config.ConsoleSerial
+			case config.ConsoleSerial:
+				start.Console = config.ConsoleNone
+			default:
+				start.Console = config.ConsoleViewer
+			}
+			start.Start = start.Start || start.Console != config.ConsoleNone
+			continue
+		case "0", "q":
+			return SummaryCancel
+		}
+		fmt.Println(style.Err("Invalid selection!"))
+	}
 }
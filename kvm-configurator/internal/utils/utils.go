@@ -1,5 +1,5 @@
 // utils/utils.go
-// last modified: Feb 24 2026
+// last modified: Oct 16 2026
 package utils
 
 import (
@@ -44,6 +44,37 @@ func ListFiles(dir string) ([]string, error) {
 	return files, nil
 }
 
+// ResolveInDir accepts an absolute path, a path relative to the working
+// directory or a file name inside dir (e.g. the configured ISO directory)
+func ResolveInDir(name, dir string) string {
+	if name == "" || filepath.IsAbs(name) {
+		return name
+	}
+	if _, err := os.Stat(name); err == nil || dir == "" {
+		if abs, err := filepath.Abs(name); err == nil {
+			return abs
+		}
+		return name
+	}
+	return filepath.Join(dir, name)
+}
+
+// ShellJoin quotes args so the line can be copied into a POSIX shell
+func ShellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		if a != "" && strings.IndexFunc(a, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+				strings.ContainsRune("-_./:=,+@%", r))
+		}) < 0 {
+			quoted[i] = a
+			continue
+		}
+		quoted[i] = "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
+
 // PromptSelection shows a numbered menu of the supplied files and returns the
 // user’s choice (1‑based). 0 means “cancel”. Errors are returned explicitly.
 func PromptSelection(r *bufio.Reader, w io.Writer, files []string) (int, error) {
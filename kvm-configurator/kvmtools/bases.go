@@ -0,0 +1,113 @@
+// kvmtools/bases.go
+// last modified: Oct 16 2026
+package kvmtools
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	// internal
+	"configurator/internal/backend"
+	"configurator/internal/model"
+	"configurator/internal/poolxml"
+	"configurator/internal/style"
+)
+
+// maxChain stops following backing files in a broken (looping) chain
+const maxChain = 16
+
+/*
+BaseUsers maps every backing image (backend.CanonicalPath) to the VMs
+whose disks are overlays on it – directly or further down the chain
+(base → overlay → overlay). An error means the map is incomplete: a disk
+could not be inspected.
+*/
+func BaseUsers(be backend.Backend) (map[string][]string, error) {
+	users := map[string][]string{}
+	disks, err := backend.DiskUsers(be)
+	if err != nil {
+		return users, err
+	}
+	var failed []string
+	for disk, vms := range disks {
+		path := disk
+		for range maxChain {
+			info, err := be.DiskInfo(path)
+			if err != nil {
+				failed = append(failed, fmt.Sprintf("%s (%v)", path, err))
+				break
+			}
+			if info.Backing == "" {
+				break
+			}
+			path = backend.CanonicalPath(info.Backing)
+			for _, vm := range vms {
+				if !slices.Contains(users[path], vm) {
+					users[path] = append(users[path], vm)
+				}
+			}
+		}
+	}
+	if len(failed) > 0 {
+		slices.Sort(failed)
+		return users, fmt.Errorf("cannot read %s", strings.Join(failed, "; "))
+	}
+	return users, nil
+}
+
+/*
+DeletableDisks filters the image files of an undefined VM down to those
+that may be removed: block devices stay, and so does every image that is
+still the base of another VM's overlay. If the bases cannot be determined
+completely, every disk stays – a missed base would take its clones with it.
+*/
+func DeletableDisks(be backend.Backend, paths []string) ([]string, error) {
+	bases, err := BaseUsers(be)
+	if err != nil {
+		return nil, fmt.Errorf("disks kept – unknown whether they are base images: %w", err)
+	}
+	return slices.DeleteFunc(slices.Clone(paths), func(p string) bool {
+		if model.IsBlockDevice(p) {
+			fmt.Printf("%s is a block device – kept.\n", p)
+			return true
+		}
+		if vms := bases[backend.CanonicalPath(p)]; len(vms) > 0 {
+			fmt.Printf("%s is the base image of %s – kept.\n", p, strings.Join(vms, ", "))
+			return true
+		}
+		return false
+	}), nil
+}
+
+// ShowBaseImages – lists the base images in use and the VMs (linked
+// clones) depending on them
+func ShowBaseImages(be backend.Backend) {
+	users, err := BaseUsers(be)
+	fmt.Println(style.BoxCenter(100, []string{"BASE IMAGES"}))
+	if err != nil {
+		fmt.Println(style.Hint("Incomplete: " + err.Error()))
+	}
+	if len(users) == 0 {
+		fmt.Println(style.Box(100, []string{"no VM is a linked clone of a base image"}))
+		return
+	}
+	bases := make([]string, 0, len(users))
+	for b := range users {
+		bases = append(bases, b)
+	}
+	slices.Sort(bases)
+	lines := style.MustTableToLines(func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, "Base image\tFormat\tSize\tClones")
+		for _, b := range bases {
+			format, size := "-", "-"
+			if info, err := be.DiskInfo(b); err == nil {
+				format, size = info.Format, poolxml.Size(info.VirtualSize)
+			}
+			vms := slices.Sorted(slices.Values(users[b]))
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", b, format, size, strings.Join(vms, ", "))
+		}
+	})
+	fmt.Println(style.Box(100, lines))
+}
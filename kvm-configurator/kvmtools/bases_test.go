@@ -0,0 +1,121 @@
+// kvmtools/bases_test.go
+// last modified: Oct 16 2026
+package kvmtools
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	// internal
+	"configurator/internal/backend"
+)
+
+// cloneLab: base ← web (overlay) ← web-snap (overlay of the overlay, VM snap),
+// plus a VM db with a plain disk
+func cloneLab() *backend.Fake {
+	f := backend.NewFake()
+	f.Images["/img/base.qcow2"] = &backend.FakeImage{Format: "qcow2", SizeGiB: 10}
+	f.Images["/img/web.qcow2"] = &backend.FakeImage{Format: "qcow2", SizeGiB: 10, Backing: "/img/base.qcow2"}
+	f.Images["/img/snap.qcow2"] = &backend.FakeImage{Format: "qcow2", SizeGiB: 10, Backing: "/img/./web.qcow2"}
+	f.Images["/img/db.qcow2"] = &backend.FakeImage{Format: "qcow2", SizeGiB: 20}
+	f.Domains["web"] = &backend.FakeDomain{Name: "web", State: "shut off", Disks: []string{"/img/web.qcow2"}}
+	f.Domains["snap"] = &backend.FakeDomain{Name: "snap", State: "shut off", Disks: []string{"/img/snap.qcow2"}}
+	f.Domains["db"] = &backend.FakeDomain{Name: "db", State: "shut off", Disks: []string{"/img/db.qcow2"}}
+	return f
+}
+
+func TestBaseUsersFollowsChains(t *testing.T) {
+	users, err := BaseUsers(cloneLab())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"/img/base.qcow2": {"snap", "web"},
+		"/img/web.qcow2":  {"snap"},
+	}
+	if len(users) != len(want) {
+		t.Fatalf("BaseUsers = %v, want %v", users, want)
+	}
+	for base, vms := range want {
+		if got := slices.Sorted(slices.Values(users[base])); !slices.Equal(got, vms) {
+			t.Errorf("users of %s = %v, want %v", base, got, vms)
+		}
+	}
+}
+
+func TestDeletableDisksKeepsBases(t *testing.T) {
+	f := cloneLab()
+	delete(f.Domains, "db") // the VM being deleted
+	got, err := DeletableDisks(f, []string{"/img/db.qcow2", "/img/base.qcow2", "/img//web.qcow2", "/dev/null"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/img/db.qcow2"}; !slices.Equal(got, want) {
+		t.Errorf("DeletableDisks = %v, want %v", got, want)
+	}
+}
+
+// failingDisks cannot read the disks of one VM (e.g. permission denied)
+type failingDisks struct{ *backend.Fake }
+
+func (f failingDisks) DomainDisks(name string) ([]string, error) {
+	if name == "snap" {
+		return nil, errors.New("permission denied")
+	}
+	return f.Fake.DomainDisks(name)
+}
+
+// failingList cannot list the VMs at all
+type failingList struct{ *backend.Fake }
+
+func (failingList) ListDomains() ([]backend.Domain, error) {
+	return nil, errors.New("connection reset")
+}
+
+func TestDeletableDisksKeepsEverythingWhenIncomplete(t *testing.T) {
+	brokenImage := cloneLab()
+	delete(brokenImage.Images, "/img/web.qcow2") // qemu-img info fails on web's overlay
+
+	for name, be := range map[string]backend.Backend{
+		"domain disks": failingDisks{cloneLab()},
+		"domain list":  failingList{cloneLab()},
+		"disk info":    brokenImage,
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := DeletableDisks(be, []string{"/img/db.qcow2", "/img/base.qcow2"})
+			if err == nil {
+				t.Fatalf("no error, deletable: %v", got)
+			}
+			if len(got) != 0 {
+				t.Errorf("deletable despite incomplete lookup: %v", got)
+			}
+		})
+	}
+}
+
+func TestBaseUsersResolvesSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.qcow2")
+	link := filepath.Join(dir, "golden.qcow2")
+	if err := os.WriteFile(base, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(base, link); err != nil {
+		t.Fatal(err)
+	}
+	f := backend.NewFake()
+	f.Images[base] = &backend.FakeImage{Format: "qcow2", SizeGiB: 10}
+	f.Images["/img/web.qcow2"] = &backend.FakeImage{Format: "qcow2", SizeGiB: 10, Backing: link}
+	f.Domains["web"] = &backend.FakeDomain{Name: "web", Disks: []string{"/img/web.qcow2"}}
+
+	got, err := DeletableDisks(f, []string{base})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("base %s (backing file %s) would be deleted", base, link)
+	}
+}
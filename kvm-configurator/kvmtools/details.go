@@ -0,0 +1,45 @@
+// kvmtools/details.go
+// last modified: Oct 16 2026
+package kvmtools
+
+import (
+	"errors"
+
+	// internal
+	"configurator/internal/backend"
+)
+
+// FetchVMDetails – FetchAllVMs plus vCPU, memory, autostart and the
+// disk paths of every VM, sorted by name
+func FetchVMDetails(be backend.Backend) ([]*VMDetails, error) {
+	vms, err := FetchAllVMs(be)
+	if err != nil {
+		return nil, err
+	}
+	var out []*VMDetails
+	for _, vm := range sortVMsAlphabetically(vms) {
+		d := &VMDetails{Name: vm.Name, State: vm.Stat}
+		if vm.Id != "-" {
+			d.Id = vm.Id
+		}
+		info, err := be.DomainInfo(vm.Name)
+		if errors.Is(err, backend.ErrNotFound) {
+			continue // gone since the list was read
+		}
+		if err != nil {
+			return nil, err
+		}
+		d.VCPU, d.MemoryMiB, d.Autostart = info.VCPU, info.MemoryMiB, info.Autostart
+
+		disks, err := be.DomainDisks(vm.Name)
+		if errors.Is(err, backend.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		d.Disks = append([]string{}, disks...) // [] instead of null in JSON
+		out = append(out, d)
+	}
+	return out, nil
+}
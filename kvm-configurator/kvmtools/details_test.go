@@ -0,0 +1,33 @@
+// kvmtools/details_test.go
+// last modified: Oct 16 2026
+package kvmtools
+
+import (
+	"slices"
+	"testing"
+
+	// internal
+	"configurator/internal/backend"
+)
+
+// staleList still lists a VM that was undefined after the list was read
+type staleList struct{ *backend.Fake }
+
+func (f staleList) ListDomains() ([]backend.Domain, error) {
+	doms, err := f.Fake.ListDomains()
+	return append(doms, backend.Domain{Name: "gone", State: "shut off"}), err
+}
+
+func TestFetchVMDetailsSkipsVanished(t *testing.T) {
+	got, err := FetchVMDetails(staleList{cloneLab()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, d := range got {
+		names = append(names, d.Name)
+	}
+	if want := []string{"db", "snap", "web"}; !slices.Equal(names, want) {
+		t.Errorf("FetchVMDetails = %v, want %v", names, want)
+	}
+}
@@ -1,23 +1,24 @@
 // kvmtools/diskops.go
-// last modified: Feb 22 2026
+// last modified: Oct 16 2026
 package kvmtools
 
 import (
 	"bufio"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
 
 	// internal
+	"configurator/internal/backend"
+	"configurator/internal/domxml"
 	"configurator/internal/style"
 	"configurator/internal/utils"
 )
 
-func getRealDiskPath(vmName string) (string, error) {
-	paths, err := GetDiskPathsViaVirsh(vmName)
+func getRealDiskPath(be backend.Backend, vmName string) (string, error) {
+	paths, err := be.DomainDisks(vmName)
 	if err != nil {
 		return "", fmt.Errorf("virsh query failed for VM %s: %w", vmName, err)
 	}
@@ -28,8 +29,8 @@ func getRealDiskPath(vmName string) (string, error) {
 }
 
 // disk resize
-func ResizeDisk(r *bufio.Reader, vmName string) error {
-	imgPath, err := getRealDiskPath(vmName)
+func ResizeDisk(r *bufio.Reader, be backend.Backend, vmName string) error {
+	imgPath, err := getRealDiskPath(be, vmName)
 	if err != nil {
 		return err
 	}
@@ -48,18 +49,16 @@ func ResizeDisk(r *bufio.Reader, vmName string) error {
 	spinner := style.SpinnerProgress("Resize is running …")
 	defer spinner.Stop()
 
-	cmd := exec.Command("qemu-img", "resize", imgPath, fmt.Sprintf("+%dG", newSize))
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("resize failed: %v – %s", err, string(out))
+	if err := be.ResizeDisk(imgPath, newSize); err != nil {
+		return fmt.Errorf("resize failed: %w", err)
 	}
 	style.Successf("Disk %s increased by %dGiB", filepath.Base(imgPath), newSize)
 	return nil
 }
 
 // convert
-func ConvertDisk(r *bufio.Reader, vmName string) error {
-	srcPath, err := getRealDiskPath(vmName)
+func ConvertDisk(r *bufio.Reader, be backend.Backend, vmName, xmlDir string) error {
+	srcPath, err := getRealDiskPath(be, vmName)
 	if err != nil {
 		return err
 	}
@@ -90,32 +89,37 @@ func ConvertDisk(r *bufio.Reader, vmName string) error {
 	spinner := style.SpinnerProgress("Conversion is underway …")
 	defer spinner.Stop()
 
-	cmd := exec.Command("qemu-img", "convert", "-O", tgtFmt, srcPath, newPath)
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("conversion failed: %v – %s", err, string(out))
+	if err := be.ConvertDisk(srcPath, newPath, tgtFmt); err != nil {
+		return fmt.Errorf("conversion failed: %w", err)
 	}
 	style.Successf("Converted disk %s to %s", filepath.Base(srcPath), tgtFmt)
 
-	// update XML entry (e.g. boot disk from qcow to vdi)
-	updateXMLPath(vmName, newPath, srcPath)
-
+	// point the definition at the new image (e.g. boot disk from qcow to vdi)
+	err = editDefinition(be, vmName, xmlDir, func(d *domxml.Domain) error {
+		if !d.ReplaceDisk(srcPath, newPath, tgtFmt) {
+			return fmt.Errorf("disk %s not in the definition", srcPath)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("definition not updated (old image still in use): %w", err)
+	}
+	style.Info("Definition updated", newPath)
 	return nil
 }
 
 // repair
-func RepairDisk(r *bufio.Reader, vmName string) error {
-	imgPath, err := getRealDiskPath(vmName)
+func RepairDisk(r *bufio.Reader, be backend.Backend, vmName string) error {
+	imgPath, err := getRealDiskPath(be, vmName)
 	if err != nil {
 		return err
 	}
 
 	// check
-	checkCmd := exec.Command("qemu-img", "check", imgPath)
-	out, err := checkCmd.CombinedOutput()
+	out, err := be.CheckDisk(imgPath)
 	if err == nil {
 		fmt.Println(style.Colourise("\nDisk is intact - no intervention required.", style.ColGreen))
-		fmt.Printf("%s\n", string(out))
+		fmt.Printf("%s\n", out)
 		return nil
 	}
 
@@ -124,50 +128,55 @@ func RepairDisk(r *bufio.Reader, vmName string) error {
 	spinner := style.SpinnerProgress("Repair is running …")
 	defer spinner.Stop()
 
-	repairCmd := exec.Command("qemu-img", "amend", "-f", "qcow2", imgPath)
-	repOut, repErr := repairCmd.CombinedOutput()
+	repOut, repErr := be.RepairDisk(imgPath)
 	if repErr != nil {
-		return fmt.Errorf("Repair failed: %v – %s", repErr, string(repOut))
+		return fmt.Errorf("Repair failed: %w", repErr)
 	}
 	style.Successf("Disk %s repaired", filepath.Base(imgPath))
-	fmt.Printf("%s\n", string(repOut))
+	fmt.Printf("%s\n", repOut)
 	return nil
 }
 
-// helper function: Adjust XML entry (only for Convert)
-func updateXMLPath(vmName, newPath, oldPath string) {
-	// assuming the current working directory is the
-	// project root and the XML directory is located there
-	possibleDirs := []string{
-		"./xml",
-		".", // fallback: aktuelle Directory
-	}
-	var xmlPath string
-	for _, d := range possibleDirs {
-		tmp := filepath.Join(d, vmName+".xml")
-		if _, err := os.Stat(tmp); err == nil {
-			xmlPath = tmp
-			break
-		}
+/*
+editDefinition loads the persistent definition of vmName, lets edit change
+it, defines the result and refreshes the saved copy in xmlDir. Everything
+the model does not know survives the round trip.
+*/
+func editDefinition(be backend.Backend, vmName, xmlDir string, edit func(*domxml.Domain) error) error {
+	raw, err := be.DefinitionXML(vmName)
+	if err != nil {
+		return err
 	}
-	if xmlPath == "" {
-		style.RedError("XML update failed – file not found", vmName, nil)
-		return
+	dom, err := domxml.Parse(raw)
+	if err != nil {
+		return err
 	}
-
-	data, err := os.ReadFile(xmlPath)
+	if err := edit(dom); err != nil {
+		return err
+	}
+	out, err := dom.Marshal()
 	if err != nil {
-		style.RedError("XML update failed (read)", xmlPath, err)
-		return
+		return err
 	}
-	updated := strings.ReplaceAll(string(data), oldPath, newPath)
-	if err := os.WriteFile(xmlPath, []byte(updated), 0644); err != nil {
-		style.RedError("XML update failed (write)", xmlPath, err)
+	if err := be.DefineXML(out); err != nil {
+		return err
 	}
+	return saveXMLCopy(xmlDir, dom.Name, out)
+}
+
+// saveXMLCopy writes the definition to <xmlDir>/<name>.xml
+func saveXMLCopy(xmlDir, name string, xml []byte) error {
+	if xmlDir == "" {
+		xmlDir = "."
+	}
+	if err := os.MkdirAll(xmlDir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(xmlDir, name+".xml"), xml, 0o644)
 }
 
 // Sub-menu that call up from "vmmenu.go"
-func DiskOpsMenu(r *bufio.Reader, vmName string) error {
+func DiskOpsMenu(r *bufio.Reader, be backend.Backend, vmName, xmlDir string) error {
 	for {
 		fmt.Println(style.BoxCenter(55,
 			[]string{"=== DISK-OPERATIONS FOR " + vmName + " ==="}))
@@ -181,13 +190,17 @@ func DiskOpsMenu(r *bufio.Reader, vmName string) error {
 		choice, _ := utils.Prompt(r, os.Stdout,
 			style.PromptMsg("\nSelection: "))
 
+		// qemu-img works on the files of this machine only
+		if choice != "0" && choice != "" && !backend.LocalURI(be.URI()) {
+			return fmt.Errorf("disk operations need a local hypervisor, not %s", be.URI())
+		}
 		switch choice {
 		case "1":
-			return ResizeDisk(r, vmName)
+			return ResizeDisk(r, be, vmName)
 		case "2":
-			return ConvertDisk(r, vmName)
+			return ConvertDisk(r, be, vmName, xmlDir)
 		case "3":
-			return RepairDisk(r, vmName)
+			return RepairDisk(r, be, vmName)
 		case "0", "":
 			return nil
 		default:
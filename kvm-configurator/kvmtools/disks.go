@@ -1,87 +1,79 @@
 // kvmtools/disks.go
-// last modified: Feb 22 2026
+// last modified: Oct 16 2026
 package kvmtools
 
 import (
-	"bufio"
-	"bytes"
-	"encoding/xml"
 	"fmt"
 	"os"
-	"os/exec"
-	//"path/filepath"
-	"strings"
-)
+	"path/filepath"
+	"slices"
 
-type domXML struct {
-	Devices struct {
-		Disks []struct {
-			Device string `xml:"device,attr"` // "disk" or "cdrom"
-			Source struct {
-				File string `xml:"file,attr"`
-			} `xml:"source"`
-		} `xml:"disk"`
-	} `xml:"devices"`
-}
+	// internal
+	"configurator/internal/backend"
+	"configurator/internal/domxml"
+	"configurator/internal/model"
+)
 
 // GetDiskPathsFromXML reads the paths from the libvirt XML file.
 func GetDiskPathsFromXML(xmlPath string) ([]string, error) {
-	data, err := os.ReadFile(xmlPath)
+	d, err := domxml.ParseFile(xmlPath)
 	if err != nil {
 		return nil, err
 	}
-	var d domXML
-	if err := xml.Unmarshal(data, &d); err != nil {
-		return nil, err
-	}
-	var out []string
-	for _, d := range d.Devices.Disks {
-		if d.Device == "disk" && d.Source.File != "" { // only disks, no iso!!
-			out = append(out, d.Source.File)
-		}
-	}
-	return out, nil
+	return d.DiskPaths(), nil // only disks, no iso!!
 }
 
-// fallback method via `virsh domblklist --details`
-func GetDiskPathsViaVirsh(vmName string) ([]string, error) {
-    // call from virsh
-    out, err := exec.Command("virsh", "domblklist", vmName, "--details").
-        CombinedOutput()
-    if err != nil {
-        return nil, fmt.Errorf("virsh domblklist failed: %w – %s", err, out)
-    }
-
-    // go through line by line
-    var paths []string
-    scanner := bufio.NewScanner(bytes.NewReader(out))
-    for scanner.Scan() {
-        line := strings.TrimSpace(scanner.Text())
-        // skip header line and empty lines
-        if line == "" || strings.HasPrefix(line, "Target") || strings.HasPrefix(line, "---") {
-            continue
-        }
-
-        // split fields (whitespace based)
-        fields := strings.Fields(line)
-        // expected Layout: Target Device Type Source
-        // example: vda disk block /var/lib/libvirt/images/my-vm-system.qcow2
-        if len(fields) < 4 {
-            // malformed – ignore, but not fatal
-            continue
-        }
-
-        device := fields[1] // second field = "disk" or "cdrom"
-        source := fields[3] // fourth field = path
-
-        if device == "disk" && source != "" && source != "-" {
-            paths = append(paths, source)
-        }
-    }
+/*
+VMDiskFiles – the image files that belong to vmName, collected before it is
+undefined: the disks of its definition (fallback: the saved XML) and the
+generated cloud-init seed. Images and devices that were attached as they
+are (attach:, --import) are not the VM's and stay out.
+*/
+func VMDiskFiles(be backend.Backend, vmName, xmlDir string) []string {
+	var dom *domxml.Domain
+	if raw, err := be.DefinitionXML(vmName); err == nil {
+		dom, _ = domxml.Parse(raw)
+	}
+	if dom == nil {
+		dom, _ = domxml.ParseFile(filepath.Join(xmlDir, vmName+".xml"))
+	}
+	if dom == nil {
+		return nil
+	}
+	paths := dom.DiskPaths()
+	if len(paths) == 0 {
+		return nil
+	}
+	// the generated cloud-init seed lies next to the system disk
+	seed := model.SeedFile(paths[0], vmName)
+	if _, err := os.Stat(seed); err == nil {
+		paths = append(paths, seed)
+	}
 
-    if err := scanner.Err(); err != nil {
-        return nil, fmt.Errorf("error scanning virsh output: %w", err)
-    }
+	attached := make(map[string]bool)
+	for _, p := range dom.AttachedDisks() {
+		attached[backend.CanonicalPath(p)] = true
+	}
+	return slices.DeleteFunc(paths, func(p string) bool {
+		if attached[backend.CanonicalPath(p)] {
+			fmt.Printf("%s was attached, not created – kept.\n", p)
+			return true
+		}
+		return false
+	})
+}
 
-    return paths, nil
-}
\ No newline at end of file
+// isAttached – path is an attached disk of vmName's definition
+func isAttached(be backend.Backend, vmName, path string) bool {
+	raw, err := be.DefinitionXML(vmName)
+	if err != nil {
+		return false
+	}
+	dom, err := domxml.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return slices.ContainsFunc(dom.AttachedDisks(), func(p string) bool {
+		return backend.CanonicalPath(p) == backend.CanonicalPath(path)
+	})
+}
@@ -0,0 +1,80 @@
+// kvmtools/disks_test.go
+// last modified: Oct 16 2026
+package kvmtools
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	// internal
+	"configurator/internal/backend"
+	"configurator/internal/config"
+	"configurator/internal/domxml"
+	"configurator/internal/model"
+)
+
+// defineWithAttached defines web with a new system disk in dir and an
+// existing image attached as data disk; both exist as files
+func defineWithAttached(t *testing.T, be *backend.Fake, dir string) (system, attached string) {
+	t.Helper()
+	cfg := model.NewDomainConfig(config.VMConfig{
+		Name: "web", ID: "debian13", CPU: 1, RAM: 1024, DiskSize: 10, DiskPath: dir,
+		Network: "network=default", Graphics: "none",
+	}, config.Defaults{})
+	attached = filepath.Join(dir, "shared-data.qcow2")
+	data := model.DiskSpec{Name: "data", Bus: "virtio"}
+	data.SetLocation(model.AttachPrefix + attached)
+	data.Format = "qcow2"
+	cfg.Disks = append(cfg.Disks, data)
+	system = model.DiskImagePath(cfg.Disks[0], cfg.Name)
+
+	dom, err := domxml.Build(cfg, "debian13")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := dom.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := be.DefineXML(raw); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{system, attached} {
+		if err := os.WriteFile(p, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		be.Images[p] = &backend.FakeImage{Format: "qcow2", SizeGiB: 10}
+	}
+	return system, attached
+}
+
+func TestVMDiskFilesSkipsAttached(t *testing.T) {
+	be := backend.NewFake()
+	system, _ := defineWithAttached(t, be, t.TempDir())
+	if got := VMDiskFiles(be, "web", t.TempDir()); !slices.Equal(got, []string{system}) {
+		t.Errorf("VMDiskFiles = %v, want only %s", got, system)
+	}
+}
+
+func TestDeleteVMKeepsAttachedDisk(t *testing.T) {
+	be := backend.NewFake()
+	system, attached := defineWithAttached(t, be, t.TempDir())
+
+	r := bufio.NewReader(strings.NewReader("y\n"))
+	if err := deleteVMWithDisks(r, be, "web", t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := be.Domains["web"]; ok {
+		t.Error("web still defined")
+	}
+	if _, err := os.Stat(system); !os.IsNotExist(err) {
+		t.Errorf("system disk %s not deleted (%v)", system, err)
+	}
+	if _, err := os.Stat(attached); err != nil {
+		t.Errorf("attached disk deleted: %v", err)
+	}
+}
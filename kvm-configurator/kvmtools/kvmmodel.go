@@ -1,7 +1,9 @@
 // kvmtools/kvmmodel.go
-// last modified: Feb 26 2026
+// last modified: Oct 16 2026
 package kvmtools
 
+import "net/netip"
+
 type Action string
 
 const (
@@ -12,8 +14,60 @@ const (
 	ActDelete		Action = "undefine"
 	ActDiskOps 	Action = "diskops"
 	ActRename		Action = "domrename"
+
+	ActNetStart     Action = "net-start"
+	ActNetStop      Action = "net-destroy"
+	ActNetAutostart Action = "net-autostart"
+	ActNetDelete    Action = "net-undefine"
+
+	ActPoolStart     Action = "pool-start"
+	ActPoolStop      Action = "pool-destroy"
+	ActPoolAutostart Action = "pool-autostart"
+	ActPoolVolumes   Action = "vol-list"
+	ActPoolDelete    Action = "pool-undefine"
 )
 
+// NetInfo is one libvirt network of the network menu
+type NetInfo struct {
+	Name      string
+	Active    bool
+	Autostart bool
+	Mode      string // nat, isolated, route, bridge, …
+	Bridge    string
+	Subnet    string // first IPv4 subnet
+	DHCP      string // first DHCP range, "start-end"
+
+	subnets []netip.Prefix // all IPv4 subnets, for the overlap check
+}
+
+// State is the network state as virsh net-list prints it
+func (n *NetInfo) State() string {
+	if n.Active {
+		return "active"
+	}
+	return "inactive"
+}
+
+// PoolInfo is one storage pool of the pool menu; sizes in bytes
+type PoolInfo struct {
+	Name       string
+	Active     bool
+	Autostart  bool
+	Type       string // dir, fs, netfs, logical, …
+	Path       string // target directory (or device)
+	Capacity   uint64
+	Allocation uint64
+	Available  uint64
+}
+
+// State is the pool state as virsh pool-list prints it
+func (p *PoolInfo) State() string {
+	if p.Active {
+		return "active"
+	}
+	return "inactive"
+}
+
 /* --------------------
 VMInfo holds the minimal information we need for the menus
 -------------------- */
@@ -21,4 +75,15 @@ type VMInfo struct {
 	Id   string // empty (“-”) when the VM is stopped
 	Name string
 	Stat string // canonical: "running" or "shut off"
-}
\ No newline at end of file
+}
+
+// VMDetails is the machine-readable view used by `configurator list`
+type VMDetails struct {
+	Id        string   `json:"id,omitempty" yaml:"id,omitempty"` // only set while running
+	Name      string   `json:"name" yaml:"name"`
+	State     string   `json:"state" yaml:"state"`
+	VCPU      int      `json:"vcpus" yaml:"vcpus"`
+	MemoryMiB int      `json:"memory_mib" yaml:"memory_mib"`
+	Autostart bool     `json:"autostart" yaml:"autostart"`
+	Disks     []string `json:"disks" yaml:"disks"`
+}
@@ -1,64 +1,34 @@
 // kvmtools/vmmenu.go
-// last modified: Feb 22 2026
+// last modified: Oct 16 2026
 package kvmtools
 
 import (
 	"bufio"
-	"bytes"
 	"fmt"
 	"os"
-	"os/exec"
-	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
 	"text/tabwriter"
 
 	// internal
+	"configurator/internal/backend"
 	"configurator/internal/style"
 )
 
-// fetchAllVMs -calls `virsh list --all` and returns []*VMInfo
-func fetchAllVMs() ([]*VMInfo, error) {
-	if _, lookErr := exec.LookPath("virsh"); lookErr != nil {
-		return nil, fmt.Errorf("virsh not found – Please check PATH")
-	}
-	out, err := exec.Command("virsh", "list", "--all").CombinedOutput()
+// FetchAllVMs – lists all domains of the backend and returns []*VMInfo
+func FetchAllVMs(be backend.Backend) ([]*VMInfo, error) {
+	doms, err := be.ListDomains()
 	if err != nil {
-		return nil, fmt.Errorf("virsh call failed: %v\n%s", err, out)
+		return nil, err
 	}
-	return parseVMs(out)
-}
-
-// parseVMs – converts raw Virsh output to []*VMInfo
-func parseVMs(raw []byte) ([]*VMInfo, error) {
-	scanner := bufio.NewScanner(bytes.NewReader(raw))
-	var vms []*VMInfo
-
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-
-		// Skip header/divider line/empty lines
-		if strings.HasPrefix(line, "Id") ||
-			strings.HasPrefix(line, "---") ||
-			line == "" {
-			continue
-		}
-
-		fields := strings.Fields(line)
-		if len(fields) < 3 {
-			continue // malformed – ignore
+	vms := make([]*VMInfo, 0, len(doms))
+	for _, d := range doms {
+		id := d.ID
+		if id == "" {
+			id = "-" // stopped
 		}
-
-		id := fields[0]                                    // may be "-"
-		rawStat := fields[len(fields)-1]                   // last column
-		stat := style.NormalizeStatus(rawStat)             // canonical
-		name := strings.Join(fields[1:len(fields)-1], " ") // everything in between
-
-		vms = append(vms, &VMInfo{Id: id, Name: name, Stat: stat})
-	}
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("Error parsing virsh output: %v", err)
+		vms = append(vms, &VMInfo{Id: id, Name: d.Name, Stat: d.State})
 	}
 	return vms, nil
 }
@@ -130,23 +100,31 @@ func pickAction(r *bufio.Reader, vm *VMInfo) Action {
 	return ""
 }
 
-// runVMAction – executes the selected virsh command
-func runVMAction(action Action, vmName string) error {
-	if action == "" {
+// RunVMAction – executes the selected action via the backend
+func RunVMAction(be backend.Backend, action Action, vmName string) error {
+	switch action {
+	case "":
 		return nil // abort / q
+	case ActStart:
+		return be.Start(vmName)
+	case ActReboot:
+		return be.Reboot(vmName)
+	case ActShutdown:
+		return be.Shutdown(vmName)
+	case ActDestroy:
+		return be.Destroy(vmName)
+	case ActDelete:
+		return be.Undefine(vmName)
 	}
-	cmd := exec.Command("virsh", string(action), vmName)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	return fmt.Errorf("unsupported action %q", action)
 }
 
 // VMMenu – public entry point
 // xmlDir: Path in which the libvirt XML files are located (e.g. "./xml")
-func VMMenu(r *bufio.Reader, xmlDir string) {
+func VMMenu(r *bufio.Reader, be backend.Backend, xmlDir string) {
 	for {
 		// fetch all VMs
-		vms, err := fetchAllVMs()
+		vms, err := FetchAllVMs(be)
 		if err != nil {
 			fmt.Fprintln(os.Stderr,
 				style.Colourise("Error reading the VM list: "+err.Error(),
@@ -184,15 +162,15 @@ func VMMenu(r *bufio.Reader, xmlDir string) {
 		}
 
 		if action == ActDiskOps {
-			// Start Disk Ops submenu (only pass VM name)
-			if err := DiskOpsMenu(r, selected.Name); err != nil {
+			// Start Disk Ops submenu
+			if err := DiskOpsMenu(r, be, selected.Name, xmlDir); err != nil {
 				fmt.Fprintln(os.Stderr, style.Colourise(err.Error(), style.ColRed))
 			}
 			continue
 		}
 
 		if action == ActRename {
-			if err := RenameVM(r, selected.Name, xmlDir); err != nil {
+			if err := RenameVM(r, be, selected.Name, xmlDir); err != nil {
 				fmt.Fprintln(os.Stderr, style.Colourise(err.Error(), style.ColRed))
 			}
 			//back to the VM overview
@@ -201,11 +179,11 @@ func VMMenu(r *bufio.Reader, xmlDir string) {
 
 		// run – special case “Undefine + Disk Cleanup”
 		if action == ActDelete {
-			if err := deleteVMWithDisks(r, selected.Name, xmlDir); err != nil {
+			if err := deleteVMWithDisks(r, be, selected.Name, xmlDir); err != nil {
 				fmt.Fprintln(os.Stderr, style.Colourise(err.Error(), style.ColRed))
 			}
 		} else {
-			if err := runVMAction(action, selected.Name); err != nil {
+			if err := RunVMAction(be, action, selected.Name); err != nil {
 				fmt.Fprintln(os.Stderr, style.Colourise(err.Error(), style.ColRed))
 			} else {
 				fmt.Println(style.Ok("Action successfully completed"))
@@ -215,22 +193,25 @@ func VMMenu(r *bufio.Reader, xmlDir string) {
 }
 
 // deleteVMWithDisks – undefine + optionales Disk‑Cleanup
-func deleteVMWithDisks(r *bufio.Reader, vmName, xmlDir string) error {
+func deleteVMWithDisks(r *bufio.Reader, be backend.Backend, vmName, xmlDir string) error {
+	// determine disk paths while the definition still exists
+	diskPaths := VMDiskFiles(be, vmName, xmlDir)
+
 	// undefine
-	if err := runVMAction(ActDelete, vmName); err != nil {
+	if err := RunVMAction(be, ActDelete, vmName); err != nil {
 		return err
 	}
 	fmt.Printf("\nVM %s became undefined.\n", vmName)
+	if !backend.LocalURI(be.URI()) {
+		fmt.Printf("The disk files lie on the host of %s – kept.\n", be.URI())
+		return nil
+	}
 
-	// determine disk paths (XML > fallback virsh)
-	var diskPaths []string
-	xmlPath := filepath.Join(xmlDir, vmName+".xml")
-	if paths, err := GetDiskPathsFromXML(xmlPath); err == nil && len(paths) > 0 {
-		diskPaths = paths
-	} else {
-		if paths, err2 := GetDiskPathsViaVirsh(vmName); err2 == nil {
-			diskPaths = paths
-		}
+	// block devices (raw disks, LVM volumes) are not files to remove, and a
+	// base image still holds the data of other VMs' overlays
+	diskPaths, err := DeletableDisks(be, diskPaths)
+	if err != nil {
+		return err
 	}
 
 	if len(diskPaths) == 0 {
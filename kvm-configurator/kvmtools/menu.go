@@ -1,5 +1,5 @@
 // kvmtools/menu.go
-// last modified: Feb 22 2026
+// last modified: Oct 16 2026
 package kvmtools
 
 import (
@@ -11,6 +11,7 @@ import (
 	"text/tabwriter"
 
 	// internal
+	"configurator/internal/backend"
 	"configurator/internal/style"
 )
 
@@ -21,11 +22,14 @@ type commandInfo struct {
 
 var menuMap = map[string]commandInfo{
 	"[1]": {"Show VMs"},
+	"[2]": {"Networks"},
+	"[3]": {"Storage"},
+	"[4]": {"Base images"},
 	"[q]": {"Back to Mainmenu"},
 }
 
 // lightweight dispatcher
-func Start(r *bufio.Reader, xmlDir string) {
+func Start(r *bufio.Reader, be backend.Backend, xmlDir string) {
 	for {
 		printMenu()
 		choice := readChoice(r)
@@ -36,7 +40,13 @@ func Start(r *bufio.Reader, xmlDir string) {
 
 		switch choice {
 		case "1":
-			VMMenu(r, xmlDir)
+			VMMenu(r, be, xmlDir)
+		case "2":
+			NetworkMenu(r, be)
+		case "3":
+			PoolMenu(r, be)
+		case "4":
+			ShowBaseImages(be)
 		default:
 			fmt.Fprintln(os.Stderr,
 				style.Err("Invalid selection"))
@@ -0,0 +1,350 @@
+// kvmtools/networks.go
+// last modified: Oct 16 2026
+package kvmtools
+
+import (
+	"bufio"
+	"fmt"
+	"net/netip"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	// internal
+	"configurator/internal/backend"
+	"configurator/internal/domxml"
+	"configurator/internal/netxml"
+	"configurator/internal/style"
+	"configurator/internal/utils"
+)
+
+// FetchNetworks – all libvirt networks with bridge, subnet and DHCP range
+// from their definitions, sorted by name
+func FetchNetworks(be backend.Backend) ([]*NetInfo, error) {
+	nets, err := be.ListNetworks()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*NetInfo, 0, len(nets))
+	for _, n := range nets {
+		info := &NetInfo{Name: n.Name, Active: n.Active, Autostart: n.Autostart}
+		if raw, err := be.NetworkXML(n.Name); err == nil {
+			if def, err := netxml.Parse(raw); err == nil {
+				info.Mode, info.Bridge = def.Mode(), def.BridgeName()
+				info.Subnet, info.DHCP = def.Subnet(), def.DHCPRange()
+				info.subnets = def.Subnets()
+			}
+		}
+		out = append(out, info)
+	}
+	slices.SortFunc(out, func(a, b *NetInfo) int { return strings.Compare(a.Name, b.Name) })
+	return out, nil
+}
+
+// printNetworkTable – prints the network list formatted
+func printNetworkTable(nets []*NetInfo) {
+	fmt.Println(style.BoxCenter(90, []string{"VIRTUAL NETWORKS"}))
+	lines := style.MustTableToLines(func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, "No.\tName\tState\tAutostart\tMode\tBridge\tSubnet\tDHCP")
+		for i, n := range nets {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", i+1, n.Name, n.State(),
+				yesNo(n.Autostart), dash(n.Mode), dash(n.Bridge), dash(n.Subnet), dash(n.DHCP))
+		}
+	})
+	fmt.Print(style.Box(90, lines))
+}
+
+// NetworkMenu – lists the networks; pick one for actions or create a new one
+func NetworkMenu(r *bufio.Reader, be backend.Backend) {
+	for {
+		nets, err := FetchNetworks(be)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, style.Colourise("Error reading the network list: "+err.Error(), style.ColRed))
+			return
+		}
+		if len(nets) == 0 {
+			fmt.Println(style.Hint("No networks defined"))
+		} else {
+			printNetworkTable(nets)
+		}
+
+		fmt.Print(style.PromptMsg("\nSelect network number, [n] new network (or q to exit): "))
+		choiceRaw, _ := r.ReadString('\n')
+		choice := strings.TrimSpace(strings.ToLower(choiceRaw))
+		switch choice {
+		case "q", "quit", "":
+			return
+		case "n":
+			if err := CreateNetwork(r, be, nets); err != nil {
+				fmt.Fprintln(os.Stderr, style.Colourise(err.Error(), style.ColRed))
+			}
+			continue
+		}
+		idx, err := strconv.Atoi(choice)
+		if err != nil || idx < 1 || idx > len(nets) {
+			fmt.Fprintln(os.Stderr, style.Err("Invalid selection"))
+			continue
+		}
+		selected := nets[idx-1]
+
+		action := pickNetworkAction(r, selected)
+		if action == "" {
+			continue
+		}
+		if err := RunNetworkAction(r, be, action, selected); err != nil {
+			fmt.Fprintln(os.Stderr, style.Colourise(err.Error(), style.ColRed))
+		} else {
+			fmt.Println(style.Ok("Action successfully completed"))
+		}
+	}
+}
+
+// pickNetworkAction – only shows permitted actions for the network's state
+func pickNetworkAction(r *bufio.Reader, n *NetInfo) Action {
+	autostart := "Enable autostart"
+	if n.Autostart {
+		autostart = "Disable autostart"
+	}
+	actions := []struct {
+		Key   string
+		Desc  string
+		Cmd   Action
+		Check func(*NetInfo) bool // true > allowed
+	}{
+		{"1", "Start", ActNetStart, func(n *NetInfo) bool { return !n.Active }},
+		{"2", "Stop", ActNetStop, func(n *NetInfo) bool { return n.Active }},
+		{"3", autostart, ActNetAutostart, nil},
+		{"0", "Delete", ActNetDelete, nil},
+		{"q", "Back to network overview", "", nil},
+	}
+
+	lines := style.MustTableToLines(func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, "Action\tDescription")
+		for _, a := range actions {
+			if a.Check != nil && !a.Check(n) {
+				continue
+			}
+			fmt.Fprintf(w, "%s\t%s\n", a.Key, a.Desc)
+		}
+	})
+	fmt.Print(style.Box(51, lines))
+
+	fmt.Print(style.PromptMsg("\nSelect action (or q to exit): "))
+	choiceRaw, _ := r.ReadString('\n')
+	choice := strings.TrimSpace(choiceRaw)
+	for _, a := range actions {
+		if choice == a.Key && (a.Check == nil || a.Check(n)) {
+			return a.Cmd
+		}
+	}
+	fmt.Fprintln(os.Stderr, style.Err("Invalid selection"))
+	return ""
+}
+
+// RunNetworkAction – executes the selected action via the backend;
+// delete asks first and names the VMs that still use the network
+func RunNetworkAction(r *bufio.Reader, be backend.Backend, action Action, n *NetInfo) error {
+	switch action {
+	case ActNetStart:
+		return be.StartNetwork(n.Name)
+	case ActNetStop:
+		if users := networkUsers(be, n.Name); len(users) > 0 {
+			style.Info("Running VMs lose their connection", strings.Join(users, ", "))
+		}
+		return be.StopNetwork(n.Name)
+	case ActNetAutostart:
+		return be.SetNetworkAutostart(n.Name, !n.Autostart)
+	case ActNetDelete:
+		prompt := fmt.Sprintf("Delete network %s?", n.Name)
+		if users := networkUsers(be, n.Name); len(users) > 0 {
+			prompt = fmt.Sprintf("Network %s is used by %s – delete anyway?", n.Name, strings.Join(users, ", "))
+		}
+		ok, err := AskYesNo(r, prompt)
+		if err != nil || !ok {
+			return fmt.Errorf("deletion of %s aborted", n.Name)
+		}
+		if n.Active {
+			if err := be.StopNetwork(n.Name); err != nil {
+				return err
+			}
+		}
+		return be.UndefineNetwork(n.Name)
+	}
+	return fmt.Errorf("unsupported action %q", action)
+}
+
+// networkUsers – names of the VMs with a NIC in the libvirt network name
+func networkUsers(be backend.Backend, name string) []string {
+	doms, err := be.ListDomains()
+	if err != nil {
+		return nil
+	}
+	var users []string
+	for _, d := range doms {
+		raw, err := be.DefinitionXML(d.Name)
+		if err != nil {
+			continue
+		}
+		dom, err := domxml.Parse(raw)
+		if err != nil {
+			continue
+		}
+		if slices.ContainsFunc(dom.Devices.Interfaces, func(i domxml.Interface) bool {
+			return i.Type == "network" && i.Source != nil && i.Source.Network == name
+		}) {
+			users = append(users, d.Name)
+		}
+	}
+	return users
+}
+
+/*
+CreateNetwork – form for a new NAT or isolated network. The suggested
+subnet is the first free 192.168.x.0/24, the DHCP range defaults to the
+whole subnet; subnets of existing networks must not overlap.
+*/
+func CreateNetwork(r *bufio.Reader, be backend.Backend, existing []*NetInfo) error {
+	fmt.Println(style.BoxCenter(51, []string{"NEW VIRTUAL NETWORK"}))
+	var used []netip.Prefix
+	for _, n := range existing {
+		used = append(used, n.subnets...)
+	}
+
+	var spec netxml.Spec
+	name, ok := askValid(r, "Name", "", func(v string) error {
+		if slices.ContainsFunc(existing, func(n *NetInfo) bool { return n.Name == v }) {
+			return fmt.Errorf("network %s already exists", v)
+		}
+		_, err := netxml.Build(netxml.Spec{Name: v, Subnet: "192.168.0.0/24"})
+		return err
+	})
+	if !ok {
+		return nil
+	}
+	spec.Name = name
+
+	if spec.Mode, ok = askValid(r, "Mode (nat or isolated)", netxml.ModeNAT, func(v string) error {
+		if v != netxml.ModeNAT && v != netxml.ModeIsolated {
+			return fmt.Errorf("unknown mode %q", v)
+		}
+		return nil
+	}); !ok {
+		return nil
+	}
+
+	suggested := ""
+	if p, found := netxml.FreeSubnet(used); found {
+		suggested = p.String()
+	}
+	var subnet netip.Prefix
+	if spec.Subnet, ok = askValid(r, "Subnet (CIDR)", suggested, func(v string) error {
+		p, err := netxml.ParseSubnet(v)
+		if err != nil {
+			return err
+		}
+		for i, u := range used {
+			if p.Overlaps(u) {
+				return fmt.Errorf("%s overlaps %s of another network (%s)", p, u, ownerOf(existing, i))
+			}
+		}
+		subnet = p
+		return nil
+	}); !ok {
+		return nil
+	}
+
+	start, end := netxml.DefaultRange(subnet)
+	dhcp, ok := askValid(r, "DHCP range (start-end or none)", start.String()+"-"+end.String(), func(v string) error {
+		if v == "none" {
+			return nil
+		}
+		s, e, _ := strings.Cut(v, "-")
+		probe := spec
+		probe.DHCPStart, probe.DHCPEnd = s, e
+		_, err := netxml.Build(probe)
+		return err
+	})
+	if !ok {
+		return nil
+	}
+	if dhcp != "none" {
+		spec.DHCPStart, spec.DHCPEnd, _ = strings.Cut(dhcp, "-")
+	}
+
+	bridge, _ := utils.Ask(r, os.Stdout, "Bridge name (empty = chosen by libvirt)", "")
+	spec.Bridge = strings.TrimSpace(bridge)
+
+	def, err := netxml.Build(spec)
+	if err != nil {
+		return err
+	}
+	raw, err := def.Marshal()
+	if err != nil {
+		return err
+	}
+	if err := be.DefineNetwork(raw); err != nil {
+		return fmt.Errorf("define network %s: %w", spec.Name, err)
+	}
+	style.Successf("Network %s defined (%s, %s)", spec.Name, spec.Mode, spec.Subnet)
+
+	startNow, err := AskYesNo(r, "Start it now and on every boot (autostart)?")
+	if err != nil || !startNow {
+		return nil
+	}
+	if err := be.StartNetwork(spec.Name); err != nil {
+		return err
+	}
+	return be.SetNetworkAutostart(spec.Name, true)
+}
+
+// askValid asks until check accepts the answer; ENTER takes def,
+// q cancels (ok = false)
+func askValid(r *bufio.Reader, label, def string, check func(string) error) (string, bool) {
+	for {
+		ans, err := utils.Ask(r, os.Stdout, label, def)
+		if err != nil {
+			return "", false
+		}
+		ans = strings.TrimSpace(ans)
+		switch {
+		case ans == "q":
+			return "", false
+		case ans == "" && def == "":
+			continue
+		case ans == "":
+			ans = def
+		}
+		if err := check(ans); err != nil {
+			fmt.Fprintln(os.Stderr, style.Err(err.Error()+" – try again or q to cancel"))
+			continue
+		}
+		return ans, true
+	}
+}
+
+// ownerOf – the network whose subnets contain index i of the combined list
+func ownerOf(nets []*NetInfo, i int) string {
+	for _, n := range nets {
+		if i < len(n.subnets) {
+			return n.Name
+		}
+		i -= len(n.subnets)
+	}
+	return "?"
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,273 @@
+// kvmtools/pools.go
+// last modified: Oct 16 2026
+package kvmtools
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	// internal
+	"configurator/internal/backend"
+	"configurator/internal/poolxml"
+	"configurator/internal/style"
+)
+
+// poolBaseDir is where the form suggests the directory of a new pool
+const poolBaseDir = "/var/lib/libvirt/pools"
+
+// FetchPools – all storage pools with type and directory from their
+// definitions, sorted by name
+func FetchPools(be backend.Backend) ([]*PoolInfo, error) {
+	pools, err := be.ListPools()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*PoolInfo, 0, len(pools))
+	for _, p := range pools {
+		info := &PoolInfo{
+			Name: p.Name, Active: p.Active, Autostart: p.Autostart,
+			Capacity: p.Capacity, Allocation: p.Allocation, Available: p.Available,
+		}
+		if raw, err := be.PoolXML(p.Name); err == nil {
+			if def, err := poolxml.Parse(raw); err == nil {
+				info.Type, info.Path = def.Type, def.Path()
+			}
+		}
+		out = append(out, info)
+	}
+	slices.SortFunc(out, func(a, b *PoolInfo) int { return strings.Compare(a.Name, b.Name) })
+	return out, nil
+}
+
+// printPoolTable – prints the pool list formatted
+func printPoolTable(pools []*PoolInfo) {
+	fmt.Println(style.BoxCenter(100, []string{"STORAGE POOLS"}))
+	lines := style.MustTableToLines(func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, "No.\tName\tState\tAutostart\tType\tDirectory\tCapacity\tAllocation\tFree")
+		for i, p := range pools {
+			capacity, alloc, free := "-", "-", "-"
+			if p.Active {
+				capacity, alloc, free = poolxml.Size(p.Capacity), poolxml.Size(p.Allocation), poolxml.Size(p.Available)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", i+1, p.Name, p.State(),
+				yesNo(p.Autostart), dash(p.Type), dash(p.Path), capacity, alloc, free)
+		}
+	})
+	fmt.Print(style.Box(100, lines))
+}
+
+// PoolMenu – lists the storage pools; pick one for actions or create a new one
+func PoolMenu(r *bufio.Reader, be backend.Backend) {
+	for {
+		pools, err := FetchPools(be)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, style.Colourise("Error reading the pool list: "+err.Error(), style.ColRed))
+			return
+		}
+		if len(pools) == 0 {
+			fmt.Println(style.Hint("No storage pools defined"))
+		} else {
+			printPoolTable(pools)
+		}
+
+		fmt.Print(style.PromptMsg("\nSelect pool number, [n] new directory pool (or q to exit): "))
+		choiceRaw, _ := r.ReadString('\n')
+		choice := strings.TrimSpace(strings.ToLower(choiceRaw))
+		switch choice {
+		case "q", "quit", "":
+			return
+		case "n":
+			if err := CreatePool(r, be, pools); err != nil {
+				fmt.Fprintln(os.Stderr, style.Colourise(err.Error(), style.ColRed))
+			}
+			continue
+		}
+		idx, err := strconv.Atoi(choice)
+		if err != nil || idx < 1 || idx > len(pools) {
+			fmt.Fprintln(os.Stderr, style.Err("Invalid selection"))
+			continue
+		}
+		selected := pools[idx-1]
+
+		action := pickPoolAction(r, selected)
+		if action == "" {
+			continue
+		}
+		if err := RunPoolAction(r, be, action, selected); err != nil {
+			fmt.Fprintln(os.Stderr, style.Colourise(err.Error(), style.ColRed))
+		} else if action != ActPoolVolumes {
+			fmt.Println(style.Ok("Action successfully completed"))
+		}
+	}
+}
+
+// pickPoolAction – only shows permitted actions for the pool's state
+func pickPoolAction(r *bufio.Reader, p *PoolInfo) Action {
+	autostart := "Enable autostart"
+	if p.Autostart {
+		autostart = "Disable autostart"
+	}
+	actions := []struct {
+		Key   string
+		Desc  string
+		Cmd   Action
+		Check func(*PoolInfo) bool // true > allowed
+	}{
+		{"1", "Show volumes", ActPoolVolumes, func(p *PoolInfo) bool { return p.Active }},
+		{"2", "Start", ActPoolStart, func(p *PoolInfo) bool { return !p.Active }},
+		{"3", "Stop", ActPoolStop, func(p *PoolInfo) bool { return p.Active }},
+		{"4", autostart, ActPoolAutostart, nil},
+		{"0", "Delete", ActPoolDelete, nil},
+		{"q", "Back to pool overview", "", nil},
+	}
+
+	lines := style.MustTableToLines(func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, "Action\tDescription")
+		for _, a := range actions {
+			if a.Check != nil && !a.Check(p) {
+				continue
+			}
+			fmt.Fprintf(w, "%s\t%s\n", a.Key, a.Desc)
+		}
+	})
+	fmt.Print(style.Box(51, lines))
+
+	fmt.Print(style.PromptMsg("\nSelect action (or q to exit): "))
+	choiceRaw, _ := r.ReadString('\n')
+	choice := strings.TrimSpace(choiceRaw)
+	for _, a := range actions {
+		if choice == a.Key && (a.Check == nil || a.Check(p)) {
+			return a.Cmd
+		}
+	}
+	fmt.Fprintln(os.Stderr, style.Err("Invalid selection"))
+	return ""
+}
+
+/*
+RunPoolAction – executes the selected action via the backend. Delete
+stops and undefines the pool; the volumes stay on disk, the directory is
+only removed on request and only if it is empty.
+*/
+func RunPoolAction(r *bufio.Reader, be backend.Backend, action Action, p *PoolInfo) error {
+	switch action {
+	case ActPoolVolumes:
+		return showVolumes(be, p)
+	case ActPoolStart:
+		return be.StartPool(p.Name)
+	case ActPoolStop:
+		return be.StopPool(p.Name)
+	case ActPoolAutostart:
+		return be.SetPoolAutostart(p.Name, !p.Autostart)
+	case ActPoolDelete:
+		ok, err := AskYesNo(r, fmt.Sprintf("Delete pool %s? (the volumes stay on disk)", p.Name))
+		if err != nil || !ok {
+			return fmt.Errorf("deletion of %s aborted", p.Name)
+		}
+		if p.Active {
+			if err := be.StopPool(p.Name); err != nil {
+				return err
+			}
+		}
+		if p.Path != "" {
+			rm, _ := AskYesNo(r, fmt.Sprintf("Also remove the directory %s (only if it is empty)?", p.Path))
+			if rm {
+				if err := be.DeletePool(p.Name); err != nil {
+					style.Info("Directory kept", err.Error())
+				}
+			}
+		}
+		return be.UndefinePool(p.Name)
+	}
+	return fmt.Errorf("unsupported action %q", action)
+}
+
+// showVolumes – the volumes of an active pool and the VMs using them
+func showVolumes(be backend.Backend, p *PoolInfo) error {
+	vols, err := be.ListVolumes(p.Name)
+	if err != nil {
+		return err
+	}
+	fmt.Println(style.BoxCenter(100, []string{"VOLUMES IN " + strings.ToUpper(p.Name)}))
+	if len(vols) == 0 {
+		fmt.Println(style.Box(100, []string{"no volumes in " + p.Path}))
+		return nil
+	}
+	users, err := backend.DiskUsers(be)
+	if err != nil {
+		fmt.Println(style.Hint("Users incomplete: " + err.Error()))
+	}
+	lines := style.MustTableToLines(func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, "Name\tCapacity\tAllocation\tUsed by\tPath")
+		for _, v := range vols {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", v.Name, poolxml.Size(v.Capacity),
+				poolxml.Size(v.Allocation), dash(strings.Join(users[backend.CanonicalPath(v.Path)], ", ")), v.Path)
+		}
+	})
+	fmt.Println(style.Box(100, lines))
+	return nil
+}
+
+/*
+CreatePool – form for a new directory pool: defines it, creates the
+directory (pool-build) and starts it; autostart on request. The directory
+must not belong to another pool.
+*/
+func CreatePool(r *bufio.Reader, be backend.Backend, existing []*PoolInfo) error {
+	fmt.Println(style.BoxCenter(51, []string{"NEW DIRECTORY POOL"}))
+
+	name, ok := askValid(r, "Name", "", func(v string) error {
+		if slices.ContainsFunc(existing, func(p *PoolInfo) bool { return p.Name == v }) {
+			return fmt.Errorf("pool %s already exists", v)
+		}
+		return poolxml.CheckName(v)
+	})
+	if !ok {
+		return nil
+	}
+
+	dir, ok := askValid(r, "Directory", filepath.Join(poolBaseDir, name), func(v string) error {
+		v = filepath.Clean(os.ExpandEnv(v))
+		if !filepath.IsAbs(v) {
+			return fmt.Errorf("directory %s must be an absolute path", v)
+		}
+		if i := slices.IndexFunc(existing, func(p *PoolInfo) bool { return p.Path == v }); i >= 0 {
+			return fmt.Errorf("%s is already the directory of pool %s", v, existing[i].Name)
+		}
+		return nil
+	})
+	if !ok {
+		return nil
+	}
+
+	def, err := poolxml.DirPool(name, os.ExpandEnv(dir))
+	if err != nil {
+		return err
+	}
+	raw, err := def.Marshal()
+	if err != nil {
+		return err
+	}
+	if err := be.DefinePool(raw); err != nil {
+		return fmt.Errorf("define pool %s: %w", name, err)
+	}
+	if err := be.BuildPool(name); err != nil {
+		return fmt.Errorf("pool %s is defined, but: %w", name, err)
+	}
+	if err := be.StartPool(name); err != nil {
+		return fmt.Errorf("pool %s is defined, but: %w", name, err)
+	}
+	style.Successf("Pool %s created and started (%s)", name, def.Path())
+
+	auto, err := AskYesNo(r, "Start it on every boot (autostart)?")
+	if err != nil || !auto {
+		return nil
+	}
+	return be.SetPoolAutostart(name, true)
+}
@@ -1,23 +1,24 @@
 // kvmtools/rename.go
-// last modified: Feb 22 2026
+// last modified: Oct 16 2026
 package kvmtools
 
 import (
 	"bufio"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 
+	"configurator/internal/backend"
+	"configurator/internal/domxml"
 	"configurator/internal/style"
 	"configurator/internal/utils"
 )
 
-func RenameVM(r *bufio.Reader, oldName, xmlDir string) error {
-	// Existence check (virsh dominfo)
-	if _, err := exec.Command("virsh", "dominfo", oldName).CombinedOutput(); err != nil {
-		return fmt.Errorf("VM %q nicht gefunden (virsh dominfo): %w", oldName, err)
+func RenameVM(r *bufio.Reader, be backend.Backend, oldName, xmlDir string) error {
+	// Existence check
+	if _, err := be.DomainInfo(oldName); err != nil {
+		return fmt.Errorf("VM %q not found: %w", oldName, err)
 	}
 
 	// ask for new name
@@ -34,39 +35,62 @@ func RenameVM(r *bufio.Reader, oldName, xmlDir string) error {
 		return fmt.Errorf("New name is identical to the old one – nothing to do")
 	}
 
-	// virsh domrename
-	renameCmd := exec.Command("virsh", "domrename", oldName, newName)
-	if out, err := renameCmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("virsh domrename failed: %w – %s", err, string(out))
+	// domrename
+	if err := be.Rename(oldName, newName); err != nil {
+		return fmt.Errorf("rename failed: %w", err)
 	}
 	style.Successf("Successfully renamed VM %s to %s", oldName, newName)
 
-	// rename XML file (if existing) // BUGS!!
-	oldXML := filepath.Join(xmlDir, oldName+".xml")
-	newXML := filepath.Join(xmlDir, newName+".xml")
-	if _, err := os.Stat(oldXML); err == nil {
-		if err := os.Rename(oldXML, newXML); err != nil {
-			style.RedError("XML file could not be renamed", oldXML, err)
-		} else {
-			style.Info("XML file renamed", fmt.Sprintf("%s → %s", oldXML, newXML))
-		}
-	} else {
-		style.RedError("XML file not found (ignore)", oldXML, nil)
-	}
-
-	// rename disk
-	if paths, err := GetDiskPathsViaVirsh(newName); err == nil && len(paths) > 0 {
+	// rename disk and point the definition at the new file – only a file
+	// of this machine can be renamed
+	if !backend.LocalURI(be.URI()) {
+		style.Info("Disk file kept", "it lies on the host of "+be.URI())
+	} else if paths, err := be.DomainDisks(newName); err == nil && len(paths) > 0 {
 		oldDisk := paths[0] // only system disk will be renamed (yet)
-		dir := filepath.Dir(oldDisk)
-		ext := filepath.Ext(oldDisk)
-		newDisk := filepath.Join(dir, newName+ext)
+		newDisk := renamedDiskPath(oldDisk, oldName, newName)
 
-		if err := os.Rename(oldDisk, newDisk); err != nil {
+		if isAttached(be, newName, oldDisk) {
+			style.Info("Disk file kept", oldDisk+" was attached, not created")
+		} else if err := os.Rename(oldDisk, newDisk); err != nil {
 			style.RedError("Disk file could not be renamed", oldDisk, err)
+		} else if err := editDefinition(be, newName, xmlDir, func(d *domxml.Domain) error {
+			if !d.ReplaceDisk(oldDisk, newDisk, "") {
+				return fmt.Errorf("disk %s not in the definition", oldDisk)
+			}
+			return nil
+		}); err != nil {
+			// keep VM and image consistent
+			style.RedError("Definition not updated – disk name restored", oldDisk, err)
+			if err := os.Rename(newDisk, oldDisk); err != nil {
+				style.RedError("Disk file could not be restored", newDisk, err)
+			}
 		} else {
 			style.Info("Disk file renamed", fmt.Sprintf("%s → %s", oldDisk, newDisk))
 		}
 	}
 
+	// saved XML: the current definition under the new name replaces the old file
+	oldXML := filepath.Join(xmlDir, oldName+".xml")
+	if raw, err := be.DefinitionXML(newName); err != nil {
+		style.RedError("XML file not updated", newName, err)
+	} else if err := saveXMLCopy(xmlDir, newName, raw); err != nil {
+		style.RedError("XML file not updated", newName, err)
+	} else {
+		style.Info("XML file written", filepath.Join(xmlDir, newName+".xml"))
+		if err := os.Remove(oldXML); err == nil {
+			style.Info("Old XML file removed", oldXML)
+		}
+	}
+
 	return nil
 }
+
+// renamedDiskPath – "web-system.qcow2" becomes "shop-system.qcow2" when the
+// VM web is renamed to shop; other names become <newName><ext>
+func renamedDiskPath(path, oldName, newName string) string {
+	dir, base := filepath.Split(path)
+	if rest, ok := strings.CutPrefix(base, oldName); ok {
+		return filepath.Join(dir, newName+rest)
+	}
+	return filepath.Join(dir, newName+filepath.Ext(base))
+}
@@ -3,46 +3,91 @@
 // GitHub: 	https://github.com/mrtoadie/
 // Repo: 		https://github.com/mrtoadie/kvm-configurator
 // License: MIT
-// last modification: Feb 22 2026
+// last modification: Oct 16 2026
 package main
 
 import (
 	"bufio"
-	//"errors"
+	"errors"
 	"fmt"
 	"os"
 
 	// internal
+	"configurator/internal/backend"
+	"configurator/internal/cli"
 	"configurator/internal/config"
 	"configurator/internal/engine"
 	"configurator/internal/style"
+	"configurator/internal/ui"
 	"configurator/kvmtools"
 )
 
 // MAIN
 func main() {
-	// [Modul: config] validates if (virt‑install, virsh) is installed
-	if err := config.EnsureAll(config.CmdVirtInstall, config.CmdVirsh); err != nil {
-		// exit if virt-install or virsh are not found
-		style.Err("virt-install not found - verify $PATH")
-		os.Exit(1)
+	// global flags (--connect) come before the command
+	global, args, err := cli.ParseGlobal(os.Args[1:])
+	if err != nil {
+		os.Exit(2)
+	}
+
+	// "configurator config validate" must work on a broken config, too
+	if ran, err := cli.RunStandalone(args); ran {
+		if err != nil {
+			if errors.Is(err, cli.ErrUsage) {
+				os.Exit(2)
+			}
+			style.RedError("Command failed", args[0], err)
+			os.Exit(1)
+		}
+		return
 	}
-	// for debug only
-	//ui.Success("✅ Prereqs OK", "virt-install & virsh FOUND!", "")
 
-	// verify that the configuration file exists in $HOME/.config/kvm-configurator
-	if ok, err := config.Exists(); err != nil || !ok {
+	// without any config file (system, user, project) copy the template to $HOME/.config/kvm-configurator
+	if !config.ExistingLayer() {
 		config.EnsureConfig()
 	}
 
-	// get everything from the YAML file in one go
-	cfg, err := config.LoadAll(config.ConfigFilePath())
-	//cfg, err := config.LoadAll(config.FileConfig) // One call, one result
+	// all layers (system, user, project, drop-ins, KVMCONF_*) in one go
+	cfg, err := global.Load()
 	if err != nil {
-		style.Err("Failed to load configuration")
+		fmt.Fprintln(os.Stderr, style.Err("Failed to load configuration: "+err.Error()))
 		os.Exit(1)
 	}
 
+	// [Modul: config] validates if the tools of the backend (virsh, qemu-img) are installed
+	if err := config.EnsureAll(backend.Requires(cfg.Connection)...); err != nil {
+		// exit if virsh or qemu-img are not found
+		fmt.Fprintln(os.Stderr, style.Err(err.Error()+" - verify $PATH"))
+		os.Exit(1)
+	}
+
+	// swtpm is only needed by VMs with an emulated TPM – warn, don't exit;
+	// for a remote hypervisor it has to be installed there, not here
+	if cfg.UsesTPM() && backend.LocalURI(cfg.Connection.URI) {
+		if err := config.RequireCommand(config.CmdSwtpm); err != nil {
+			fmt.Fprintln(os.Stderr, style.Colourise(err.Error()+" - VMs with a TPM (Windows 11) cannot start", style.ColYellow))
+		}
+	}
+
+	// all hypervisor calls go through this backend
+	be, err := backend.New(cfg.Connection)
+	if err != nil {
+		style.RedError("Backend", cfg.Connection.Backend, err)
+		os.Exit(1)
+	}
+
+	// non-interactive subcommands, e.g. "configurator create --profile archlinux …"
+	if len(args) > 0 {
+		if err := cli.Run(args, cfg, be); err != nil {
+			if errors.Is(err, cli.ErrUsage) {
+				os.Exit(2)
+			}
+			style.RedError("Command failed", args[0], err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// determine xml save path
 	xmlDir := cfg.XmlDir
 	// determine working directory (ISO folder)
@@ -54,23 +99,22 @@ func main() {
 		}
 	}
 
-	// // [Modul: config]
-	osList := cfg.OSList     // the list of supported distributions
-	defaults := cfg.Defaults // global specifications (disk path, size, ...)
-	variantByName := make(map[string]string, len(osList))
-	for _, d := range osList {
-		variantByName[d.Name] = d.ID
+	// active connection for the header
+	uri := be.URI()
+	if uri == "" {
+		uri = "libvirt default"
 	}
 
 	// main menu loop
 	r := bufio.NewReader(os.Stdin)
 	for {
 		//fmt.Println(utils.Colourise("\n=== MAIN MENU ===", utils.ColBlue))
-		fmt.Println(style.BoxCenter(20, []string{"KVM-CONFIGURATOR"}))
+		fmt.Println(style.BoxCenter(max(20, len(uri)), []string{"KVM-CONFIGURATOR", uri}))
 
 		fmt.Println(style.Box(20, []string{
 			"[1] New VM",
 			"[2] KVM-Tools",
+			"[3] Manage profiles",
 			"[0] Exit",
 		}))
 		fmt.Print(style.PromptMsg(" Selection: "))
@@ -90,19 +134,28 @@ func main() {
 			// The actual “launch sequence” for a new VM
 			if err := engine.RunNewVMWorkflow(
 				r,
-				osList,
-				defaults,
-				variantByName,
+				be,
+				cfg,
 				workDir,
 				cfg.IsoPath,
 				cfg.XmlDir,
+				global.Load,
 			); err != nil {
 				// error
 				fmt.Fprintf(os.Stderr, "%sError: %v%s\n",
 					style.ColRed, err, style.ColReset)
 			}
 		case "2":
-			kvmtools.Start(r, xmlDir)
+			kvmtools.Start(r, be, xmlDir)
+		case "3":
+			// profiles are written to the user file, then all layers are merged again
+			if ui.ManageProfiles(r, config.ConfigFilePath(), cfg) {
+				if fresh, err := global.Load(); err != nil {
+					style.RedError("Reload configuration", "", err)
+				} else {
+					cfg = fresh
+				}
+			}
 		default:
 			fmt.Println(style.Err("\nInvalid selection!"))
 		}